@@ -15,13 +15,16 @@ import (
 )
 
 type Config struct {
-	cache_converter.Config `yaml:",inline"`
-	printVersion           bool
-	prodLogging            bool
-	upgradeV3UUID          bool
-	upgradeV3UserData      bool
-	downgradeV4UUID        bool
-	downgradeV4UserData    bool
+	cache_converter.Config
+	printVersion        bool
+	prodLogging         bool
+	upgradeV3UUID       bool
+	upgradeV3UserData   bool
+	downgradeV4UUID     bool
+	downgradeV4UserData bool
+	exportCache         string
+	importCache         string
+	dumpFile            string
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -34,13 +37,59 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.downgradeV4UUID, "downgrade-v4-uuid", false, "run the v4 -> v3 UUID conversion")
 	f.BoolVar(&c.downgradeV4UserData, "downgrade-v4-userdata", false, "run the v4 -> v3 User Data conversion")
 
+	f.StringVar(&c.exportCache, "export", "", "export the v4 cache {uuid|userdata|textures} to the dump-file")
+	f.StringVar(&c.importCache, "import", "", "import the dump-file into the v4 cache {uuid|userdata|textures}")
+	f.StringVar(&c.dumpFile, "dump-file", "-", "Path of the (gzip compressed) dump for export/import (- is stdout/stdin)")
+
 	c.Config.RegisterFlags(f)
 }
 
+func exportCache(cc *cache_converter.CacheConverter, cacheName, path string) error {
+	c, err := cc.Cachev4(cacheName)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return cc.Export(c, os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cc.Export(c, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func importCache(cc *cache_converter.CacheConverter, cacheName, path string) error {
+	c, err := cc.Cachev4(cacheName)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return cc.Import(c, os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return cc.Import(c, f)
+}
+
 func main() {
 
 	var config Config
-	cfg.Parse(&config, "CACHECONV")
+	if err := cfg.Parse(&config, "CACHECONV"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 
 	var logConfig zap.Config
 	if config.prodLogging {
@@ -71,13 +120,30 @@ func main() {
 		os.Exit(0)
 	}
 
-	cc, err := cache_converter.New(config.Config)
+	// Only the caches of the conversion are opened
+	var cacheIDs []string
+	switch {
+	case config.downgradeV4UUID, config.upgradeV3UUID:
+		cacheIDs = []string{"UUID", "Legacy-UUID"}
+	case config.downgradeV4UserData, config.upgradeV3UserData:
+		cacheIDs = []string{"UserData", "Legacy-UserData"}
+	case config.exportCache != "":
+		cacheIDs = []string{config.exportCache}
+	case config.importCache != "":
+		cacheIDs = []string{config.importCache}
+	}
+
+	cc, err := cache_converter.New(config.Config, cacheIDs...)
 	if err != nil {
 		logger.Errorf("Error initialising cacheconv: %v", err)
+		mainLogger.Sync()
+		os.Exit(1)
 	}
+	defer cc.Close()
 
 	logger.Infof("Starting cacheconv %s", version.Info())
 
+	var failed bool
 	switch {
 	case config.downgradeV4UUID:
 		cc.MigrateUUIDV4toV3()
@@ -87,5 +153,22 @@ func main() {
 		cc.MigrateUUIDV3toV4()
 	case config.upgradeV3UserData:
 		cc.MigrateUserDataV3toV4()
+	case config.exportCache != "":
+		if err := exportCache(cc, config.exportCache, config.dumpFile); err != nil {
+			logger.Errorf("Error exporting %s: %v", config.exportCache, err)
+			failed = true
+		}
+	case config.importCache != "":
+		if err := importCache(cc, config.importCache, config.dumpFile); err != nil {
+			logger.Errorf("Error importing %s: %v", config.importCache, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		// os.Exit skips the deferred calls, so the caches are closed first
+		cc.Close()
+		mainLogger.Sync()
+		os.Exit(1)
 	}
 }
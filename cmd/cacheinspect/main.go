@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	_ "github.com/minotar/imgd/pkg/build"
+	"github.com/minotar/imgd/pkg/cache_inspector"
+	"github.com/minotar/imgd/pkg/mcclient/status"
+	"github.com/minotar/imgd/pkg/util/cfg"
+	"github.com/minotar/imgd/pkg/util/log"
+
+	"github.com/prometheus/common/version"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const usage = `Usage: cacheinspect [flags] <command> [args]
+
+Commands:
+  get <uuid|userdata|textures> <key>   Decode a cached value and show its TTL
+  ttl <uuid|userdata|textures> <key>   Show the remaining TTL of a key
+  list <uuid|userdata|textures> [prefix]
+                                       List keys (and TTLs) starting with the prefix
+  status <uuid|userdata> [prefix]      Count entries by their lookup Status
+  ages <uuid|userdata> [prefix]        Histogram of entry ages based on their Timestamp
+  user <username|uuid>                 Follow a user through each cache (why are they Steve?)
+
+The caches are opened read-only, so persistent caches may need skind to be stopped first.
+`
+
+type Config struct {
+	cache_inspector.Config
+	printVersion bool
+	debugLogging bool
+	limit        int
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.printVersion, "version", false, "Print this builds version information")
+	f.BoolVar(&c.debugLogging, "debug", false, "Log the cache initialisation and decode errors")
+	f.IntVar(&c.limit, "limit", 100, "Max keys to show with list (0 is unlimited)")
+
+	c.Config.RegisterFlags(f)
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl == 0 {
+		return "no expiry"
+	}
+	return ttl.Round(time.Second).String()
+}
+
+func run(ci *cache_inspector.CacheInspector, config Config, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough arguments")
+	}
+	command, arg := args[0], args[1]
+	var extra string
+	if len(args) > 2 {
+		extra = args[2]
+	}
+
+	switch command {
+	case "get":
+		entry, err := ci.Get(arg, extra)
+		if err != nil {
+			return err
+		}
+		fmt.Print(entry)
+
+	case "ttl":
+		entry, err := ci.Get(arg, extra)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatTTL(entry.TTL))
+
+	case "list":
+		marker, err := ci.List(arg, extra, "", config.limit, func(key string, ttl time.Duration) {
+			fmt.Printf("%s\t%s\n", key, formatTTL(ttl))
+		})
+		if err != nil {
+			return err
+		}
+		if marker != "" {
+			fmt.Printf("... stopped at the limit of %d (next key is \"%s\")\n", config.limit, marker)
+		}
+
+	case "status":
+		stats, err := ci.Stats(arg, extra, cache_inspector.DefaultAgeBuckets)
+		if err != nil {
+			return err
+		}
+		var statuses []status.Status
+		for s := range stats.Status {
+			statuses = append(statuses, s)
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+		for _, s := range statuses {
+			fmt.Printf("%-18s %d\n", cache_inspector.StatusName(s), stats.Status[s])
+		}
+		fmt.Printf("%-18s %d\n", "Undecodable", stats.Invalid)
+		fmt.Printf("%-18s %d\n", "Total", stats.Total)
+
+	case "ages":
+		stats, err := ci.Stats(arg, extra, cache_inspector.DefaultAgeBuckets)
+		if err != nil {
+			return err
+		}
+		for i, bucket := range stats.AgeBuckets {
+			fmt.Printf("<= %-12s %d\n", bucket, stats.Ages[i])
+		}
+		fmt.Printf(">  %-12s %d\n", stats.AgeBuckets[len(stats.AgeBuckets)-1], stats.Ages[len(stats.AgeBuckets)])
+		fmt.Printf("%-15s %d\n", "Undecodable", stats.Invalid)
+
+	case "user":
+		entries, err := ci.Lookup(arg)
+		for _, entry := range entries {
+			fmt.Println(entry)
+		}
+		if err != nil {
+			return fmt.Errorf("lookup stopped: %s", err)
+		}
+		fmt.Println("Found a cached skin for the user")
+
+	default:
+		return fmt.Errorf("unknown command \"%s\"", command)
+	}
+	return nil
+}
+
+func main() {
+
+	var config Config
+	if err := cfg.Parse(&config, "CACHEINSPECT"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if config.printVersion {
+		fmt.Println(version.Print("cacheinspect"))
+		os.Exit(0)
+	}
+
+	args := pflag.Args()
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Logs go to stderr, leaving stdout for the inspection output
+	logConfig := zap.NewDevelopmentConfig()
+	if !config.debugLogging {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	}
+	mainLogger, err := logConfig.Build()
+	if err != nil {
+		fmt.Printf("Logger failed to init: %+v\n", err)
+		os.Exit(1)
+	}
+	defer mainLogger.Sync() // flushes buffer, if any
+	config.Logger = log.NewZapLogger(mainLogger)
+
+	ci, err := cache_inspector.New(config.Config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening caches: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = run(ci, config, args)
+	ci.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -20,7 +20,7 @@ func init() {
 }
 
 type Config struct {
-	imgd.Config  `yaml:",inline"`
+	imgd.Config
 	printVersion bool
 }
 
@@ -36,7 +36,10 @@ func main() {
 	logger := log.NewZapLogger(mainLogger)
 
 	var config Config
-	cfg.Parse(&config, "IMGD")
+	if err := cfg.Parse(&config, "IMGD"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 	logger.Infof("Config: %+v\n", config)
 
 	config.Logger = logger
@@ -51,6 +54,13 @@ func main() {
 	s, err := imgd.New(config.Config)
 	if err != nil {
 		logger.Errorf("Error initialising imgd: %v", err)
+		os.Exit(1)
+	}
+	// A reload (SIGHUP or admin request) re-reads the config in the same way
+	s.ConfigLoader = func() (imgd.Config, []string, error) {
+		var reloaded Config
+		changed, err := cfg.Reload(&reloaded, "IMGD")
+		return reloaded.Config, changed, err
 	}
 
 	logger.Infof("Starting imgd %s", version.Info())
@@ -20,8 +20,8 @@ func init() {
 }
 
 type Config struct {
-	processd.Config `yaml:",inline"`
-	printVersion    bool
+	processd.Config
+	printVersion bool
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -36,7 +36,10 @@ func main() {
 	logger := log.NewZapLogger(mainLogger)
 
 	var config Config
-	cfg.Parse(&config, "PROCESSD")
+	if err := cfg.Parse(&config, "PROCESSD"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 	logger.Infof("Config: %+v\n", config)
 
 	config.Logger = logger
@@ -52,6 +55,13 @@ func main() {
 	s, err := processd.New(config.Config)
 	if err != nil {
 		logger.Errorf("Error initialising processd: %v", err)
+		os.Exit(1)
+	}
+	// A reload (SIGHUP or admin request) re-reads the config in the same way
+	s.ConfigLoader = func() (processd.Config, []string, error) {
+		var reloaded Config
+		changed, err := cfg.Reload(&reloaded, "PROCESSD")
+		return reloaded.Config, changed, err
 	}
 
 	logger.Infof("Starting processd %s", version.Info())
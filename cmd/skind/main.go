@@ -20,7 +20,7 @@ func init() {
 }
 
 type Config struct {
-	skind.Config `yaml:",inline"`
+	skind.Config
 	printVersion bool
 }
 
@@ -36,7 +36,10 @@ func main() {
 	logger := log.NewZapLogger(mainLogger)
 
 	var config Config
-	cfg.Parse(&config, "SKIND")
+	if err := cfg.Parse(&config, "SKIND"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 	logger.Infof("Config: %+v\n", config)
 
 	config.Logger = logger
@@ -52,6 +55,13 @@ func main() {
 	s, err := skind.New(config.Config)
 	if err != nil {
 		logger.Errorf("Error initialising skind: %v", err)
+		os.Exit(1)
+	}
+	// A reload (SIGHUP or admin request) re-reads the config in the same way
+	s.ConfigLoader = func() (skind.Config, []string, error) {
+		var reloaded Config
+		changed, err := cfg.Reload(&reloaded, "SKIND")
+		return reloaded.Config, changed, err
 	}
 
 	logger.Infof("Starting skind %s", version.Info())
@@ -44,8 +44,9 @@ type BadgerCache struct {
 	*BadgerCacheConfig
 }
 
-// ensure that the cache.Cache interface is implemented
+// ensure that the cache.Cache and cache.Iterator interfaces are implemented
 var _ cache.Cache = new(BadgerCache)
+var _ cache.Iterator = new(BadgerCache)
 
 func NewBadgerCache(cfg *BadgerCacheConfig) (*BadgerCache, error) {
 	cfg.Logger = cfg.Logger.With(
@@ -53,7 +54,13 @@ func NewBadgerCache(cfg *BadgerCacheConfig) (*BadgerCache, error) {
 		"cacheType", "BadgerCache",
 	)
 	cfg.Logger.Infof("initializing BadgerCache \"%s\"", cfg.path)
-	bs, err := badger_store.NewBadgerStore(cfg.path, cfg.Logger)
+	var bs *badger_store.BadgerStore
+	var err error
+	if cfg.ReadOnly {
+		bs, err = badger_store.NewBadgerStoreReadOnly(cfg.path, cfg.Logger)
+	} else {
+		bs, err = badger_store.NewBadgerStore(cfg.path, cfg.Logger)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -134,13 +141,30 @@ func (bc *BadgerCache) TTL(key string) (time.Duration, error) {
 		return 0, cache.ErrNoExpiry
 	}
 
-	now := bc.StoreExpiry.Clock.Now()
+	return bc.expiresAtTTL(expiresAt, bc.StoreExpiry.Clock.Now()), nil
+}
 
+// expiresAtTTL converts the Badger expiresAt into a TTL (always at least 1 Second)
+func (bc *BadgerCache) expiresAtTTL(expiresAt uint64, now time.Time) time.Duration {
 	ttl := time.Unix(int64(expiresAt), 0).UTC().Sub(now) // Could be negative?
 	if ttl < time.Duration(time.Second) {
 		ttl = time.Duration(time.Second)
 	}
-	return ttl, nil
+	return ttl
+}
+
+// Iterate relies on Badger to skip keys which have expired
+func (bc *BadgerCache) Iterate(opts cache.IterateOptions, fn cache.IterateFunc) (string, error) {
+	now := bc.StoreExpiry.Clock.Now()
+
+	return bc.BadgerStore.IterateItems(opts, func(key string, value []byte, expiresAt uint64) error {
+		var ttl time.Duration
+		// An expiresAt of 0 is a non-expiring key, which is a TTL of 0
+		if expiresAt != 0 {
+			ttl = bc.expiresAtTTL(expiresAt, now)
+		}
+		return fn(key, value, ttl)
+	})
 }
 
 func (bc *BadgerCache) Remove(key string) error {
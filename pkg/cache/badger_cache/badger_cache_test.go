@@ -92,6 +92,13 @@ func TestInsertTTLAndFlush(t *testing.T) {
 	test_helpers.InsertTTLAndFlush(cacheTester)
 }
 
+func TestInsertTTLAndIterate(t *testing.T) {
+	cacheTester := newCacheTester(t)
+	defer cacheTester.Cache.Close()
+
+	test_helpers.InsertTTLAndIterate(cacheTester)
+}
+
 /*
 var largeBucket = test_store.NewTestStoreBench()
 var largeBucket2 = test_store.NewTestStoreBench()
@@ -53,8 +53,9 @@ type BoltCache struct {
 	*BoltCacheConfig
 }
 
-// ensure that the cache.Cache interface is implemented
+// ensure that the cache.Cache and cache.Iterator interfaces are implemented
 var _ cache.Cache = new(BoltCache)
+var _ cache.Iterator = new(BoltCache)
 
 func NewBoltCache(cfg *BoltCacheConfig) (*BoltCache, error) {
 	cfg.Logger = cfg.Logger.With(
@@ -63,7 +64,13 @@ func NewBoltCache(cfg *BoltCacheConfig) (*BoltCache, error) {
 		"bucketName", cfg.bucketName,
 	)
 	cfg.Logger.Infof("initializing BoltCache \"%s\"", cfg.path)
-	bs, err := bolt_store.NewBoltStore(cfg.path, cfg.bucketName)
+	var bs *bolt_store.BoltStore
+	var err error
+	if cfg.ReadOnly {
+		bs, err = bolt_store.NewBoltStoreReadOnly(cfg.path, cfg.bucketName)
+	} else {
+		bs, err = bolt_store.NewBoltStore(cfg.path, cfg.bucketName)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +183,21 @@ func (bc *BoltCache) Remove(key string) error {
 	return nil
 }
 
+// Iterate skips keys which have expired, but are yet to be removed by the ExpiryScan
+func (bc *BoltCache) Iterate(opts cache.IterateOptions, fn cache.IterateFunc) (string, error) {
+	now := bc.StoreExpiry.Clock.Now()
+
+	return bc.BoltStore.Iterate(opts, func(key string, value []byte) error {
+		bse := store_expiry.DecodeStoreEntry([]byte(key), value)
+		if bse.HasExpired(now) {
+			return nil
+		}
+		// A cache.ErrNoExpiry is a TTL of 0
+		ttl, _ := bse.TTL(now)
+		return fn(key, bse.Value, ttl)
+	})
+}
+
 func firstOrSeek(c *bolt.Cursor, keyMarker string) (k, v []byte) {
 	if keyMarker == "" {
 		return c.First()
@@ -110,6 +110,13 @@ func TestInsertTTLAndFlush(t *testing.T) {
 	test_helpers.InsertTTLAndFlush(cacheTester)
 }
 
+func TestInsertTTLAndIterate(t *testing.T) {
+	cacheTester := newCacheTester(t)
+	defer cacheTester.Cache.Close()
+
+	test_helpers.InsertTTLAndIterate(cacheTester)
+}
+
 // Tests the ExpiryScan with a smaller chunk size / max scan size
 // This is because the COMPACTION_MAX_SCAN is much larger than the iteration count
 func TestExpiryScanIteration(t *testing.T) {
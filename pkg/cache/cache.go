@@ -6,6 +6,7 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"strings"
@@ -31,9 +32,32 @@ type Cache interface {
 	Stop()
 }
 
+// Iterator is optionally implemented by a Cache which is able to enumerate its keys
+type Iterator interface {
+	// Iterate calls fn for each unexpired key matching the options, in ascending key order.
+	// The returned marker is the next key to be visited and can be used as the
+	// StartKey to resume the iteration. An empty marker means every key was visited.
+	Iterate(opts IterateOptions, fn IterateFunc) (marker string, err error)
+}
+
+// IterateFunc is called with each key/value visited by an Iterator along with the remaining TTL
+// A TTL of 0 means the key has no expiry (the same as InsertTTL)
+// Returning ErrStopIteration ends the iteration early without an error
+type IterateFunc func(key string, value []byte, ttl time.Duration) error
+
+type IterateOptions = storage.IterateOptions
+
+// ContextRetriever is optionally implemented by a Cache which uses the request context on a Retrieve (eg. for tracing)
+type ContextRetriever interface {
+	RetrieveCtx(ctx context.Context, key string) ([]byte, error)
+}
+
 type CacheConfig struct {
 	Name   string
 	Logger util_log.Logger
+	// ReadOnly opens a persistent backend without write access (eg. for inspection)
+	// Start should not be called on a read-only cache
+	ReadOnly bool
 }
 
 func (c *CacheConfig) RegisterFlags(f *flag.FlagSet, cacheID string) {
@@ -45,6 +69,23 @@ var (
 	ErrNoExpiry = errors.New("key does not have an associated Expiry/TTL")
 )
 
+// Iterate will use the Iterator of the cache, if it has one
+func Iterate(cache Cache, opts IterateOptions, fn IterateFunc) (string, error) {
+	iterator, ok := cache.(Iterator)
+	if !ok {
+		return "", ErrIterationNotSupported
+	}
+	return iterator.Iterate(opts, fn)
+}
+
+// RetrieveCtx will use the ContextRetriever of the cache, if it has one
+func RetrieveCtx(ctx context.Context, cache Cache, key string) ([]byte, error) {
+	if retriever, ok := cache.(ContextRetriever); ok {
+		return retriever.RetrieveCtx(ctx, key)
+	}
+	return cache.Retrieve(key)
+}
+
 func InsertKV(cache Cache, key, value string, ttl time.Duration) error {
 	return cache.InsertTTL(key, []byte(value), ttl)
 }
@@ -61,7 +102,9 @@ func InsertGob(cache Cache, key string, e interface{}, ttl time.Duration) error
 }
 
 var (
-	RetrieveKV  = storage.RetrieveKV
-	RetrieveGob = storage.RetrieveGob
-	ErrNotFound = storage.ErrNotFound
+	RetrieveKV               = storage.RetrieveKV
+	RetrieveGob              = storage.RetrieveGob
+	ErrNotFound              = storage.ErrNotFound
+	ErrStopIteration         = storage.ErrStopIteration
+	ErrIterationNotSupported = storage.ErrIterationNotSupported
 )
@@ -1,6 +1,8 @@
 package lru_cache
 
 import (
+	"flag"
+	"strings"
 	"time"
 
 	"github.com/minotar/imgd/pkg/cache"
@@ -19,6 +21,10 @@ type LruCacheConfig struct {
 	size int
 }
 
+func (c *LruCacheConfig) RegisterFlags(f *flag.FlagSet, cacheID string) {
+	f.IntVar(&c.size, strings.ToLower("cache."+cacheID+".lru-size"), 1024, "Entries of the LRU cache")
+}
+
 func NewLruCacheConfig(size int, cacheCfg cache.CacheConfig) *LruCacheConfig {
 	return &LruCacheConfig{
 		size:        size,
@@ -27,6 +33,7 @@ func NewLruCacheConfig(size int, cacheCfg cache.CacheConfig) *LruCacheConfig {
 }
 
 var _ cache.Cache = new(LruCache)
+var _ cache.Iterator = new(LruCache)
 
 func NewLruCache(cfg *LruCacheConfig) (*LruCache, error) {
 	cfg.Logger.Infof("initializing LruCache with size %d", cfg.size)
@@ -92,6 +99,24 @@ func (lc *LruCache) TTL(key string) (time.Duration, error) {
 	return ttl, nil
 }
 
+// Iterate skips keys which have expired, but are yet to be removed by the MemoryExpiry
+func (lc *LruCache) Iterate(opts cache.IterateOptions, fn cache.IterateFunc) (string, error) {
+	now := lc.MemoryExpiry.Clock.Now()
+	records := lc.MemoryExpiry.Records()
+
+	return lc.LruStore.Iterate(opts, func(key string, value []byte) error {
+		var ttl time.Duration
+		// Keys without a record have no expiry, which is a TTL of 0
+		if record, ok := records[key]; ok {
+			if record.HasExpired(now) {
+				return nil
+			}
+			ttl, _ = record.TTL(now)
+		}
+		return fn(key, value, ttl)
+	})
+}
+
 // Both MemoryExpiry and LruStore have Len methods - we need to specify which here
 func (lc *LruCache) Len() uint {
 	return lc.LruStore.Len()
@@ -84,6 +84,13 @@ func TestInsertTTLAndFlush(t *testing.T) {
 	test_helpers.InsertTTLAndFlush(cacheTester)
 }
 
+func TestInsertTTLAndIterate(t *testing.T) {
+	cacheTester := newCacheTester(t, 500)
+	defer cacheTester.Cache.Close()
+
+	test_helpers.InsertTTLAndIterate(cacheTester)
+}
+
 // Todo: test filled cached
 
 /*
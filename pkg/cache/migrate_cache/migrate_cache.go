@@ -1,38 +1,57 @@
-// Migrate from bolt to badger
+// Migrate from one cache.Cache to another (eg. bolt to badger, or bolt to a new bolt path)
+// Reads fall through to the OldCache while the keys are copied across in the background
 package migrate_cache
 
 import (
-	"errors"
 	"flag"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/minotar/imgd/pkg/cache"
-	"github.com/minotar/imgd/pkg/cache/badger_cache"
-	"github.com/minotar/imgd/pkg/cache/bolt_cache"
-	store_expiry "github.com/minotar/imgd/pkg/cache/util/expiry/store"
+	cache_metrics "github.com/minotar/imgd/pkg/cache/util/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-const MIN_RECACHE_TTL = time.Duration(1) * time.Minute
+const (
+	// Keys with less than this TTL remaining are not worth migrating
+	MIN_RECACHE_TTL = time.Duration(1) * time.Minute
+	// Prefix of the keys used to track the migration progress (these are never migrated)
+	MIGRATION_KEY_PREFIX = "MINOTAR_MIGRATION_"
+	// How long the migration progress is retained
+	MIGRATION_STATUS_TTL = time.Minute * time.Duration(10080)
+)
 
 type MigrateCacheConfig struct {
+	migrationCounter  *prometheus.CounterVec
+	migrationFinished prometheus.Gauge
 	cache.CacheConfig
-	bolt_cache.BoltCacheConfig
-	badger_cache.BadgerCacheConfig
-	OldCache *bolt_cache.BoltCache
-	NewCache *badger_cache.BadgerCache
+	OldCache cache.Cache
+	NewCache cache.Cache
 
 	performMigration bool
+	// Max keys per second to migrate (0 is unlimited)
+	rateLimit int
+	// Number of keys between saving the progress marker
+	markerInterval int
 }
 
 func (c *MigrateCacheConfig) RegisterFlags(f *flag.FlagSet, cacheID string) {
-	f.BoolVar(&c.performMigration, strings.ToLower("cache."+cacheID+".migrate"), false, "Peform Bolt -> Badger migration")
+	flagPath := strings.ToLower("cache." + cacheID + ".migrate")
+	f.BoolVar(&c.performMigration, flagPath, false, "Peform migration from the old to new cache")
+	f.IntVar(&c.rateLimit, flagPath+"-rate-limit", 0, "Max keys per second to migrate (0 is unlimited)")
+	f.IntVar(&c.markerInterval, flagPath+"-marker-interval", 1000, "Number of keys between saving the migration progress")
 }
 
 type MigrateCache struct {
 	*MigrateCacheConfig
+	stopMigration chan bool
+	// Closed by Stop to cancel a delayed compaction start
+	stopping     chan struct{}
+	stoppingOnce sync.Once
+	// The migration and NewCache inserts running in the background, which Stop waits for
+	background sync.WaitGroup
 }
 
 var _ cache.Cache = new(MigrateCache)
@@ -41,27 +60,28 @@ func NewMigrateCache(cfg *MigrateCacheConfig) (*MigrateCache, error) {
 	cfg.Logger = cfg.Logger.With(
 		"cacheName", cfg.Name,
 		"cacheType", "MigrateCache",
-		"cacheParent", "MigrateCache", // The caches will overwrite the cacheType
 	)
 	cfg.Logger.Infof("initializing MigrateCache")
-	mc := &MigrateCache{MigrateCacheConfig: cfg}
-
-	cfg.BoltCacheConfig.Logger = cfg.Logger
-	cfg.BadgerCacheConfig.Logger = cfg.Logger
 
-	bolt, err := bolt_cache.NewBoltCache(&cfg.BoltCacheConfig)
-	if err != nil {
-		return nil, err
+	if cfg.OldCache == nil || cfg.NewCache == nil {
+		return nil, fmt.Errorf("MigrateCache requires both an old and new cache")
+	}
+	if _, ok := cfg.OldCache.(cache.Iterator); cfg.performMigration && !ok {
+		return nil, fmt.Errorf("MigrateCache old cache \"%s\" does not support iteration", cfg.OldCache.Name())
+	}
+	if cfg.markerInterval < 1 {
+		cfg.markerInterval = 1000
 	}
 
-	badger, err := badger_cache.NewBadgerCache(&cfg.BadgerCacheConfig)
-	if err != nil {
-		return nil, err
+	mc := &MigrateCache{
+		MigrateCacheConfig: cfg,
+		stopMigration:      make(chan bool, 1),
+		stopping:           make(chan struct{}),
 	}
+	mc.migrationCounter = cache_metrics.NewCacheMigrationCounter("MigrateCache", mc.Name())
+	mc.migrationFinished = cache_metrics.NewCacheMigrationFinished("MigrateCache", mc.Name())
 
-	mc.OldCache = bolt
-	mc.NewCache = badger
-	cfg.Logger.Infof("initialized MigrateCache \"%s\"", mc.Name())
+	cfg.Logger.Infof("initialized MigrateCache \"%s\" from \"%s\" to \"%s\"", mc.Name(), cfg.OldCache.Name(), cfg.NewCache.Name())
 	return mc, nil
 }
 
@@ -69,6 +89,10 @@ func (mc *MigrateCache) Name() string {
 	return mc.CacheConfig.Name
 }
 
+func (mc *MigrateCache) caches() []cache.Cache {
+	return []cache.Cache{mc.NewCache, mc.OldCache}
+}
+
 func (mc *MigrateCache) Insert(key string, value []byte) error {
 	return mc.NewCache.Insert(key, value)
 }
@@ -79,7 +103,7 @@ func (mc *MigrateCache) InsertTTL(key string, value []byte, ttl time.Duration) e
 
 func (mc *MigrateCache) Retrieve(key string) ([]byte, error) {
 	var errors []error
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Retrieving \"%s\" from cache %d \"%s\"", key, i, c.Name())
 
 		value, err := c.Retrieve(key)
@@ -97,7 +121,11 @@ func (mc *MigrateCache) Retrieve(key string) ([]byte, error) {
 			ttl, err := mc.OldCache.TTL(key)
 			if err == nil {
 				mc.Logger.Debugf("Adding \"%s\" to NewCache", key)
-				go mc.NewCache.InsertTTL(key, value, ttl)
+				mc.background.Add(1)
+				go func() {
+					defer mc.background.Done()
+					mc.NewCache.InsertTTL(key, value, ttl)
+				}()
 			}
 		}
 
@@ -114,7 +142,7 @@ func (mc *MigrateCache) Retrieve(key string) ([]byte, error) {
 // Probably won't be used too much
 func (mc *MigrateCache) TTL(key string) (time.Duration, error) {
 	var errors []error
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Getting TTL of key \"%s\" from cache %d (%s)", key, i, c.Name())
 
 		ttl, err := c.TTL(key)
@@ -135,7 +163,7 @@ func (mc *MigrateCache) TTL(key string) (time.Duration, error) {
 
 func (mc *MigrateCache) Remove(key string) error {
 	var errors []error
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Removing key \"%s\" from cache %d (%s)", key, i, c.Name())
 
 		err := c.Remove(key)
@@ -153,7 +181,7 @@ func (mc *MigrateCache) Remove(key string) error {
 
 func (mc *MigrateCache) Flush() error {
 	var errors []error
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Flushing cache %d (%s)", i, c.Name())
 
 		err := c.Flush()
@@ -170,7 +198,7 @@ func (mc *MigrateCache) Flush() error {
 
 func (mc *MigrateCache) Len() uint {
 	var maxLen uint
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Getting length of cache %d (%s)", i, c.Name())
 
 		cacheLen := c.Len()
@@ -184,7 +212,7 @@ func (mc *MigrateCache) Len() uint {
 
 func (mc *MigrateCache) Size() uint64 {
 	var maxSize uint64
-	for i, c := range []cache.Cache{mc.NewCache, mc.OldCache} {
+	for i, c := range mc.caches() {
 		mc.Logger.Debugf("Getting size of cache %d (%s)", i, c.Name())
 
 		cacheSize := c.Size()
@@ -196,23 +224,32 @@ func (mc *MigrateCache) Size() uint64 {
 	return maxSize
 }
 
+// Iterate uses the OldCache until the migration has finished
+func (mc *MigrateCache) Iterate(opts cache.IterateOptions, fn cache.IterateFunc) (string, error) {
+	if finished, _ := mc.GetMigrationStatus(); finished {
+		return cache.Iterate(mc.NewCache, opts, fn)
+	}
+	return cache.Iterate(mc.OldCache, opts, fn)
+}
+
 const (
 	MIGRATION_FINISHED     = "it has finished"
 	MIGRATION_NOT_FINISHED = "not finished"
 )
 
+// The migration status is stored in the NewCache so it survives restarts
 func (mc *MigrateCache) SetMigrationStatus(finished bool, keyMarker string) error {
 	bool_value := MIGRATION_NOT_FINISHED
 	if finished {
 		bool_value = MIGRATION_FINISHED
 	}
 
-	err := mc.InsertTTL("MINOTAR_MIGRATION_BOOL", []byte(bool_value), time.Minute*time.Duration(10080))
+	err := mc.NewCache.InsertTTL(MIGRATION_KEY_PREFIX+"BOOL", []byte(bool_value), MIGRATION_STATUS_TTL)
 	if err != nil {
 		return err
 	}
 
-	err = mc.InsertTTL("MINOTAR_MIGRATION_MARKER", []byte(keyMarker), time.Minute*time.Duration(10080))
+	err = mc.NewCache.InsertTTL(MIGRATION_KEY_PREFIX+"MARKER", []byte(keyMarker), MIGRATION_STATUS_TTL)
 	if err != nil {
 		return err
 	}
@@ -221,7 +258,7 @@ func (mc *MigrateCache) SetMigrationStatus(finished bool, keyMarker string) erro
 }
 
 func (mc *MigrateCache) GetMigrationStatus() (finished bool, keyMarker string) {
-	bool_value, err := mc.Retrieve("MINOTAR_MIGRATION_BOOL")
+	bool_value, err := mc.NewCache.Retrieve(MIGRATION_KEY_PREFIX + "BOOL")
 	if err != nil {
 		mc.Logger.Infof("Failure to get migration status: %v", err)
 		return
@@ -230,7 +267,7 @@ func (mc *MigrateCache) GetMigrationStatus() (finished bool, keyMarker string) {
 		return true, ""
 	}
 
-	keyMarkerBytes, err := mc.Retrieve("MINOTAR_MIGRATION_MARKER")
+	keyMarkerBytes, err := mc.NewCache.Retrieve(MIGRATION_KEY_PREFIX + "MARKER")
 	if err != nil {
 		mc.Logger.Infof("Failure to get migration marker: %v", err)
 		return
@@ -239,16 +276,26 @@ func (mc *MigrateCache) GetMigrationStatus() (finished bool, keyMarker string) {
 	return false, string(keyMarkerBytes)
 }
 
-func firstOrSeek(c *bolt.Cursor, keyMarker string) (k, v []byte) {
-	if keyMarker == "" {
-		return c.First()
-	} else {
-		return c.Seek([]byte(keyMarker))
+// migrateKey copies a single key into the NewCache, returning the result for the metrics
+func (mc *MigrateCache) migrateKey(key string, value []byte, ttl time.Duration) string {
+	if strings.HasPrefix(key, MIGRATION_KEY_PREFIX) {
+		return "skipped_status"
+	}
+	// A TTL of 0 is no expiry, so is always migrated
+	if ttl != 0 && ttl < MIN_RECACHE_TTL {
+		return "skipped_ttl"
 	}
+	// A key already in the NewCache will be the same or fresher
+	if _, err := mc.NewCache.TTL(key); err != cache.ErrNotFound {
+		return "skipped_present"
+	}
+	if err := mc.NewCache.InsertTTL(key, value, ttl); err != nil {
+		mc.Logger.Warnf("Unable to migrate \"%s\": %v", key, err)
+		return "error"
+	}
+	return "migrated"
 }
 
-var ErrCompactionFinished = errors.New("compaction has finished")
-
 func (mc *MigrateCache) Migrate() {
 	logger := mc.Logger.With("operation", "migration")
 
@@ -256,50 +303,74 @@ func (mc *MigrateCache) Migrate() {
 	migrateCompleted, keyMarker := mc.GetMigrationStatus()
 	if migrateCompleted {
 		logger.Info("Migration reports it has already completed")
+		mc.migrationFinished.Set(1)
 		return
 	}
 
 	var scannedCount, errorCount int
 
-	dbLength := int(mc.OldCache.Len())
-	logger = logger.With("boltLength", dbLength)
-	logger.Info("Starting migration from Bolt -> Badger")
-	start := time.Now()
-
-	err := mc.OldCache.DB.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket([]byte(mc.OldCache.Bucket)).Cursor()
+	// The throttle ticks once for each key we can migrate
+	var throttle <-chan time.Time
+	if mc.rateLimit > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(mc.rateLimit))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
 
-		var k []byte
-		for k, v := firstOrSeek(c, keyMarker); k != nil; k, v = c.Next() {
-			scannedCount++
-			key := string(k)
+	logger = logger.With(
+		"oldCache", mc.OldCache.Name(),
+		"oldLength", mc.OldCache.Len(),
+		"newCache", mc.NewCache.Name(),
+	)
+	logger.Infof("Starting migration from key marker \"%s\"", keyMarker)
+	start := time.Now()
 
-			if scannedCount%1000 == 0 {
-				logger.Infof("Marking current keyMarker %s", key)
-				mc.SetMigrationStatus(false, key)
+	opts := cache.IterateOptions{StartKey: keyMarker, Limit: mc.markerInterval}
+	for {
+		// The key the migration was stopped at (it was not migrated, so is where to resume from)
+		var stoppedKey string
+		marker, err := cache.Iterate(mc.OldCache, opts, func(key string, value []byte, ttl time.Duration) error {
+			select {
+			case <-mc.stopMigration:
+				stoppedKey = key
+				return cache.ErrStopIteration
+			default:
+			}
+			if throttle != nil {
+				<-throttle
 			}
 
-			present, _ := mc.NewCache.Key(key)
-			if !present {
-				bse := store_expiry.DecodeStoreEntry(k, v)
-				ttl, _ := bse.TTL(mc.OldCache.StoreExpiry.Clock.Now())
-				err := mc.NewCache.InsertTTL(key, bse.Value, ttl)
-				if err != nil {
-					errorCount++
-				}
+			scannedCount++
+			result := mc.migrateKey(key, value, ttl)
+			if result == "error" {
+				errorCount++
 			}
+			mc.migrationCounter.WithLabelValues(result).Inc()
+			return nil
+		})
+		if err != nil {
+			logger.Errorf("Migration failed at key marker \"%s\": %v", marker, err)
+			break
 		}
-
-		if k == nil {
-			return ErrCompactionFinished
+		// The marker from Iterate would be the key after the one stopped at
+		opts.StartKey = marker
+		if stoppedKey != "" {
+			opts.StartKey = stoppedKey
 		}
 
-		return nil
-	})
+		if opts.StartKey == "" {
+			logger.Info("Marking migration completion")
+			mc.SetMigrationStatus(true, "")
+			mc.migrationFinished.Set(1)
+			break
+		}
 
-	if err == ErrCompactionFinished {
-		logger.Info("Marking migration completion")
-		mc.SetMigrationStatus(true, "")
+		logger.Infof("Marking current keyMarker %s", opts.StartKey)
+		mc.SetMigrationStatus(false, opts.StartKey)
+		if stoppedKey != "" {
+			logger.Info("Migration was stopped")
+			break
+		}
 	}
 
 	dur := time.Since(start)
@@ -309,27 +380,46 @@ func (mc *MigrateCache) Migrate() {
 		"duration", dur,
 	)
 	logger.Info("Key Migration finished")
-
-	// Now trigger the compaction
-	mc.NewCache.Start()
 }
 
 func (mc *MigrateCache) Start() {
 	mc.Logger.Info("starting MigrateCache")
 	if mc.performMigration {
 		mc.Logger.Info("Migration is enabled - starting")
-		go mc.Migrate()
+		mc.background.Add(1)
+		go func() {
+			defer mc.background.Done()
+			mc.Migrate()
+			select {
+			case <-mc.stopping:
+				// The migration was stopped, so the NewCache is about to be closed
+			default:
+				// Now trigger the compaction
+				mc.NewCache.Start()
+			}
+		}()
 	} else {
 		// Delay the compaction starting
 		// Running compaction and massive inserts concurrently can cause issues
 		go func() {
-			time.Sleep(time.Minute * time.Duration(30))
-			mc.NewCache.Start()
+			select {
+			case <-time.After(time.Minute * time.Duration(30)):
+				mc.NewCache.Start()
+			case <-mc.stopping:
+			}
 		}()
 	}
 }
 
+// Stop waits for the migration to save its progress (and any NewCache inserts) before stopping the NewCache
 func (mc *MigrateCache) Stop() {
+	mc.stoppingOnce.Do(func() { close(mc.stopping) })
+	// Signal an in-progress migration to save its progress (non-blocking if there isn't one)
+	select {
+	case mc.stopMigration <- true:
+	default:
+	}
+	mc.background.Wait()
 	mc.NewCache.Stop()
 }
 
@@ -0,0 +1,150 @@
+package migrate_cache
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	"github.com/minotar/imgd/pkg/cache/util/test_helpers"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func newBackendCache(t *testing.T, clock *test_helpers.MockClock, name string, size int) *lru_cache.LruCache {
+	logger := log.NewBuiltinLogger(1)
+	logger.Named(name)
+	cache, err := lru_cache.NewLruCache(lru_cache.NewLruCacheConfig(size,
+		cache.CacheConfig{
+			Name:   name,
+			Logger: logger,
+		},
+	))
+	if err != nil {
+		t.Fatalf("Error creating LruCache: %s", err)
+	}
+
+	cache.MemoryExpiry.Clock = clock
+	return cache
+}
+
+func newMigrateCache(t *testing.T, size int) (*MigrateCache, []string) {
+	clock := test_helpers.MockedUTC()
+	oldCache := newBackendCache(t, clock, "old", size)
+	// Allow room for the migration status keys
+	newCache := newBackendCache(t, clock, "new", size+10)
+
+	logger := log.NewBuiltinLogger(1)
+	logger.Named("MigrateCacheTest")
+
+	mc, err := NewMigrateCache(&MigrateCacheConfig{
+		CacheConfig: cache.CacheConfig{
+			Name:   "MigrateCacheTest",
+			Logger: logger,
+		},
+		OldCache:         oldCache,
+		NewCache:         newCache,
+		performMigration: true,
+		markerInterval:   size / 10,
+	})
+	if err != nil {
+		t.Fatalf("Error creating MigrateCache: %s", err)
+	}
+
+	// Key i has a TTL of i*30 seconds (so key 0 has no expiry)
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = test_helpers.RandString(32)
+		oldCache.InsertTTL(keys[i], []byte("value_"+keys[i]), time.Duration(i)*30*time.Second)
+	}
+
+	return mc, keys
+}
+
+func checkMigrated(t *testing.T, mc *MigrateCache, key string, i int) {
+	value, err := mc.NewCache.Retrieve(key)
+	if err != nil {
+		t.Errorf("Key %s (%d) should have been migrated: %s", key, i, err)
+		return
+	}
+	if string(value) != "value_"+key {
+		t.Errorf("Key %s (%d) was not the expected value: %s", key, i, value)
+	}
+
+	ttl, err := mc.NewCache.TTL(key)
+	if i == 0 && err != cache.ErrNoExpiry {
+		t.Errorf("Key %s (%d) should have no expiry: %v", key, i, err)
+	} else if i != 0 && ttl != time.Duration(i)*30*time.Second {
+		t.Errorf("Key %s (%d) TTL was %s", key, i, ttl)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	mc, keys := newMigrateCache(t, 100)
+	defer mc.Close()
+
+	mc.Migrate()
+
+	for i, key := range keys {
+		// A TTL of 30 seconds is below the MIN_RECACHE_TTL
+		if i == 1 {
+			if _, err := mc.NewCache.Retrieve(key); err != cache.ErrNotFound {
+				t.Errorf("Key %s (%d) should not have been migrated: %v", key, i, err)
+			}
+			continue
+		}
+		checkMigrated(t, mc, key, i)
+	}
+
+	if finished, _ := mc.GetMigrationStatus(); !finished {
+		t.Errorf("Migration status should be finished")
+	}
+}
+
+func TestMigrateResume(t *testing.T) {
+	mc, keys := newMigrateCache(t, 100)
+	defer mc.Close()
+
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	marker := sorted[len(sorted)/2]
+	mc.SetMigrationStatus(false, marker)
+
+	mc.Migrate()
+
+	for i, key := range keys {
+		if key < marker {
+			if _, err := mc.NewCache.Retrieve(key); err != cache.ErrNotFound {
+				t.Errorf("Key %s (%d) before the marker should not have been migrated: %v", key, i, err)
+			}
+		} else if i != 1 {
+			checkMigrated(t, mc, key, i)
+		}
+	}
+}
+
+func TestMigrateStopResume(t *testing.T) {
+	mc, keys := newMigrateCache(t, 100)
+	defer mc.Close()
+
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+
+	// The stop is seen when visiting the first key, so it should not be skipped when resuming
+	mc.stopMigration <- true
+	mc.Migrate()
+
+	if finished, marker := mc.GetMigrationStatus(); finished || marker != sorted[0] {
+		t.Fatalf("Stopped migration should be marked at the first key %s, not: %v %s", sorted[0], finished, marker)
+	}
+
+	mc.Migrate()
+
+	for i, key := range keys {
+		if i != 1 {
+			checkMigrated(t, mc, key, i)
+		}
+	}
+}
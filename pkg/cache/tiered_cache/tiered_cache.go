@@ -1,16 +1,29 @@
 package tiered_cache
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/minotar/imgd/pkg/cache"
+	cache_metrics "github.com/minotar/imgd/pkg/cache/util/metrics"
+	"github.com/minotar/imgd/pkg/util/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const MIN_RECACHE_TTL = time.Duration(1) * time.Minute
 
 type TieredCache struct {
 	*TieredCacheConfig
+	// Retrieve results of each tier (by index)
+	tierRetrieve     []*prometheus.CounterVec
+	backfillCounter  *prometheus.CounterVec
+	backfillSkipped  *prometheus.CounterVec
+	backfillDuration prometheus.Observer
+	// In-progress back-fills, which Stop waits for
+	backfills sync.WaitGroup
 }
 
 type TieredCacheConfig struct {
@@ -19,10 +32,20 @@ type TieredCacheConfig struct {
 }
 
 var _ cache.Cache = new(TieredCache)
+var _ cache.Iterator = new(TieredCache)
+var _ cache.ContextRetriever = new(TieredCache)
+
+var tracer = tracing.Tracer("tiered_cache")
 
 func NewTieredCache(cfg *TieredCacheConfig) (*TieredCache, error) {
 	cfg.Logger.Infof("initializing TieredCache with %d cache(s)", len(cfg.Caches))
 	tc := &TieredCache{TieredCacheConfig: cfg}
+	for i, c := range cfg.Caches {
+		tc.tierRetrieve = append(tc.tierRetrieve, cache_metrics.NewCacheTierRetrieveCounter("TieredCache", tc.Name(), i, c.Name()))
+	}
+	tc.backfillCounter = cache_metrics.NewCacheBackfillCounter("TieredCache", tc.Name())
+	tc.backfillSkipped = cache_metrics.NewCacheBackfillSkipped("TieredCache", tc.Name())
+	tc.backfillDuration = cache_metrics.NewCacheBackfillDuration("TieredCache", tc.Name())
 	cfg.Logger.Infof("initialized TieredCache \"%s\"", tc.Name())
 	return tc, nil
 }
@@ -67,42 +90,70 @@ func (tc *TieredCache) updateCaches(cacheID int, key string, value []byte) {
 	if err == cache.ErrNotFound {
 		// Likely that the key expired within the split second since?
 		tc.Logger.Infof("Cache %d (%s) reports key \"%s\" is now a cache.ErrNotFound", cacheID, validCache.Name(), key)
+		tc.backfillSkipped.WithLabelValues("not_found").Inc()
 		return
 	} else if err == cache.ErrNoExpiry {
 		// It's a key which doesn't have an expiry set - possibly badly added to the Cache?
 		tc.Logger.Warnf("Cache %d (%s) reports key \"%s\" had no TTL/Expiry - not re-adding", cacheID, validCache.Name(), key)
+		tc.backfillSkipped.WithLabelValues("no_expiry").Inc()
 		return
 	} else if err != nil {
 		// This is a cache related error (vs. a missing key/expiry)
 		tc.Logger.Errorf("Cache %d (%s) reports key \"%s\" with TTL err: %s\n", cacheID, validCache.Name(), key, err)
+		tc.backfillSkipped.WithLabelValues("ttl_error").Inc()
 		return
 	}
 
 	if ttl < MIN_RECACHE_TTL {
 		tc.Logger.Debugf("TTL of key \"%s\" was less than a minute - not re-adding", key)
+		tc.backfillSkipped.WithLabelValues("min_ttl").Inc()
 		return
 	}
 
 	// cacheID was the cache the data came from, so we insert in the caches before that
-	tc.cacheInsert(cacheID, key, value, ttl)
+	start := time.Now()
+	errors := tc.cacheInsert(cacheID, key, value, ttl)
+	tc.backfillDuration.Observe(time.Since(start).Seconds())
+	tc.backfillCounter.WithLabelValues("inserted").Add(float64(cacheID - len(errors)))
+	tc.backfillCounter.WithLabelValues("error").Add(float64(len(errors)))
 }
 
 func (tc *TieredCache) Retrieve(key string) ([]byte, error) {
+	return tc.RetrieveCtx(context.Background(), key)
+}
+
+// RetrieveCtx records a span for each tier that is tried and stops once the context ends
+func (tc *TieredCache) RetrieveCtx(ctx context.Context, key string) ([]byte, error) {
 	var errors []error
 	for i, c := range tc.Caches {
+		if err := ctx.Err(); err != nil {
+			// No point trying the slower tiers for an abandoned request
+			return nil, err
+		}
 		tc.Logger.Debugf("Retrieving \"%s\" from cache %d \"%s\"", key, i, c.Name())
-		value, err := c.Retrieve(key)
+		tierCtx, span := tracer.Start(ctx, "TieredCache Retrieve "+c.Name(), tracing.CacheAttributes(c.Name(), "retrieve"))
+		span.SetAttributes(attribute.Int("cache.tier", i))
+		value, err := cache.RetrieveCtx(tierCtx, c, key)
+		span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+		tc.recordTierRetrieve(i, err)
 		if err == cache.ErrNotFound {
+			span.End()
 			// errors logic at end handles ErrNotFound
 			continue
-		} else if err != nil {
+		}
+		tracing.EndSpan(span, err)
+		if err != nil {
 			// This is a cache related error (vs. a missing key)
 			tc.Logger.Errorf("Error retrieving key \"%s\" from cache %d (%s): %s", key, i, c.Name(), err)
 			errors = append(errors, err)
 			continue
 		}
 		// We had a hit - we should update the earlier caches
-		go tc.updateCaches(i, key, value)
+		tc.backfills.Add(1)
+		go func(i int) {
+			defer tc.backfills.Done()
+			tc.updateCaches(i, key, value)
+		}(i)
 
 		return value, nil
 	}
@@ -114,6 +165,22 @@ func (tc *TieredCache) Retrieve(key string) ([]byte, error) {
 	return nil, cache.ErrNotFound
 }
 
+// recordTierRetrieve counts the hit/miss/error of a tier
+func (tc *TieredCache) recordTierRetrieve(tier int, err error) {
+	if tier >= len(tc.tierRetrieve) {
+		// A tier was appended to the Caches after the TieredCache was created
+		return
+	}
+	switch {
+	case err == nil:
+		tc.tierRetrieve[tier].WithLabelValues("hit").Inc()
+	case err == cache.ErrNotFound:
+		tc.tierRetrieve[tier].WithLabelValues("miss").Inc()
+	default:
+		tc.tierRetrieve[tier].WithLabelValues("error").Inc()
+	}
+}
+
 // Probably won't be used too much
 func (tc *TieredCache) TTL(key string) (time.Duration, error) {
 	var errors []error
@@ -135,6 +202,19 @@ func (tc *TieredCache) TTL(key string) (time.Duration, error) {
 	return 0, errors[len(errors)-1]
 }
 
+// Iterate uses the last cache which supports iteration
+// Inserts go to every cache, so the last (and typically largest) cache is expected to be the most complete
+func (tc *TieredCache) Iterate(opts cache.IterateOptions, fn cache.IterateFunc) (string, error) {
+	for i := len(tc.Caches) - 1; i >= 0; i-- {
+		c := tc.Caches[i]
+		if iterator, ok := c.(cache.Iterator); ok {
+			tc.Logger.Debugf("Iterating cache %d (%s)", i, c.Name())
+			return iterator.Iterate(opts, fn)
+		}
+	}
+	return "", cache.ErrIterationNotSupported
+}
+
 func (tc *TieredCache) Remove(key string) error {
 	var errors []error
 	for i, c := range tc.Caches {
@@ -202,8 +282,10 @@ func (tc *TieredCache) Start() {
 	}
 }
 
+// Stop waits for any in-progress back-fills before stopping each cache
 func (tc *TieredCache) Stop() {
 	tc.Logger.Info("stopping TieredCache")
+	tc.backfills.Wait()
 	for i, c := range tc.Caches {
 		tc.Logger.Infof("stopping cache %d \"%s\"", i, c.Name())
 		c.Stop()
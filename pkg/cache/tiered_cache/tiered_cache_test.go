@@ -2,11 +2,14 @@ package tiered_cache
 
 import (
 	"testing"
+	"time"
 
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/cache/lru_cache"
 	"github.com/minotar/imgd/pkg/cache/util/test_helpers"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func newBackendCache(t *testing.T, clock *test_helpers.MockClock, name string, size int) *lru_cache.LruCache {
@@ -111,3 +114,56 @@ func TestInsertTTLAndFlush(t *testing.T) {
 
 	test_helpers.InsertTTLAndFlush(cacheTester)
 }
+
+func TestInsertTTLAndIterate(t *testing.T) {
+	cacheTester := newCacheTester(t, 500)
+	defer cacheTester.Cache.Close()
+
+	test_helpers.InsertTTLAndIterate(cacheTester)
+}
+
+func TestTierMetrics(t *testing.T) {
+	clock := test_helpers.MockedUTC()
+	c1 := newBackendCache(t, clock, "metrics0", 10)
+	c2 := newBackendCache(t, clock, "metrics1", 10)
+
+	tc, err := NewTieredCache(&TieredCacheConfig{
+		Caches: []cache.Cache{c1, c2},
+		CacheConfig: cache.CacheConfig{
+			Name:   "TieredCacheMetrics",
+			Logger: log.NewBuiltinLogger(1),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error creating TieredCache: %s", err)
+	}
+
+	c2.InsertTTL("long", []byte("value"), time.Hour)
+	c2.InsertTTL("short", []byte("value"), time.Second)
+	tc.Retrieve("long")
+	tc.Retrieve("short")
+	tc.Retrieve("missing")
+	// Wait for the back-fill goroutines to finish
+	tc.backfills.Wait()
+
+	metrics := []struct {
+		name     string
+		counter  prometheus.Counter
+		expected float64
+	}{
+		{"tier 0 miss", tc.tierRetrieve[0].WithLabelValues("miss"), 3},
+		{"tier 1 hit", tc.tierRetrieve[1].WithLabelValues("hit"), 2},
+		{"tier 1 miss", tc.tierRetrieve[1].WithLabelValues("miss"), 1},
+		{"back-fill inserted", tc.backfillCounter.WithLabelValues("inserted"), 1},
+		{"back-fill min TTL skip", tc.backfillSkipped.WithLabelValues("min_ttl"), 1},
+	}
+	for _, m := range metrics {
+		if value := testutil.ToFloat64(m.counter); value != m.expected {
+			t.Errorf("Metric %s should be %v, not %v", m.name, m.expected, value)
+		}
+	}
+
+	if _, err := c1.Retrieve("long"); err != nil {
+		t.Errorf("Key should have been back-filled into tier 0: %v", err)
+	}
+}
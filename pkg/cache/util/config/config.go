@@ -8,12 +8,13 @@ import (
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/cache/badger_cache"
 	"github.com/minotar/imgd/pkg/cache/bolt_cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
 	"github.com/minotar/imgd/pkg/cache/migrate_cache"
 	"github.com/minotar/imgd/pkg/util/log"
 )
 
 const (
-	CACHE_LIST    = "{bolt|badger|migrate}"
+	CACHE_LIST    = "{bolt|badger|lru|migrate}"
 	CACHE_DEFAULT = "bolt"
 )
 
@@ -24,23 +25,85 @@ type Config struct {
 
 	bolt_cache.BoltCacheConfig
 	badger_cache.BadgerCacheConfig
+	lru_cache.LruCacheConfig
 	migrate_cache.MigrateCacheConfig
-}
 
-func (c *Config) RegisterFlags(f *flag.FlagSet, cacheID string) {
+	// The old/new caches used by the "migrate" backend
+	MigrateFrom *Config
+	MigrateTo   *Config
+}
 
-	f.StringVar(&c.CacheType, strings.ToLower("cache."+cacheID+".backend"), CACHE_DEFAULT, "Backend cache to use "+CACHE_LIST)
+func (c *Config) registerBackendFlags(f *flag.FlagSet, cacheID, defaultType string) {
+	f.StringVar(&c.CacheType, strings.ToLower("cache."+cacheID+".backend"), defaultType, "Backend cache to use "+CACHE_LIST)
 	c.CacheConfig.RegisterFlags(f, cacheID)
 
 	c.BoltCacheConfig.RegisterFlags(f, cacheID)
 	c.BadgerCacheConfig.RegisterFlags(f, cacheID)
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet, cacheID string) {
+	c.RegisterFlagsWithBackend(f, cacheID, CACHE_DEFAULT)
+}
+
+// RegisterFlagsWithBackend registers the flags with another default backend (eg. "none" for an optional cache)
+func (c *Config) RegisterFlagsWithBackend(f *flag.FlagSet, cacheID, defaultType string) {
+	c.registerBackendFlags(f, cacheID, defaultType)
+	// The migrate old/new caches cannot be an LRU
+	c.LruCacheConfig.RegisterFlags(f, cacheID)
 	c.MigrateCacheConfig.RegisterFlags(f, cacheID)
+
+	// When unset, the migration is from Bolt -> Badger using the above flags
+	c.MigrateFrom = &Config{}
+	c.MigrateTo = &Config{}
+	c.MigrateFrom.registerBackendFlags(f, cacheID+"-From", "")
+	c.MigrateTo.registerBackendFlags(f, cacheID+"-To", "")
+}
+
+// Validate checks the backend of the cache (and of the migrate old/new caches)
+func (c *Config) Validate() error {
+	switch strings.ToLower(c.CacheType) {
+	case "bolt", "badger", "lru", "none":
+	case "migrate":
+		for _, backendCfg := range []*Config{c.MigrateFrom, c.MigrateTo} {
+			if backendCfg == nil {
+				continue
+			}
+			switch strings.ToLower(backendCfg.CacheType) {
+			case "", "bolt", "badger":
+			default:
+				return fmt.Errorf("cache \"%s\" can only migrate from/to {bolt|badger}, not \"%s\"", c.Name, backendCfg.CacheType)
+			}
+		}
+	default:
+		return fmt.Errorf("cache \"%s\" backend \"%s\" should be one of %s (or none)", c.Name, c.CacheType, CACHE_LIST)
+	}
+	return nil
+}
+
+// newMigrateBackend creates the old/new cache for the "migrate" backend
+// Without a backend set, the parent Config is used with the defaultType
+func newMigrateBackend(cfg *Config, backendCfg *Config, defaultType string) (cache.Cache, error) {
+	if backendCfg == nil || backendCfg.CacheType == "" {
+		backendCfg = &Config{
+			CacheType:         defaultType,
+			CacheConfig:       cfg.CacheConfig,
+			BoltCacheConfig:   cfg.BoltCacheConfig,
+			BadgerCacheConfig: cfg.BadgerCacheConfig,
+		}
+	}
+	if strings.ToLower(backendCfg.CacheType) == "migrate" {
+		return nil, fmt.Errorf("cannot migrate to or from another migrate cache")
+	}
+	backendCfg.Logger = cfg.Logger.With("cacheParent", cfg.CacheConfig.Name)
+	backendCfg.ReadOnly = cfg.ReadOnly
+	return NewCache(backendCfg)
 }
 
 func NewCache(cfg *Config) (cache.Cache, error) {
 	cfg.CacheConfig.Logger = cfg.Logger
 	cfg.BoltCacheConfig.CacheConfig = cfg.CacheConfig
 	cfg.BadgerCacheConfig.CacheConfig = cfg.CacheConfig
+	cfg.LruCacheConfig.CacheConfig = cfg.CacheConfig
 	cfg.MigrateCacheConfig.CacheConfig = cfg.CacheConfig
 
 	switch strings.ToLower(cfg.CacheType) {
@@ -48,9 +111,19 @@ func NewCache(cfg *Config) (cache.Cache, error) {
 		return bolt_cache.NewBoltCache(&cfg.BoltCacheConfig)
 	case "badger":
 		return badger_cache.NewBadgerCache(&cfg.BadgerCacheConfig)
+	case "lru":
+		return lru_cache.NewLruCache(&cfg.LruCacheConfig)
 	case "migrate":
-		cfg.MigrateCacheConfig.BoltCacheConfig = cfg.BoltCacheConfig
-		cfg.MigrateCacheConfig.BadgerCacheConfig = cfg.BadgerCacheConfig
+		oldCache, err := newMigrateBackend(cfg, cfg.MigrateFrom, "bolt")
+		if err != nil {
+			return nil, fmt.Errorf("creating old cache: %s", err)
+		}
+		newCache, err := newMigrateBackend(cfg, cfg.MigrateTo, "badger")
+		if err != nil {
+			return nil, fmt.Errorf("creating new cache: %s", err)
+		}
+		cfg.MigrateCacheConfig.OldCache = oldCache
+		cfg.MigrateCacheConfig.NewCache = newCache
 		return migrate_cache.NewMigrateCache(&cfg.MigrateCacheConfig)
 	case "none":
 		return nil, nil
@@ -3,6 +3,7 @@ package expiry
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,7 +23,8 @@ type Expiry struct {
 	compactorFunc     func()
 	closer            chan bool
 	compactorInterval time.Duration
-	running           bool
+	// Read by an in-progress compaction (in the compactor goroutine), so is accessed atomically
+	running int32
 }
 
 type Options struct {
@@ -52,22 +54,21 @@ func NewExpiry(options *Options) (*Expiry, error) {
 
 // IsRunning can access the running state readonly
 func (e *Expiry) IsRunning() bool {
-	return e.running
+	return atomic.LoadInt32(&e.running) == 1
 }
 
 func (e *Expiry) Start() {
-	if !e.IsRunning() {
+	if atomic.CompareAndSwapInt32(&e.running, 0, 1) {
 		go e.runCompactor()
-		e.running = true
 	}
 }
 
+// Stop blocks until the compactor has exited, so the store can then be safely closed
 func (e *Expiry) Stop() {
-	if e.IsRunning() {
-		// Signal to the runCompactor it should stop ticking
+	// Setting to false first so an in-progress compaction can stop
+	if atomic.CompareAndSwapInt32(&e.running, 1, 0) {
+		// Signal to the runCompactor it should stop ticking (received once any compaction has returned)
 		e.closer <- true
-		// Setting to false so an in-progress compaction can stop
-		e.running = false
 	}
 }
 
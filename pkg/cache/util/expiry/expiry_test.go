@@ -53,3 +53,34 @@ func TestCompactor(t *testing.T) {
 	}
 
 }
+
+func TestStopWaitsForCompaction(t *testing.T) {
+	started := make(chan bool)
+	var interrupted bool
+	var expiry *Expiry
+
+	expiry, _ = NewExpiry(&Options{
+		Clock:             realClock{},
+		CompactorInterval: time.Minute,
+		CompactorFunc: func() {
+			started <- true
+			// A compaction checks IsRunning to exit early
+			for expiry.IsRunning() {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(5 * time.Millisecond)
+			interrupted = true
+		},
+	})
+
+	expiry.Start()
+	<-started
+	expiry.Stop()
+
+	if !interrupted {
+		t.Errorf("Stop should wait for the in-progress compaction to return")
+	}
+	if expiry.IsRunning() {
+		t.Errorf("Expiry should not be running after Stop()")
+	}
+}
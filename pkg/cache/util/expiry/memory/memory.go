@@ -153,6 +153,18 @@ func (m *MemoryExpiry) GetTTL(key string) (time.Duration, error) {
 	return rec.TTL(m.Clock.Now())
 }
 
+// Records returns a snapshot of the expiry records, keyed by key
+func (m *MemoryExpiry) Records() map[string]expiry.ExpiryRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := make(map[string]expiry.ExpiryRecord, len(m.records))
+	for _, rec := range m.records {
+		records[rec.Key] = rec
+	}
+	return records
+}
+
 func (m *MemoryExpiry) Len() uint {
 	return uint(len(m.records))
 }
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -31,6 +33,55 @@ var (
 			Help:      "Total number of expired records.",
 		}, []string{"type", "cache"},
 	)
+	cacheMigrationCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "migration_keys_total",
+			Help:      "Total number of keys scanned by a cache migration, by result.",
+		}, []string{"type", "cache", "result"},
+	)
+	cacheTierRetrieveCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "tier_retrieve_total",
+			Help:      "Total number of retrieves from each tier of a tiered cache, by result (hit/miss/error).",
+		}, []string{"type", "cache", "tier", "tier_name", "result"},
+	)
+	cacheBackfillCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "backfill_inserts_total",
+			Help:      "Total number of inserts back-filling the earlier tiers of a tiered cache, by result.",
+		}, []string{"type", "cache", "result"},
+	)
+	cacheBackfillSkipped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "backfill_skipped_total",
+			Help:      "Total number of back-fills skipped, by reason (eg. the TTL was too short).",
+		}, []string{"type", "cache", "reason"},
+	)
+	cacheBackfillDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "backfill_duration_seconds",
+			Help:      "Time (in seconds) back-filling the earlier tiers of a tiered cache took.",
+			Buckets:   DefBuckets,
+		}, []string{"type", "cache"},
+	)
+	cacheMigrationFinished = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "cache",
+			Name:      "migration_finished",
+			Help:      "Whether the cache migration has finished (1) or not (0).",
+		}, []string{"type", "cache"},
+	)
 )
 
 func NewCacheOperationDuration(cacheType, cacheName string) prometheus.ObserverVec {
@@ -47,6 +98,51 @@ func NewCacheExpiredCounter(cacheType, cacheName string) *prometheus.CounterVec
 	})
 }
 
+func NewCacheMigrationCounter(cacheType, cacheName string) *prometheus.CounterVec {
+	return cacheMigrationCounter.MustCurryWith(prometheus.Labels{
+		"type":  cacheType,
+		"cache": cacheName,
+	})
+}
+
+func NewCacheMigrationFinished(cacheType, cacheName string) prometheus.Gauge {
+	return cacheMigrationFinished.With(prometheus.Labels{
+		"type":  cacheType,
+		"cache": cacheName,
+	})
+}
+
+// NewCacheTierRetrieveCounter is curried with the tier, leaving the "result" label
+func NewCacheTierRetrieveCounter(cacheType, cacheName string, tier int, tierName string) *prometheus.CounterVec {
+	return cacheTierRetrieveCounter.MustCurryWith(prometheus.Labels{
+		"type":      cacheType,
+		"cache":     cacheName,
+		"tier":      strconv.Itoa(tier),
+		"tier_name": tierName,
+	})
+}
+
+func NewCacheBackfillCounter(cacheType, cacheName string) *prometheus.CounterVec {
+	return cacheBackfillCounter.MustCurryWith(prometheus.Labels{
+		"type":  cacheType,
+		"cache": cacheName,
+	})
+}
+
+func NewCacheBackfillSkipped(cacheType, cacheName string) *prometheus.CounterVec {
+	return cacheBackfillSkipped.MustCurryWith(prometheus.Labels{
+		"type":  cacheType,
+		"cache": cacheName,
+	})
+}
+
+func NewCacheBackfillDuration(cacheType, cacheName string) prometheus.Observer {
+	return cacheBackfillDuration.With(prometheus.Labels{
+		"type":  cacheType,
+		"cache": cacheName,
+	})
+}
+
 func NewCacheSizeGauge(cacheType, cacheName string, f func() uint64) {
 	gaugeFunc := func() float64 {
 		return float64(f())
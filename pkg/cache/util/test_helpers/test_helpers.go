@@ -3,6 +3,8 @@ package test_helpers
 import (
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -269,3 +271,102 @@ func InsertTTLAndFlush(cacheTester CacheTester) {
 		cacheTester.Tester.Errorf("Cache length should have been %d, not: %d", 0, cacheLen)
 	}
 }
+
+func (ct *CacheTester) IterateKeys(opts cache.IterateOptions) (visited []string, marker string) {
+	marker, err := cache.Iterate(ct.Cache, opts, func(key string, value []byte, _ time.Duration) error {
+		visited = append(visited, key)
+		if string(value) != fmt.Sprint("value_", key) {
+			ct.Tester.Errorf("Key %s was not the expected value: %s", key, value)
+		}
+		return nil
+	})
+	if err != nil {
+		ct.Tester.Errorf("Iterate should not have returned an error: %s", err)
+	}
+	return
+}
+
+func (ct *CacheTester) CompareKeys(name string, visited, expected []string) {
+	if len(visited) != len(expected) {
+		ct.Tester.Errorf("%s should have visited %d keys, not: %d", name, len(expected), len(visited))
+		return
+	}
+	for i, key := range expected {
+		if visited[i] != key {
+			ct.Tester.Errorf("%s key %d should have been %s, not: %s", name, i, key, visited[i])
+		}
+	}
+}
+
+func InsertTTLAndIterate(cacheTester CacheTester) {
+	sorted := AddSortedString(DebugInsertTTL(cacheTester.Cache), cacheTester.Iterations)
+	noExpiry := sorted[0]
+
+	// Iteration is in key order (rather than TTL order)
+	keys := make([]string, len(sorted))
+	copy(keys, sorted)
+	sort.Strings(keys)
+
+	// Iterate everything, checking the TTL
+	var visited []string
+	marker, err := cache.Iterate(cacheTester.Cache, cache.IterateOptions{}, func(key string, _ []byte, ttl time.Duration) error {
+		visited = append(visited, key)
+		if key == noExpiry && ttl != 0 {
+			cacheTester.Tester.Errorf("Non expiring key %s should have a TTL of 0, not: %s", key, ttl)
+		} else if key != noExpiry && ttl <= 0 {
+			cacheTester.Tester.Errorf("Expiring key %s should have a positive TTL, not: %s", key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		cacheTester.Tester.Errorf("Iterate should not have returned an error: %s", err)
+	}
+	if marker != "" {
+		cacheTester.Tester.Errorf("Full Iterate should return an empty marker, not: %s", marker)
+	}
+	cacheTester.CompareKeys("Full Iterate", visited, keys)
+
+	// Iterate in chunks using the marker to resume
+	visited = nil
+	opts := cache.IterateOptions{Limit: cacheTester.Iterations / 10}
+	for {
+		chunk, marker := cacheTester.IterateKeys(opts)
+		if len(chunk) > opts.Limit {
+			cacheTester.Tester.Errorf("Limited Iterate visited %d keys, more than %d", len(chunk), opts.Limit)
+		}
+		visited = append(visited, chunk...)
+		if marker == "" {
+			break
+		}
+		opts.StartKey = marker
+	}
+	cacheTester.CompareKeys("Resumed Iterate", visited, keys)
+
+	// Iterate by prefix
+	prefix := keys[len(keys)/2][:1]
+	var expected []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			expected = append(expected, key)
+		}
+	}
+	visited, _ = cacheTester.IterateKeys(cache.IterateOptions{Prefix: prefix})
+	cacheTester.CompareKeys("Prefix Iterate", visited, expected)
+
+	// Stopping should return the following key as the marker
+	stopAt := len(keys) / 2
+	visited = nil
+	marker, err = cache.Iterate(cacheTester.Cache, cache.IterateOptions{}, func(key string, _ []byte, _ time.Duration) error {
+		visited = append(visited, key)
+		if len(visited) > stopAt {
+			return cache.ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		cacheTester.Tester.Errorf("Stopped Iterate should not have returned an error: %s", err)
+	}
+	if marker != keys[stopAt+1] {
+		cacheTester.Tester.Errorf("Stopped Iterate marker should have been %s, not: %s", keys[stopAt+1], marker)
+	}
+}
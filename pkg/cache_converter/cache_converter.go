@@ -1,10 +1,12 @@
 // cache_converter is the go between to migrate to/from v3/4
 // To read the source data, it needs to loop through all it's keys
-// For this reason, it will likely only ever support Redis (on v3) and BoltCache on v4
+// For this reason, it will likely only ever support Redis (on v3) and a cache.Iterator on v4
 package cache_converter
 
 import (
 	"flag"
+	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -14,11 +16,9 @@ import (
 	"github.com/minotar/imgd/pkg/cache_converter/legacy_storage"
 	"github.com/minotar/imgd/pkg/cache_converter/legacy_storage/radix"
 
-	"github.com/boltdb/bolt"
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/cache/bolt_cache"
 	cache_config "github.com/minotar/imgd/pkg/cache/util/config"
-	store_expiry "github.com/minotar/imgd/pkg/cache/util/expiry/store"
 )
 
 type IteratingProcessor func(k string, v []byte, ttl time.Duration)
@@ -38,11 +38,12 @@ type Config struct {
 	Logger log.Logger
 	MinTTL time.Duration
 
-	CacheUUIDv4     *cache_config.Config `yaml:"cache_uuid"`
-	CacheUserDatav4 *cache_config.Config `yaml:"cache_userdata"`
+	CacheUUIDv4     *cache_config.Config
+	CacheUserDatav4 *cache_config.Config
+	CacheTexturesv4 *cache_config.Config
 
-	CacheUUIDv3     *RedisConfig `yaml:"cachev3_uuid"`
-	CacheUserDatav3 *RedisConfig `yaml:"cachev3_userdata"`
+	CacheUUIDv3     *RedisConfig
+	CacheUserDatav3 *RedisConfig
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -51,119 +52,143 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 
 	c.CacheUUIDv4 = &cache_config.Config{}
 	c.CacheUserDatav4 = &cache_config.Config{}
+	c.CacheTexturesv4 = &cache_config.Config{}
 
 	c.CacheUUIDv3 = &RedisConfig{}
 	c.CacheUserDatav3 = &RedisConfig{}
 
 	c.CacheUUIDv4.RegisterFlags(f, "UUID")
 	c.CacheUserDatav4.RegisterFlags(f, "UserData")
+	c.CacheTexturesv4.RegisterFlags(f, "Textures")
 
 	c.CacheUUIDv3.RegisterFlags(f, "Legacy-UUID")
 	c.CacheUserDatav3.RegisterFlags(f, "Legacy-UserData")
 }
 
+func (c *Config) Validate() error {
+	for _, cacheCfg := range []*cache_config.Config{c.CacheUUIDv4, c.CacheUserDatav4, c.CacheTexturesv4} {
+		if err := cacheCfg.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type CacheConverter struct {
 	Cfg      Config
 	Cachesv4 struct {
 		UUID     cache.Cache
 		UserData cache.Cache
+		Textures cache.Cache
 	}
 	Cachesv3 struct {
 		UUID     legacy_storage.Storage
 		UserData legacy_storage.Storage
 	}
-	Now time.Time
 }
 
-func New(cfg Config) (*CacheConverter, error) {
+// New opens the caches with the given IDs (as in their flags), eg. "Legacy-UUID" and "UUID" to convert the UUIDs
+// The IDs are {uuid|userdata|textures|legacy-uuid|legacy-userdata}, and any other cache is left unopened (nil)
+func New(cfg Config, cacheIDs ...string) (*CacheConverter, error) {
+	open := make(map[string]bool)
+	for _, cacheID := range cacheIDs {
+		switch cacheID = strings.ToLower(cacheID); cacheID {
+		case "uuid", "userdata", "textures", "legacy-uuid", "legacy-userdata":
+			open[cacheID] = true
+		default:
+			return nil, fmt.Errorf("unknown cache \"%s\" {uuid|userdata|textures|legacy-uuid|legacy-userdata}", cacheID)
+		}
+	}
 
-	// Caches v3
-	cacheUUIDv3, err := radix.New(radix.RedisConfig{
-		Network: "tcp",
-		Address: cfg.CacheUUIDv3.Address,
-		Auth:    cfg.CacheUUIDv3.Auth,
-		DB:      cfg.CacheUUIDv3.DB,
-		Size:    10,
-	})
-	if err != nil {
-		cfg.Logger.Errorf("Unable to create cache UUIDv3: %v", err)
+	cacheConverter := &CacheConverter{
+		Cfg: cfg,
 	}
 
-	cacheUserDatav3, err := radix.New(radix.RedisConfig{
-		Network: "tcp",
-		Address: cfg.CacheUserDatav3.Address,
-		Auth:    cfg.CacheUserDatav3.Auth,
-		DB:      cfg.CacheUserDatav3.DB,
-		Size:    10,
-	})
-	if err != nil {
-		cfg.Logger.Errorf("Unable to create cache UserDatav3: %v", err)
+	// Caches v3
+	if open["legacy-uuid"] {
+		cacheUUIDv3, err := radix.New(radix.RedisConfig{
+			Network: "tcp",
+			Address: cfg.CacheUUIDv3.Address,
+			Auth:    cfg.CacheUUIDv3.Auth,
+			DB:      cfg.CacheUUIDv3.DB,
+			Size:    10,
+		})
+		if err != nil {
+			cfg.Logger.Errorf("Unable to create cache UUIDv3: %v", err)
+		}
+		cacheConverter.Cachesv3.UUID = cacheUUIDv3
 	}
 
-	// Caches v4
-	cfg.CacheUUIDv4.Logger = cfg.Logger
-	cacheUUIDv4, err := cache_config.NewCache(cfg.CacheUUIDv4)
-	if err != nil {
-		cfg.Logger.Panicf("Unable to create cache UUIDv4: %v", err)
+	if open["legacy-userdata"] {
+		cacheUserDatav3, err := radix.New(radix.RedisConfig{
+			Network: "tcp",
+			Address: cfg.CacheUserDatav3.Address,
+			Auth:    cfg.CacheUserDatav3.Auth,
+			DB:      cfg.CacheUserDatav3.DB,
+			Size:    10,
+		})
+		if err != nil {
+			cfg.Logger.Errorf("Unable to create cache UserDatav3: %v", err)
+		}
+		cacheConverter.Cachesv3.UserData = cacheUserDatav3
 	}
-	// Skip running the compactor
-	//cacheUUIDv4.Start()
 
-	cfg.CacheUserDatav4.Logger = cfg.Logger
-	cacheUserDatav4, _ := cache_config.NewCache(cfg.CacheUserDatav4)
-	if err != nil {
-		cfg.Logger.Panicf("Unable to create cache UserDatav4: %v", err)
+	// Caches v4 (skip running the compactor, ie. they are not Started)
+	if open["uuid"] {
+		cfg.CacheUUIDv4.Logger = cfg.Logger
+		cacheUUIDv4, err := cache_config.NewCache(cfg.CacheUUIDv4)
+		if err != nil {
+			cfg.Logger.Panicf("Unable to create cache UUIDv4: %v", err)
+		}
+		cacheConverter.Cachesv4.UUID = cacheUUIDv4
 	}
-	// Skip running the compactor
-	//cacheUserDatav4.Start()
 
-	cacheConverter := &CacheConverter{
-		Cfg: cfg,
-		Now: time.Now(),
+	if open["userdata"] {
+		cfg.CacheUserDatav4.Logger = cfg.Logger
+		cacheUserDatav4, err := cache_config.NewCache(cfg.CacheUserDatav4)
+		if err != nil {
+			cfg.Logger.Panicf("Unable to create cache UserDatav4: %v", err)
+		}
+		cacheConverter.Cachesv4.UserData = cacheUserDatav4
 	}
 
-	cacheConverter.Cachesv3.UUID = cacheUUIDv3
-	cacheConverter.Cachesv3.UserData = cacheUserDatav3
-	cacheConverter.Cachesv4.UUID = cacheUUIDv4
-	cacheConverter.Cachesv4.UserData = cacheUserDatav4
+	if open["textures"] {
+		cfg.CacheTexturesv4.Logger = cfg.Logger
+		cacheTexturesv4, err := cache_config.NewCache(cfg.CacheTexturesv4)
+		if err != nil {
+			cfg.Logger.Panicf("Unable to create cache Texturesv4: %v", err)
+		}
+		cacheConverter.Cachesv4.Textures = cacheTexturesv4
+	}
 
 	return cacheConverter, nil
 }
 
-func (cc *CacheConverter) boltIterator(bc *bolt_cache.BoltCache, processor IteratingProcessor) {
+func (cc *CacheConverter) cacheIterator(c cache.Cache, processor IteratingProcessor) {
 
 	var count int
 
-	err := bc.DB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bc.Bucket))
-
-		return b.ForEach(func(k, v []byte) error {
-			count++
-			key := string(k)
-			key = strings.ToLower(key)
-			data := make([]byte, len(v))
-			copy(data, v)
-			bse := store_expiry.DecodeStoreEntry(k, data)
-			ttl, err := bse.TTL(cc.Now)
-			if err != nil {
-				cc.Cfg.Logger.Warnf("%s key threw a TTL error: %v", key, err)
-				return nil
-			}
-			if ttl < cc.Cfg.MinTTL {
-				cc.Cfg.Logger.Debugf("Skipping %s as TTL %s was less than %s", key, ttl, cc.Cfg.MinTTL)
-				return nil
-			}
-
-			processor(key, bse.Value, ttl)
-
-			if (count % 1000) == 0 {
-				cc.Cfg.Logger.Infof("%d keys have been processed out of ~%d", count, bc.Len())
-			}
+	_, err := cache.Iterate(c, cache.IterateOptions{}, func(key string, value []byte, ttl time.Duration) error {
+		count++
+		key = strings.ToLower(key)
+		if ttl == 0 {
+			cc.Cfg.Logger.Warnf("%s key had no expiry", key)
 			return nil
-		})
+		}
+		if ttl < cc.Cfg.MinTTL {
+			cc.Cfg.Logger.Debugf("Skipping %s as TTL %s was less than %s", key, ttl, cc.Cfg.MinTTL)
+			return nil
+		}
+
+		processor(key, value, ttl)
+
+		if (count % 1000) == 0 {
+			cc.Cfg.Logger.Infof("%d keys have been processed out of ~%d", count, c.Len())
+		}
+		return nil
 	})
 	if err != nil {
-		bc.Logger.Errorf("Error iterating through: %v", err)
+		cc.Cfg.Logger.Errorf("Error iterating through %s: %v", c.Name(), err)
 	}
 }
 
@@ -210,16 +235,14 @@ func (cc *CacheConverter) radixIterator(r *radix.RedisCache, processor Iterating
 // V4 -> V3
 func (cc *CacheConverter) MigrateUUIDV4toV3() {
 
-	boltCache := cc.Cachesv4.UUID.(*bolt_cache.BoltCache)
-	cc.boltIterator(boltCache, processUUIDv4(cc.Cfg.Logger, cc.Cachesv3.UUID.Insert))
+	cc.cacheIterator(cc.Cachesv4.UUID, processUUIDv4(cc.Cfg.Logger, cc.Cachesv3.UUID.Insert))
 
 }
 
 // V4 -> V3
 func (cc *CacheConverter) MigrateUserDataV4toV3() {
 
-	boltCache := cc.Cachesv4.UserData.(*bolt_cache.BoltCache)
-	cc.boltIterator(boltCache, processUserDatav4(cc.Cfg.Logger, cc.Cachesv3.UserData.Insert))
+	cc.cacheIterator(cc.Cachesv4.UserData, processUserDatav4(cc.Cfg.Logger, cc.Cachesv3.UserData.Insert))
 
 }
 
@@ -249,8 +272,111 @@ func (cc *CacheConverter) MigrateUserDataV3toV4() {
 
 	redisCache := cc.Cachesv3.UserData.(*radix.RedisCache)
 	cc.Cfg.Logger.Infof("Size of Redis is %d keys", redisCache.Len())
-	boltCache := cc.Cachesv4.UUID.(*bolt_cache.BoltCache)
+	boltCache := cc.Cachesv4.UserData.(*bolt_cache.BoltCache)
 	boltCache.DB.NoSync = true
 	cc.radixIterator(redisCache, processUserDatav3(cc.Cfg.Logger, cc.Cachesv4.UserData.InsertTTL))
 	cc.boltSync(boltCache)
 }
+
+// Cachev4 returns the v4 cache by name {uuid|userdata|textures}
+func (cc *CacheConverter) Cachev4(name string) (cache.Cache, error) {
+	var c cache.Cache
+	switch strings.ToLower(name) {
+	case "uuid":
+		c = cc.Cachesv4.UUID
+	case "userdata":
+		c = cc.Cachesv4.UserData
+	case "textures":
+		c = cc.Cachesv4.Textures
+	default:
+		return nil, fmt.Errorf("unknown cache \"%s\" {uuid|userdata|textures}", name)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("cache \"%s\" is not open (or its backend is none)", name)
+	}
+	return c, nil
+}
+
+// Export writes every key of the cache (with at least the MinTTL, or no expiry) to the dump
+func (cc *CacheConverter) Export(c cache.Cache, w io.Writer) error {
+	dw, err := NewDumpWriter(w, c.Name(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Iterate(c, cache.IterateOptions{}, func(key string, value []byte, ttl time.Duration) error {
+		if ttl != 0 && ttl < cc.Cfg.MinTTL {
+			cc.Cfg.Logger.Debugf("Skipping %s as TTL %s was less than %s", key, ttl, cc.Cfg.MinTTL)
+			return nil
+		}
+		if err := dw.Write(key, value, ttl); err != nil {
+			return err
+		}
+		if (dw.Count % 1000) == 0 {
+			cc.Cfg.Logger.Infof("%d keys have been exported out of ~%d", dw.Count, c.Len())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("exporting %s: %s", c.Name(), err)
+	}
+
+	cc.Cfg.Logger.Infof("Exported %d keys from %s", dw.Count, c.Name())
+	return dw.Close()
+}
+
+// Import inserts the keys from the dump into the cache
+// The TTLs are reduced by the time since the dump was created
+func (cc *CacheConverter) Import(c cache.Cache, r io.Reader) error {
+	dr, err := NewDumpReader(r)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+
+	cc.Cfg.Logger.Infof("Importing dump of %s created %s", dr.Header.Cache, dr.Header.Created)
+	now := time.Now()
+
+	var imported, errorCount int
+	for {
+		entry, err := dr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		ttl := entry.TTLAt(dr.Header.Created, now)
+		if ttl != 0 && ttl < cc.Cfg.MinTTL {
+			cc.Cfg.Logger.Debugf("Skipping %s as TTL %s was less than %s", entry.Key, ttl, cc.Cfg.MinTTL)
+			continue
+		}
+
+		if err := c.InsertTTL(entry.Key, entry.Value, ttl); err != nil {
+			cc.Cfg.Logger.Warnf("Erroring inserting %s with TTL %s: %v", entry.Key, ttl, err)
+			errorCount++
+			continue
+		}
+		imported++
+
+		if (dr.Count % 1000) == 0 {
+			cc.Cfg.Logger.Infof("%d keys have been read from the dump", dr.Count)
+		}
+	}
+
+	if bc, ok := c.(*bolt_cache.BoltCache); ok {
+		cc.boltSync(bc)
+	}
+
+	cc.Cfg.Logger.Infof("Imported %d of %d keys into %s (%d errors)", imported, dr.Count, c.Name(), errorCount)
+	return nil
+}
+
+// Close the v4 caches, ensuring any writes are persisted
+func (cc *CacheConverter) Close() {
+	for _, c := range []cache.Cache{cc.Cachesv4.UUID, cc.Cachesv4.UserData, cc.Cachesv4.Textures} {
+		if c != nil {
+			c.Close()
+		}
+	}
+}
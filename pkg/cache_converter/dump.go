@@ -0,0 +1,133 @@
+package cache_converter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The dump is a gzip compressed stream of JSON lines
+// The first line is a DumpHeader, followed by a DumpEntry per key
+// Being JSON, the Value is base64 encoded
+const (
+	DUMP_FORMAT  = "imgd-cache-dump"
+	DUMP_VERSION = 1
+)
+
+type DumpHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Cache   string    `json:"cache"`
+	Created time.Time `json:"created"`
+}
+
+type DumpEntry struct {
+	Key string `json:"key"`
+	// Remaining TTL in seconds when the dump was created (0 is no expiry)
+	TTL   int64  `json:"ttl"`
+	Value []byte `json:"value"`
+}
+
+// TTLAt is the remaining TTL of the entry at the given time, based on when the dump was created
+// A TTL of 0 means the entry has no expiry, and a negative TTL means it has since expired
+func (e DumpEntry) TTLAt(created, now time.Time) time.Duration {
+	if e.TTL == 0 {
+		return 0
+	}
+	ttl := time.Duration(e.TTL)*time.Second - now.Sub(created)
+	if ttl == 0 {
+		// 0 would otherwise be "no expiry"
+		ttl = -1
+	}
+	return ttl
+}
+
+type DumpWriter struct {
+	gz    *gzip.Writer
+	enc   *json.Encoder
+	Count int
+}
+
+func NewDumpWriter(w io.Writer, cacheName string, created time.Time) (*DumpWriter, error) {
+	gz := gzip.NewWriter(w)
+	dw := &DumpWriter{gz: gz, enc: json.NewEncoder(gz)}
+
+	header := DumpHeader{
+		Format:  DUMP_FORMAT,
+		Version: DUMP_VERSION,
+		Cache:   cacheName,
+		Created: created.UTC(),
+	}
+	if err := dw.enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("writing dump header: %s", err)
+	}
+	return dw, nil
+}
+
+func (dw *DumpWriter) Write(key string, value []byte, ttl time.Duration) error {
+	entry := DumpEntry{
+		Key:   key,
+		TTL:   int64(ttl / time.Second),
+		Value: value,
+	}
+	// Round sub-second TTLs up so they aren't treated as "no expiry"
+	if ttl > 0 && entry.TTL == 0 {
+		entry.TTL = 1
+	}
+	if err := dw.enc.Encode(entry); err != nil {
+		return fmt.Errorf("writing dump entry \"%s\": %s", key, err)
+	}
+	dw.Count++
+	return nil
+}
+
+// Close flushes the compressed stream (but does not close the underlying io.Writer)
+func (dw *DumpWriter) Close() error {
+	return dw.gz.Close()
+}
+
+type DumpReader struct {
+	gz     *gzip.Reader
+	dec    *json.Decoder
+	Header DumpHeader
+	Count  int
+}
+
+func NewDumpReader(r io.Reader) (*DumpReader, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("reading dump: %s", err)
+	}
+	dr := &DumpReader{gz: gz, dec: json.NewDecoder(gz)}
+
+	if err := dr.dec.Decode(&dr.Header); err != nil {
+		return nil, fmt.Errorf("reading dump header: %s", err)
+	}
+	if dr.Header.Format != DUMP_FORMAT {
+		return nil, fmt.Errorf("dump format \"%s\" is not \"%s\"", dr.Header.Format, DUMP_FORMAT)
+	}
+	if dr.Header.Version != DUMP_VERSION {
+		return nil, fmt.Errorf("dump version %d is not supported (expected %d)", dr.Header.Version, DUMP_VERSION)
+	}
+	return dr, nil
+}
+
+// Next returns the next DumpEntry, or io.EOF when there are no more
+func (dr *DumpReader) Next() (DumpEntry, error) {
+	var entry DumpEntry
+	err := dr.dec.Decode(&entry)
+	if err == io.EOF {
+		return entry, err
+	} else if err != nil {
+		return entry, fmt.Errorf("reading dump entry %d: %s", dr.Count+1, err)
+	}
+	dr.Count++
+	return entry, nil
+}
+
+func (dr *DumpReader) Close() error {
+	return dr.gz.Close()
+}
@@ -0,0 +1,96 @@
+package cache_converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	"github.com/minotar/imgd/pkg/cache/util/test_helpers"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func newLruCache(t *testing.T, name string) *lru_cache.LruCache {
+	logger := log.NewBuiltinLogger(1)
+	c, err := lru_cache.NewLruCache(lru_cache.NewLruCacheConfig(100,
+		cache.CacheConfig{
+			Name:   name,
+			Logger: logger,
+		},
+	))
+	if err != nil {
+		t.Fatalf("Error creating LruCache: %s", err)
+	}
+	return c
+}
+
+func TestExportAndImport(t *testing.T) {
+	cc := &CacheConverter{Cfg: Config{
+		Logger: log.NewBuiltinLogger(1),
+		MinTTL: time.Minute,
+	}}
+
+	source := newLruCache(t, "source")
+	dest := newLruCache(t, "dest")
+
+	// Key i has a TTL of i hours (so key 0 has no expiry)
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = test_helpers.RandString(32)
+		source.InsertTTL(keys[i], []byte("value_"+keys[i]), time.Duration(i)*time.Hour)
+	}
+	// A key below the MinTTL should not be exported
+	shortKey := test_helpers.RandString(32)
+	source.InsertTTL(shortKey, []byte("short"), time.Second)
+
+	var dump bytes.Buffer
+	if err := cc.Export(source, &dump); err != nil {
+		t.Fatalf("Export should not have returned an error: %s", err)
+	}
+	if err := cc.Import(dest, &dump); err != nil {
+		t.Fatalf("Import should not have returned an error: %s", err)
+	}
+
+	for i, key := range keys {
+		value, err := dest.Retrieve(key)
+		if err != nil {
+			t.Errorf("Key %s (%d) had an error: %s", key, i, err)
+			continue
+		}
+		if string(value) != "value_"+key {
+			t.Errorf("Key %s (%d) was not the expected value: %s", key, i, value)
+		}
+
+		ttl, err := dest.TTL(key)
+		if i == 0 {
+			if err != cache.ErrNoExpiry {
+				t.Errorf("Key %s (%d) should have no expiry: %v", key, i, err)
+			}
+		} else if expected := time.Duration(i) * time.Hour; ttl > expected || ttl < expected-time.Minute {
+			t.Errorf("Key %s (%d) TTL was %s", key, i, ttl)
+		}
+	}
+
+	if _, err := dest.Retrieve(shortKey); err != cache.ErrNotFound {
+		t.Errorf("Key %s below the MinTTL should not have been imported: %v", shortKey, err)
+	}
+}
+
+func TestDumpReaderVersion(t *testing.T) {
+	var dump bytes.Buffer
+	gz := gzip.NewWriter(&dump)
+	json.NewEncoder(gz).Encode(DumpHeader{
+		Format:  DUMP_FORMAT,
+		Version: DUMP_VERSION + 1,
+		Cache:   "test",
+	})
+	gz.Close()
+
+	_, err := NewDumpReader(&dump)
+	if err == nil {
+		t.Errorf("Dump with an unsupported version should have returned an error")
+	}
+}
@@ -0,0 +1,401 @@
+// cache_inspector opens the v4 caches read-only to look at what they hold
+// Values are decoded into the UUIDEntry / McUser (or texture image) that mcclient would see
+package cache_inspector
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/migrate_cache"
+	cache_config "github.com/minotar/imgd/pkg/cache/util/config"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/mcclient/status"
+	mc_uuid "github.com/minotar/imgd/pkg/mcclient/uuid"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+const (
+	CACHE_UUID     = "uuid"
+	CACHE_USERDATA = "userdata"
+	CACHE_TEXTURES = "textures"
+
+	day = 24 * time.Hour
+)
+
+// DefaultAgeBuckets are the upper bounds used for the Timestamp age histogram
+var DefaultAgeBuckets = []time.Duration{time.Hour, 12 * time.Hour, day, 7 * day, 14 * day, 30 * day}
+
+var ErrNoMetadata = errors.New("cache entries do not have a Status/Timestamp")
+
+type Config struct {
+	Logger log.Logger
+
+	CacheUUID     *cache_config.Config
+	CacheUserData *cache_config.Config
+	CacheTextures *cache_config.Config
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	c.CacheUUID = &cache_config.Config{}
+	c.CacheUserData = &cache_config.Config{}
+	c.CacheTextures = &cache_config.Config{}
+
+	c.CacheUUID.RegisterFlags(f, "UUID")
+	c.CacheUserData.RegisterFlags(f, "UserData")
+	c.CacheTextures.RegisterFlags(f, "Textures")
+}
+
+func (c *Config) Validate() error {
+	for _, cacheCfg := range []*cache_config.Config{c.CacheUUID, c.CacheUserData, c.CacheTextures} {
+		if err := cacheCfg.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type CacheInspector struct {
+	Cfg    Config
+	Caches struct {
+		UUID     cache.Cache
+		UserData cache.Cache
+		Textures cache.Cache
+	}
+}
+
+func newReadOnlyCache(logger log.Logger, cfg *cache_config.Config) (cache.Cache, error) {
+	cfg.Logger = logger
+	cfg.ReadOnly = true
+	// The caches are never Started, so there is no compaction or migration
+	return cache_config.NewCache(cfg)
+}
+
+// New opens each configured cache read-only
+// The persistent backends may refuse to open while another process has them open for writing
+func New(cfg Config) (*CacheInspector, error) {
+	ci := &CacheInspector{Cfg: cfg}
+
+	// A failed cache is not assigned, as it would be a non-nil interface holding a nil pointer
+	cacheUUID, err := newReadOnlyCache(cfg.Logger, cfg.CacheUUID)
+	if err != nil {
+		return nil, fmt.Errorf("opening cache UUID: %s", err)
+	}
+	ci.Caches.UUID = cacheUUID
+
+	cacheUserData, err := newReadOnlyCache(cfg.Logger, cfg.CacheUserData)
+	if err != nil {
+		ci.Close()
+		return nil, fmt.Errorf("opening cache UserData: %s", err)
+	}
+	ci.Caches.UserData = cacheUserData
+
+	cacheTextures, err := newReadOnlyCache(cfg.Logger, cfg.CacheTextures)
+	if err != nil {
+		ci.Close()
+		return nil, fmt.Errorf("opening cache Textures: %s", err)
+	}
+	ci.Caches.Textures = cacheTextures
+
+	return ci, nil
+}
+
+// Cache returns the cache by its short name {uuid|userdata|textures}
+func (ci *CacheInspector) Cache(name string) (cache.Cache, error) {
+	var c cache.Cache
+	switch strings.ToLower(name) {
+	case CACHE_UUID:
+		c = ci.Caches.UUID
+	case CACHE_USERDATA:
+		c = ci.Caches.UserData
+	case CACHE_TEXTURES:
+		c = ci.Caches.Textures
+	default:
+		return nil, fmt.Errorf("unknown cache \"%s\" (expected uuid|userdata|textures)", name)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("cache \"%s\" is disabled", name)
+	}
+	return c, nil
+}
+
+func (ci *CacheInspector) Close() {
+	for _, c := range []cache.Cache{ci.Caches.UUID, ci.Caches.UserData, ci.Caches.Textures} {
+		if c != nil {
+			c.Close()
+		}
+	}
+}
+
+// StatusName is the readable name of a status.Status (its Error() is not suited to an "Ok")
+func StatusName(s status.Status) string {
+	switch s {
+	case status.StatusUnSet:
+		return "Unset"
+	case status.StatusOk:
+		return "Ok"
+	case status.StatusErrorGeneric:
+		return "ErrorGeneric"
+	case status.StatusErrorUnknownUser:
+		return "ErrorUnknownUser"
+	case status.StatusErrorRateLimit:
+		return "ErrorRateLimit"
+	case status.StatusErrorInvalidTexture:
+		return "ErrorInvalidTexture"
+	default:
+		return fmt.Sprintf("Unknown(%d)", s)
+	}
+}
+
+// Entry is a decoded cache value
+type Entry struct {
+	Cache string
+	Key   string
+	// Remaining TTL, with 0 being no expiry
+	TTL  time.Duration
+	Size int
+	// Status and Timestamp are not set for textures (though a failed texture has a Status)
+	Status    status.Status
+	Timestamp time.Time
+	// Detail is a readable summary of the decoded value
+	Detail string
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cache:     %s\n", e.Cache)
+	fmt.Fprintf(&b, "key:       %s\n", e.Key)
+	if e.TTL == 0 {
+		fmt.Fprintf(&b, "ttl:       no expiry\n")
+	} else {
+		fmt.Fprintf(&b, "ttl:       %s\n", e.TTL.Round(time.Second))
+	}
+	fmt.Fprintf(&b, "size:      %d bytes\n", e.Size)
+	if !e.Timestamp.IsZero() {
+		fmt.Fprintf(&b, "status:    %s\n", StatusName(e.Status))
+		fmt.Fprintf(&b, "timestamp: %s (%s ago)\n", e.Timestamp, time.Since(e.Timestamp).Round(time.Second))
+	}
+	fmt.Fprintf(&b, "value:     %s\n", e.Detail)
+	return b.String()
+}
+
+// NormaliseKey matches the key to how mcclient would have cached it
+func NormaliseKey(cacheName, key string) string {
+	switch strings.ToLower(cacheName) {
+	case CACHE_UUID:
+		return strings.ToLower(key)
+	case CACHE_USERDATA:
+		return strings.ReplaceAll(strings.ToLower(key), "-", "")
+	default:
+		// Texture keys are left untouched
+		return key
+	}
+}
+
+// Decode the cached value based on the cache it came from
+func Decode(cacheName, key string, value []byte) (Entry, error) {
+	entry := Entry{
+		Cache: strings.ToLower(cacheName),
+		Key:   key,
+		Size:  len(value),
+	}
+
+	switch entry.Cache {
+	case CACHE_UUID:
+		if len(value) < 5 {
+			// As per mcclient, 4 bytes or less would be an invalid status/timestamp
+			return entry, fmt.Errorf("UUIDEntry \"%s\" is too short: %v", key, value)
+		}
+		uuidEntry := mc_uuid.DecodeUUIDEntry(value)
+		entry.Status = uuidEntry.Status
+		entry.Timestamp = uuidEntry.Timestamp.Time()
+		entry.Detail = fmt.Sprintf("UUIDEntry{UUID: \"%s\", Valid: %t}", uuidEntry.UUID, uuidEntry.IsValid())
+
+	case CACHE_USERDATA:
+		mcUser, err := mcuser.DecompressMcUser(value)
+		if err != nil {
+			return entry, fmt.Errorf("decoding McUser \"%s\": %s", key, err)
+		}
+		entry.Status = mcUser.Status
+		entry.Timestamp = mcUser.Timestamp.Time()
+		entry.Detail = fmt.Sprintf("McUser{Username: \"%s\", UUID: \"%s\", SkinPath: \"%s\", TexturesMcNet: %t, Valid: %t, Fresh: %t}",
+			mcUser.Username, mcUser.UUID, mcUser.Textures.SkinPath, mcUser.Textures.TexturesMcNet, mcUser.IsValid(), mcUser.IsFresh())
+
+	case CACHE_TEXTURES:
+		if len(value) == 1 {
+			// A failed texture is cached as just its Status
+			entry.Status = status.Status(value[0])
+			entry.Detail = fmt.Sprintf("Texture{Status: %s}", StatusName(entry.Status))
+			break
+		}
+		imgCfg, format, err := image.DecodeConfig(bytes.NewReader(value))
+		if err != nil {
+			return entry, fmt.Errorf("decoding texture \"%s\": %s", key, err)
+		}
+		entry.Detail = fmt.Sprintf("Texture{Format: %s, Width: %d, Height: %d}", format, imgCfg.Width, imgCfg.Height)
+
+	default:
+		return entry, fmt.Errorf("unknown cache \"%s\" (expected uuid|userdata|textures)", cacheName)
+	}
+
+	return entry, nil
+}
+
+// Get retrieves and decodes a key along with its TTL
+func (ci *CacheInspector) Get(cacheName, key string) (Entry, error) {
+	entry, _, err := ci.get(cacheName, key)
+	return entry, err
+}
+
+// get also returns the raw value so callers can decode it further
+func (ci *CacheInspector) get(cacheName, key string) (Entry, []byte, error) {
+	c, err := ci.Cache(cacheName)
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	key = NormaliseKey(cacheName, key)
+
+	value, err := c.Retrieve(key)
+	if err != nil {
+		return Entry{Cache: cacheName, Key: key}, nil, fmt.Errorf("retrieving \"%s\" from %s: %w", key, c.Name(), err)
+	}
+
+	entry, err := Decode(cacheName, key, value)
+	if err != nil {
+		return entry, value, err
+	}
+
+	entry.TTL, err = c.TTL(key)
+	if err == cache.ErrNoExpiry {
+		entry.TTL = 0
+	} else if err != nil {
+		return entry, value, fmt.Errorf("retrieving TTL of \"%s\" from %s: %s", key, c.Name(), err)
+	}
+	return entry, value, nil
+}
+
+// Lookup follows a Username (or UUID) through each cache in the same order as mcclient
+// The Entries found are returned, with an error explaining where the lookup stopped
+func (ci *CacheInspector) Lookup(user string) ([]Entry, error) {
+	var entries []Entry
+
+	uuid := NormaliseKey(CACHE_USERDATA, user)
+	if !minecraft.RegexUUIDPlain.MatchString(uuid) {
+		entry, value, err := ci.get(CACHE_UUID, user)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+		uuidEntry := mc_uuid.DecodeUUIDEntry(value)
+		if !uuidEntry.IsValid() {
+			return entries, fmt.Errorf("UUIDEntry has status %s and no UUID", StatusName(uuidEntry.Status))
+		}
+		uuid = uuidEntry.UUID
+	}
+
+	entry, value, err := ci.get(CACHE_USERDATA, uuid)
+	if err != nil {
+		return entries, err
+	}
+	entries = append(entries, entry)
+	// Already successfully decoded by get
+	mcUser, _ := mcuser.DecompressMcUser(value)
+	if !mcUser.IsValid() {
+		return entries, fmt.Errorf("McUser has status %s and no SkinPath", StatusName(mcUser.Status))
+	}
+
+	if ci.Caches.Textures == nil {
+		return entries, nil
+	}
+	entry, err = ci.Get(CACHE_TEXTURES, mcUser.Textures.SkinPath)
+	if err != nil {
+		return entries, err
+	}
+	return append(entries, entry), nil
+}
+
+// List calls fn with each key (and its TTL) starting with the prefix
+// A limit of 0 is unlimited, and the returned marker can be used to continue the listing
+func (ci *CacheInspector) List(cacheName, prefix, startKey string, limit int, fn func(key string, ttl time.Duration)) (string, error) {
+	c, err := ci.Cache(cacheName)
+	if err != nil {
+		return "", err
+	}
+
+	opts := cache.IterateOptions{Prefix: prefix, StartKey: startKey, Limit: limit}
+	return cache.Iterate(c, opts, func(key string, _ []byte, ttl time.Duration) error {
+		fn(key, ttl)
+		return nil
+	})
+}
+
+// Stats summarises the Status and Timestamp of the entries in a cache
+type Stats struct {
+	Total int
+	// Entries which could not be decoded
+	Invalid int
+	Status  map[status.Status]int
+	// AgeBuckets are the upper bounds of each Ages count
+	// The final Ages count is for entries older than every bucket
+	AgeBuckets []time.Duration
+	Ages       []int
+}
+
+func (s *Stats) observe(entry Entry, now time.Time) {
+	s.Status[entry.Status]++
+
+	age := now.Sub(entry.Timestamp)
+	for i, bucket := range s.AgeBuckets {
+		if age <= bucket {
+			s.Ages[i]++
+			return
+		}
+	}
+	s.Ages[len(s.AgeBuckets)]++
+}
+
+// Stats iterates every entry (with the given prefix) and counts them by Status and Timestamp age
+// Only the uuid and userdata caches have the Status/Timestamp metadata
+func (ci *CacheInspector) Stats(cacheName, prefix string, ageBuckets []time.Duration) (*Stats, error) {
+	if strings.ToLower(cacheName) == CACHE_TEXTURES {
+		return nil, ErrNoMetadata
+	}
+	c, err := ci.Cache(cacheName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Status:     make(map[status.Status]int),
+		AgeBuckets: ageBuckets,
+		Ages:       make([]int, len(ageBuckets)+1),
+	}
+	now := time.Now()
+
+	_, err = cache.Iterate(c, cache.IterateOptions{Prefix: prefix}, func(key string, value []byte, _ time.Duration) error {
+		if strings.HasPrefix(key, migrate_cache.MIGRATION_KEY_PREFIX) {
+			// Not a cached user, but the state of a MigrateCache
+			return nil
+		}
+		stats.Total++
+		entry, err := Decode(cacheName, key, value)
+		if err != nil {
+			ci.Cfg.Logger.Debugf("Unable to decode: %v", err)
+			stats.Invalid++
+			return nil
+		}
+		stats.observe(entry, now)
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("iterating through %s: %s", c.Name(), err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,139 @@
+package cache_inspector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/mcclient/status"
+	mc_uuid "github.com/minotar/imgd/pkg/mcclient/uuid"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/tinytime"
+)
+
+const testUUID = "d9135e082f2244c89cb10af21fc1d6ed"
+
+func newLruCache(t *testing.T, name string) *lru_cache.LruCache {
+	c, err := lru_cache.NewLruCache(lru_cache.NewLruCacheConfig(100,
+		cache.CacheConfig{
+			Name:   name,
+			Logger: log.NewBuiltinLogger(1),
+		},
+	))
+	if err != nil {
+		t.Fatalf("Error creating LruCache: %s", err)
+	}
+	return c
+}
+
+func newCacheInspector(t *testing.T) *CacheInspector {
+	ci := &CacheInspector{Cfg: Config{Logger: log.NewBuiltinLogger(1)}}
+	ci.Caches.UUID = newLruCache(t, "uuid")
+	ci.Caches.UserData = newLruCache(t, "userdata")
+	return ci
+}
+
+func insertUUIDEntry(ci *CacheInspector, username, uuid string, s status.Status, age time.Duration) {
+	uuidEntry := mc_uuid.UUIDEntry{
+		UUID:      uuid,
+		Timestamp: tinytime.NewTinyTime(time.Now().Add(-age)),
+		Status:    s,
+	}
+	ci.Caches.UUID.InsertTTL(username, uuidEntry.Encode(), uuidEntry.TTL())
+}
+
+func TestGetUUIDEntry(t *testing.T) {
+	ci := newCacheInspector(t)
+	insertUUIDEntry(ci, "clone1018", testUUID, status.StatusOk, time.Hour)
+
+	// Keys are normalised to lowercase
+	entry, err := ci.Get(CACHE_UUID, "Clone1018")
+	if err != nil {
+		t.Fatalf("Get should not have returned an error: %s", err)
+	}
+	if entry.Status != status.StatusOk {
+		t.Errorf("Entry status should be Ok, not: %s", StatusName(entry.Status))
+	}
+	if entry.TTL == 0 {
+		t.Errorf("Entry should have a TTL")
+	}
+	if expected := `UUIDEntry{UUID: "` + testUUID + `", Valid: true}`; entry.Detail != expected {
+		t.Errorf("Entry detail should be %s, not: %s", expected, entry.Detail)
+	}
+
+	if _, err := ci.Get(CACHE_UUID, "missing"); err == nil {
+		t.Errorf("Get of a missing key should return an error")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	ci := newCacheInspector(t)
+	insertUUIDEntry(ci, "clone1018", testUUID, status.StatusOk, time.Hour)
+
+	mcUser := mcuser.McUser{
+		User:      minecraft.User{Username: "clone1018", UUID: testUUID},
+		Textures:  mcuser.Textures{SkinPath: "skinhash", TexturesMcNet: true},
+		Timestamp: tinytime.NewTinyTime(time.Now()),
+		Status:    status.StatusOk,
+	}
+	userBytes, _ := mcUser.Compress()
+	ci.Caches.UserData.InsertTTL(testUUID, userBytes, mcUser.TTL())
+
+	entries, err := ci.Lookup("clone1018")
+	if err != nil {
+		t.Fatalf("Lookup should not have returned an error: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Lookup should have found 2 entries, not: %d", len(entries))
+	}
+	if entries[1].Cache != CACHE_USERDATA || entries[1].Key != testUUID {
+		t.Errorf("Second entry should be the McUser, not: %+v", entries[1])
+	}
+
+	insertUUIDEntry(ci, "unknown", "", status.StatusErrorUnknownUser, time.Hour)
+	entries, err = ci.Lookup("unknown")
+	if err == nil || len(entries) != 1 {
+		t.Errorf("Lookup of an unknown user should stop at the UUIDEntry: %v %+v", err, entries)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ci := newCacheInspector(t)
+
+	for i := 0; i < 10; i++ {
+		insertUUIDEntry(ci, "ok"+string(rune('a'+i)), testUUID, status.StatusOk, 2*time.Hour)
+	}
+	for i := 0; i < 5; i++ {
+		insertUUIDEntry(ci, "unknown"+string(rune('a'+i)), "", status.StatusErrorUnknownUser, 10*day)
+	}
+	ci.Caches.UUID.InsertTTL("broken", []byte{1}, time.Hour)
+
+	stats, err := ci.Stats(CACHE_UUID, "", DefaultAgeBuckets)
+	if err != nil {
+		t.Fatalf("Stats should not have returned an error: %s", err)
+	}
+
+	if stats.Total != 16 || stats.Invalid != 1 {
+		t.Errorf("Stats should have 16 entries with 1 invalid, not: %d/%d", stats.Total, stats.Invalid)
+	}
+	if stats.Status[status.StatusOk] != 10 || stats.Status[status.StatusErrorUnknownUser] != 5 {
+		t.Errorf("Stats status counts were wrong: %+v", stats.Status)
+	}
+	// 2 hours is within the 12 hour bucket, 10 days is within the 14 day bucket
+	if stats.Ages[1] != 10 || stats.Ages[4] != 5 {
+		t.Errorf("Stats age counts were wrong: %+v", stats.Ages)
+	}
+
+	// Only the "ok" keys
+	stats, _ = ci.Stats(CACHE_UUID, "ok", DefaultAgeBuckets)
+	if stats.Total != 10 {
+		t.Errorf("Stats with a prefix should have 10 entries, not: %d", stats.Total)
+	}
+
+	if _, err := ci.Stats(CACHE_TEXTURES, "", DefaultAgeBuckets); err != ErrNoMetadata {
+		t.Errorf("Stats of textures should return ErrNoMetadata, not: %v", err)
+	}
+}
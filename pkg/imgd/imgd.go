@@ -1,6 +1,8 @@
 package imgd
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"time"
 
@@ -9,22 +11,51 @@ import (
 	"github.com/minotar/imgd/pkg/processd"
 	"github.com/minotar/imgd/pkg/skind"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/reload"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"github.com/minotar/imgd/pkg/util/tracing"
 
 	"github.com/weaveworks/common/server"
 )
 
+// reloadableSettings can be changed without a restart (see Reload)
+var reloadableSettings = append([]string{
+	"imgd.cors-allow-all",
+	"imgd.use-etags",
+	"imgd.redirect-username",
+	"imgd.cache-control-ttl",
+	"imgd.error-cache-control-ttl",
+	"log.level",
+}, mcclient.ReloadableSettings...)
+
 type Config struct {
-	Server   server.Config   `yaml:"server,omitempty"`
-	McClient mcclient.Config `yaml:"mcclient,omitempty"`
+	Server   server.Config
+	McClient mcclient.Config
 	Logger   log.Logger
-	// Add open CORS headers to easch response
+	// Add open CORS headers to each response
 	CorsAllowAll bool
 	// Return an ETag based on the texture ID
 	UseETags bool
 	// Return a 302 redirect for Username requests to their related UUID
 	RedirectUsername bool
 	CacheControlTTL  time.Duration
+	// Cache TTL returned with Steve when the lookup fails
+	ErrorCacheControlTTL time.Duration
+	// Serve legacy (64x32) skins on /skin in the 64x64 layout
+	UpgradeLegacySkins bool
+	Tracing            tracing.Config
+	Ready              skind.ReadyConfig
+}
+
+// HandlerSettings are the response settings (which a reload can change)
+func (c *Config) HandlerSettings() route_helpers.HandlerSettings {
+	return route_helpers.HandlerSettings{
+		CorsAllowAll:         c.CorsAllowAll,
+		UseETags:             c.UseETags,
+		RedirectUsername:     c.RedirectUsername,
+		CacheControlTTL:      c.CacheControlTTL,
+		ErrorCacheControlTTL: c.ErrorCacheControlTTL,
+	}
 }
 
 // RegisterFlags registers flag.
@@ -35,18 +66,44 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.UseETags, "imgd.use-etags", true, "Use etags to skip re-processing")
 	f.BoolVar(&c.RedirectUsername, "imgd.redirect-username", true, "Redirect username requests to the UUID variant")
 	f.DurationVar(&c.CacheControlTTL, "imgd.cache-control-ttl", time.Duration(6)*time.Hour, "Cache TTL returned to clients")
+	f.DurationVar(&c.ErrorCacheControlTTL, "imgd.error-cache-control-ttl", time.Minute, "Cache TTL returned to clients when the skin lookup fails")
+	f.BoolVar(&c.UpgradeLegacySkins, "imgd.upgrade-legacy-skins", false, "Serve legacy (64x32) skins on /skin in the 64x64 layout")
 
+	c.Ready.RegisterFlags(f, "imgd")
+	c.Tracing.RegisterFlags(f)
 	c.Server.RegisterFlags(f)
 	c.McClient.RegisterFlags(f)
 
 }
 
+func (c *Config) Validate() error {
+	if c.CacheControlTTL < 0 {
+		return errors.New("imgd.cache-control-ttl should not be negative")
+	}
+	if c.ErrorCacheControlTTL < 0 {
+		return errors.New("imgd.error-cache-control-ttl should not be negative")
+	}
+	if err := c.Ready.Validate(); err != nil {
+		return err
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+	return c.McClient.Validate()
+}
+
 type Imgd struct {
 	Cfg Config
 
-	Server        *server.Server
-	McClient      *mcclient.McClient
-	ProcessRoutes map[string]skind.SkinProcessor
+	Server   *server.Server
+	McClient *mcclient.McClient
+	Settings *route_helpers.LiveSettings
+	// Flushes any buffered trace spans
+	TracingShutdown func(context.Context) error
+	ProcessRoutes   map[string]skind.SkinProcessor
+	// Re-reads the config (with the names of the changed settings), which is required to Reload
+	ConfigLoader func() (Config, []string, error)
+	Reloader     *reload.Reloader
 }
 
 func New(cfg Config) (*Imgd, error) {
@@ -55,6 +112,11 @@ func New(cfg Config) (*Imgd, error) {
 	// Set the GRPC to localhost only
 	cfg.Server.GRPCListenAddress = "127.0.0.4"
 
+	tracingShutdown, err := tracing.Init(cfg.Tracing, "imgd", cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg.McClient.CacheUUID.Logger = cfg.Logger
 	cacheUUID, err := cache_config.NewCache(cfg.McClient.CacheUUID)
 	if err != nil {
@@ -78,11 +140,18 @@ func New(cfg Config) (*Imgd, error) {
 		cacheTextures.Start()
 	}
 
+	if err := log.SetZapLevel(cfg.Server.LogLevel.String()); err != nil {
+		return nil, err
+	}
+
 	imgd := &Imgd{
-		Cfg:           cfg,
-		McClient:      mcclient.NewMcClient(&cfg.McClient),
-		ProcessRoutes: processd.DefaultProcessRoutes,
+		Cfg:             cfg,
+		McClient:        mcclient.NewMcClient(&cfg.McClient),
+		Settings:        route_helpers.NewLiveSettings(cfg.HandlerSettings()),
+		TracingShutdown: tracingShutdown,
+		ProcessRoutes:   processd.DefaultProcessRoutes,
 	}
+	imgd.Reloader = reload.New(cfg.Logger, imgd.applyReload, reloadableSettings)
 
 	imgd.McClient.Caches.UUID = cacheUUID
 	imgd.McClient.Caches.UserData = cacheUserData
@@ -96,6 +165,10 @@ func (i *Imgd) Run() error {
 	if err := i.initServer(); err != nil {
 		return err
 	}
+	// Once the server stops (eg. SIGTERM), drain the requests and close the caches
+	defer i.Shutdown()
+	stopWatch := i.Reloader.Watch()
+	defer stopWatch()
 	// init other bits
 
 	return i.Server.Run()
@@ -103,17 +176,48 @@ func (i *Imgd) Run() error {
 	//return nil
 }
 
+// Shutdown stops accepting requests and waits for the in-flight ones (up to the server.graceful-shutdown-timeout)
+// The caches are then closed once any pending inserts finish, and remaining spans are flushed
+func (i *Imgd) Shutdown() {
+	i.Cfg.Logger.Info("Shutting down imgd")
+	i.Server.Shutdown()
+
+	i.McClient.Close()
+	i.Cfg.Logger.Info("Closed caches")
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.Cfg.Server.ServerGracefulShutdownTimeout)
+	defer cancel()
+	i.TracingShutdown(ctx)
+}
+
+// applyReload swaps in the response settings, the McClient upstream settings and TTL policy, and the log level
+// The caches (and anything else) keep the config they were created with
+func (i *Imgd) applyReload() ([]string, error) {
+	if i.ConfigLoader == nil {
+		return nil, errors.New("no config loader to reload from")
+	}
+	cfg, changed, err := i.ConfigLoader()
+	if err != nil {
+		return nil, err
+	}
+	if err := log.SetZapLevel(cfg.Server.LogLevel.String()); err != nil {
+		return nil, err
+	}
+	i.Settings.Store(cfg.HandlerSettings())
+	i.McClient.Reload(&cfg.McClient)
+	return changed, nil
+}
+
 func (i *Imgd) initServer() error {
 	serv, err := server.New(i.Cfg.Server)
 	if err != nil {
 		return err
 	}
 
-	//serv.HTTP.Use(route_helpers.LoggingMiddleware(i.Cfg.Logger))
+	serv.HTTP.Use(route_helpers.LoggingMiddleware(i.Cfg.Logger))
+	serv.HTTP.Use(tracing.Middleware("imgd"))
 
-	if i.Cfg.CorsAllowAll {
-		serv.HTTP.Use(route_helpers.CorsHandler)
-	}
+	serv.HTTP.Use(i.Settings.CorsMiddleware)
 
 	i.Server = serv
 	i.routes()
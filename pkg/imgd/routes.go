@@ -4,16 +4,24 @@ import (
 	"github.com/felixge/fgprof"
 	"github.com/minotar/imgd/pkg/processd"
 	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/util/health"
 )
 
 // routes registers all the routes
 func (i *Imgd) routes() {
 	i.Server.HTTP.Path("/debug/fgprof").Handler(fgprof.Handler())
 	i.Server.HTTP.Path("/healthcheck").Handler(skind.HealthcheckHandler(i.McClient))
+	i.Server.HTTP.Path("/live").Handler(health.LiveHandler())
+	i.Server.HTTP.Path("/ready").Handler(health.ReadyHandler(skind.NewReadyChecker(i.Cfg.Ready, i.McClient)))
 	i.Server.HTTP.Path("/dbsize").Handler(skind.SizecheckHandler(i.McClient))
 
-	skinWrapper := skind.NewSkinWrapper(i.Cfg.Logger, i.McClient, i.Cfg.UseETags, i.Cfg.RedirectUsername, i.Cfg.CacheControlTTL)
+	skinWrapper := skind.NewSkinWrapper(i.Cfg.Logger, i.McClient, i.Settings)
 
-	skind.RegisterSkinRoutes(i.Server.HTTP, skinWrapper)
+	skinPageWrapper := skinWrapper
+	if i.Cfg.UpgradeLegacySkins {
+		skinPageWrapper = skind.NewSkinWrapper(i.Cfg.Logger, i.McClient, i.Settings, skind.LegacySkinUpgrade)
+	}
+
+	skind.RegisterSkinRoutes(i.Server.HTTP, skinWrapper, skinPageWrapper)
 	processd.RegisterProcessingRoutes(i.Server.HTTP, skinWrapper, i.ProcessRoutes)
 }
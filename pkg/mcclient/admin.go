@@ -0,0 +1,105 @@
+package mcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+// RefreshUser re-requests the UUID (for a Username) and McUser from the API, ignoring any cached freshness
+// As with a stale lookup, a failed request will not replace an already valid cached entry (but is still an error)
+func (mc *McClient) RefreshUser(ctx context.Context, logger log.Logger, userReq UserReq) (log.Logger, mcuser.McUser, error) {
+	uuid := strings.ToLower(userReq.UUID)
+	if uuid == "" {
+		if userReq.Username == "" {
+			return logger, mcuser.McUser{}, errors.New("no UUID/Username given")
+		}
+		logger = logger.With("username", userReq.Username)
+		// The cached entry is only used when the fresh request fails
+		uuidEntry, _ := mc.CacheRetrieveUUIDEntry(ctx, logger, userReq.Username)
+		uuidEntry, err := mc.requestUUIDEntry(ctx, logger, userReq.Username, uuidEntry)
+		if err != nil {
+			return logger, mcuser.McUser{}, fmt.Errorf("UUID request failed (the cached entry was kept): %w", err)
+		}
+		if !uuidEntry.IsValid() {
+			return logger, mcuser.McUser{}, uuidEntry.Status.GetError()
+		}
+		uuid = uuidEntry.UUID
+	}
+
+	logger = logger.With("uuid", uuid)
+	mcUser, _ := mc.CacheRetrieveMcUser(ctx, logger, uuid)
+	mcUser, err := mc.requestMcUser(ctx, logger, uuid, mcUser)
+	if err != nil {
+		return logger, mcUser, fmt.Errorf("McUser request failed (the cached entry was kept): %w", err)
+	}
+	if !mcUser.IsValid() {
+		return logger, mcUser, mcUser.Status.GetError()
+	}
+	logger.Infof("Refreshed user with skinPath: %s", mcUser.Textures.SkinPath)
+	return logger, mcUser, nil
+}
+
+// PurgeUser removes the UUIDEntry, McUser and skin texture of a user from the caches
+// The related keys are found from whichever entries are still cached
+// The "cache:key" of each removal is returned
+func (mc *McClient) PurgeUser(ctx context.Context, logger log.Logger, userReq UserReq) ([]string, error) {
+	var purged []string
+	var errs []string
+	remove := func(c cache.Cache, key string) {
+		if c == nil || key == "" {
+			return
+		}
+		if err := c.Remove(key); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%s %v", c.Name(), key, err))
+			return
+		}
+		purged = append(purged, c.Name()+":"+key)
+	}
+
+	username := strings.ToLower(userReq.Username)
+	uuid := strings.ToLower(userReq.UUID)
+
+	if username != "" {
+		uuidEntry, err := mc.CacheRetrieveUUIDEntry(ctx, logger, username)
+		if err == nil && uuidEntry.IsValid() {
+			uuid = uuidEntry.UUID
+		}
+		remove(mc.Caches.UUID, username)
+	}
+
+	if uuid != "" {
+		mcUser, err := mc.CacheRetrieveMcUser(ctx, logger, uuid)
+		if err == nil {
+			if username == "" {
+				// The Username -> UUID mapping is cached whenever an McUser is requested
+				remove(mc.Caches.UUID, strings.ToLower(mcUser.Username))
+			}
+			remove(mc.Caches.Textures, mcUser.Textures.SkinPath)
+		}
+		remove(mc.Caches.UserData, uuid)
+	}
+
+	if len(errs) > 0 {
+		return purged, fmt.Errorf("failed to purge: %s", strings.Join(errs, ", "))
+	}
+	logger.Infof("Purged user from caches: %v", purged)
+	return purged, nil
+}
+
+// PurgeTexture removes a texture from the cache (the key being the SkinPath of an McUser)
+func (mc *McClient) PurgeTexture(ctx context.Context, logger log.Logger, textureKey string) error {
+	if mc.Caches.Textures == nil {
+		return ErrCacheDisabled
+	}
+	if err := mc.Caches.Textures.Remove(textureKey); err != nil {
+		return fmt.Errorf("failed to purge texture \"%s\": %s", textureKey, err)
+	}
+	logger.Infof("Purged texture from %s: %s", mc.Caches.Textures.Name(), textureKey)
+	return nil
+}
@@ -4,41 +4,128 @@ package mcclient
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"time"
 
 	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
 	"github.com/minotar/imgd/pkg/mcclient/status"
 	mc_uuid "github.com/minotar/imgd/pkg/mcclient/uuid"
 )
 
-func (mc *McClient) RequestUUIDEntry(logger log.Logger, username string, uuidEntry mc_uuid.UUIDEntry) mc_uuid.UUIDEntry {
+// stageContext applies the deadline of an upstream stage to the request context
+// A zero timeout leaves only the request context (and the HTTP client timeout)
+func stageContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// requestAbandoned is true when the request context ended (eg. the client disconnected)
+// The upstream error is then not the fault of the API, so the result should not be cached
+func requestAbandoned(ctx context.Context, logger log.Logger, source string) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	logger.Debugf("Abandoned %s as the request ended: %v", source, ctx.Err())
+	apiAbandoned.WithLabelValues(source).Inc()
+	return true
+}
+
+// recordUpstream tracks the result for the upstream error rate
+// An unknown user is a valid response from the API, so is not a failure
+func (mc *McClient) recordUpstream(err error) {
+	mc.UpstreamErrors.Record(err != nil && !errors.Is(err, minecraft.ErrUserNotFound))
+}
+
+// ProbeUpstream checks the API is reachable by looking up the username (bypassing the caches)
+// An unknown user still shows the API is responding
+func (mc *McClient) ProbeUpstream(ctx context.Context, username string) error {
+	upstream := mc.upstream()
+	ctx, cancel := stageContext(ctx, upstream.timeouts.UUID)
+	defer cancel()
+	_, err := upstream.api.GetUUIDCtx(ctx, username)
+	if err != nil && !errors.Is(err, minecraft.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (mc *McClient) RequestUUIDEntry(ctx context.Context, logger log.Logger, username string, uuidEntry mc_uuid.UUIDEntry) mc_uuid.UUIDEntry {
+	uuidEntry, _ = mc.requestUUIDEntry(ctx, logger, username, uuidEntry)
+	return uuidEntry
+}
+
+// requestUUIDEntry also returns the error of the request when the given (stale) uuidEntry was kept instead
+func (mc *McClient) requestUUIDEntry(ctx context.Context, logger log.Logger, username string, uuidEntry mc_uuid.UUIDEntry) (mc_uuid.UUIDEntry, error) {
+	upstream := mc.upstream()
+	apiCtx, cancel := stageContext(ctx, upstream.timeouts.UUID)
+	defer cancel()
+	apiCtx, span := tracer.Start(apiCtx, "Mojang GetUUID", trace.WithAttributes(attribute.String("username", username)))
+	start := time.Now()
 	// GetUUID uses the GetAPIProfile which would also pull the Username (not wanted)
-	uuidFresh, err := mc.API.GetUUID(username)
+	uuidFresh, err := upstream.api.GetUUIDCtx(apiCtx, username)
+	tracing.EndSpan(span, err)
+	access_log.FromContext(ctx).AddUpstream("GetAPIProfile", time.Since(start), err)
+
+	if err != nil && requestAbandoned(ctx, logger, "GetAPIProfile") {
+		if !uuidEntry.IsValid() {
+			uuidEntry.Status = status.StatusErrorGeneric
+		}
+		return uuidEntry, err
+	}
+	mc.recordUpstream(err)
 	uuidEntryFresh := mc_uuid.NewUUIDEntry(logger, username, uuidFresh, err)
 
 	if !uuidEntryFresh.IsValid() && uuidEntry.IsValid() {
 		// New result errored, but the original/stale Entry was already valid - Don't cache!
 		// Todo: stat this?
-		return uuidEntry
+		return uuidEntry, uuidEntryFresh.Status.GetError()
 	}
 
 	logger.With("uuid", uuidEntryFresh.UUID)
 	// Todo: goroutine?
-	mc.CacheInsertUUIDEntry(logger, username, uuidEntryFresh)
-	return uuidEntryFresh
+	mc.CacheInsertUUIDEntry(ctx, logger, username, uuidEntryFresh)
+	return uuidEntryFresh, nil
+}
+
+func (mc *McClient) RequestMcUser(ctx context.Context, logger log.Logger, uuid string, mcUser mcuser.McUser) mcuser.McUser {
+	mcUser, _ = mc.requestMcUser(ctx, logger, uuid, mcUser)
+	return mcUser
 }
 
-func (mc *McClient) RequestMcUser(logger log.Logger, uuid string, mcUser mcuser.McUser) mcuser.McUser {
-	sessionProfile, err := mc.API.GetSessionProfile(uuid)
+// requestMcUser also returns the error of the request when the given (stale) mcUser was kept instead
+func (mc *McClient) requestMcUser(ctx context.Context, logger log.Logger, uuid string, mcUser mcuser.McUser) (mcuser.McUser, error) {
+	upstream := mc.upstream()
+	apiCtx, cancel := stageContext(ctx, upstream.timeouts.UserData)
+	defer cancel()
+	apiCtx, span := tracer.Start(apiCtx, "Mojang GetSessionProfile", trace.WithAttributes(attribute.String("uuid", uuid)))
+	start := time.Now()
+	sessionProfile, err := upstream.api.GetSessionProfileCtx(apiCtx, uuid)
+	tracing.EndSpan(span, err)
+	access_log.FromContext(ctx).AddUpstream("GetSessionProfile", time.Since(start), err)
+
+	if err != nil && requestAbandoned(ctx, logger, "GetSessionProfile") {
+		if !mcUser.IsValid() {
+			mcUser.Status = status.StatusErrorGeneric
+		}
+		return mcUser, err
+	}
+	mc.recordUpstream(err)
 
 	mcUserFresh := mcuser.NewMcUser(logger, uuid, sessionProfile, err)
 
 	if !mcUserFresh.IsValid() && mcUser.IsValid() {
 		// New result errored, but the original/stale Entry was already valid - Don't cache!
-		return mcUser
+		return mcUser, mcUserFresh.Status.GetError()
 	}
 
 	// Todo: Add username to logger With() field?
@@ -49,28 +136,41 @@ func (mc *McClient) RequestMcUser(logger log.Logger, uuid string, mcUser mcuser.
 		logger = logger.With("username", username)
 		// Cache the Username -> UUID mapping
 		// Todo: Is it okay to copy these values to new object? Status?
-		go mc.CacheInsertUUIDEntry(logger, username, mc_uuid.UUIDEntry{
+		uuidEntry := mc_uuid.UUIDEntry{
 			UUID:      mcUserFresh.UUID,
 			Timestamp: mcUserFresh.Timestamp,
 			Status:    mcUserFresh.Status,
-		})
+		}
+		mc.goInsert(func() { mc.CacheInsertUUIDEntry(ctx, logger, username, uuidEntry) })
 	}
-	mc.CacheInsertMcUser(logger, uuid, mcUserFresh)
-	return mcUserFresh
+	mc.CacheInsertMcUser(ctx, logger, uuid, mcUserFresh)
+	return mcUserFresh, nil
 }
 
 // Remember to close the mcuser.TextureIO.ReadCloser if error is nil
-func (mc *McClient) RequestTexture(logger log.Logger, textureKey string, textureURL string) (textureIO mcuser.TextureIO, err error) {
+func (mc *McClient) RequestTexture(ctx context.Context, logger log.Logger, textureKey string, textureURL string) (textureIO mcuser.TextureIO, err error) {
 	// Use our API object for the request
 	textureIO.TextureID = textureKey
 
 	// Todo: Retry logic?
 
+	// The stage deadline also covers reading the body
+	upstream := mc.upstream()
+	apiCtx, cancel := stageContext(ctx, upstream.timeouts.Texture)
+	defer cancel()
+	apiCtx, span := tracer.Start(apiCtx, "Mojang FetchTexture", trace.WithAttributes(attribute.String("texture", textureKey)))
 	// Set Ctx Source for metrics
-	ctx := minecraft.CtxWithSource(context.Background(), "TextureFetch")
-	respBody, err := mc.API.ApiRequestCtx(ctx, textureURL)
+	apiCtx = minecraft.CtxWithSource(apiCtx, "TextureFetch")
+	start := time.Now()
+	respBody, err := upstream.api.ApiRequestCtx(apiCtx, textureURL)
 
 	if err != nil {
+		tracing.EndSpan(span, err)
+		access_log.FromContext(ctx).AddUpstream("TextureFetch", time.Since(start), err)
+		if requestAbandoned(ctx, logger, "TextureFetch") {
+			return
+		}
+		mc.recordUpstream(err)
 		logger.Warnf("Texture fetch failed: %v", err)
 		status.MetricTextureFetchError()
 		return
@@ -80,9 +180,29 @@ func (mc *McClient) RequestTexture(logger log.Logger, textureKey string, texture
 
 	// Todo: verify this isn't super inefficient..!
 
-	// Read the bytes so we can then send to cache
-	textureBytes, err := io.ReadAll(respBody)
-	mc.CacheInsertTexture(logger, textureKey, textureBytes)
+	// Read the bytes so we can then send to cache (beyond the limit, the texture is invalid anyway)
+	textureBytes, err := io.ReadAll(io.LimitReader(respBody, mcuser.MaxTextureBytes+1))
+	tracing.EndSpan(span, err)
+	access_log.FromContext(ctx).AddUpstream("TextureFetch", time.Since(start), err)
+	if err != nil {
+		// A partial texture (eg. from the deadline passing) must not be cached
+		if !requestAbandoned(ctx, logger, "TextureFetch") {
+			mc.recordUpstream(err)
+			logger.Warnf("Texture read failed: %v", err)
+			status.MetricTextureFetchError()
+		}
+		return
+	}
+	mc.recordUpstream(nil)
+
+	// Eg. an HTML error page or a truncated PNG must not be cached or served (so the failure is cached briefly instead)
+	if err = mcuser.ValidateSkinBytes(textureBytes); err != nil {
+		logger.Warnf("Invalid texture: %v", err)
+		status.MetricTextureInvalid()
+		mc.CacheInsertTextureStatus(ctx, logger, textureKey, status.StatusErrorInvalidTexture)
+		return textureIO, status.StatusErrorInvalidTexture
+	}
+	mc.CacheInsertTexture(ctx, logger, textureKey, textureBytes)
 
 	// Put the bytes back into a ReadCloser so we can use them later
 	textureIO.ReadCloser = io.NopCloser(bytes.NewReader(textureBytes))
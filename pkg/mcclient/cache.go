@@ -2,6 +2,7 @@ package mcclient
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,8 +11,13 @@ import (
 
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/mcclient/status"
 	"github.com/minotar/imgd/pkg/mcclient/uuid"
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,18 +28,52 @@ var (
 	ErrCacheDisabled = errors.New("Cache is disabled")
 )
 
-// Todo: metrics / tracing / timing
+// Todo: metrics / timing
+
+// isContextErr is true when the request context ended (vs. a genuine cache/API error)
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// startCacheSpan traces a cache operation (a TieredCache adds a child span for each tier)
+func startCacheSpan(ctx context.Context, c cache.Cache, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "Cache "+operation+" "+c.Name(), tracing.CacheAttributes(c.Name(), operation))
+}
+
+// cacheRetrieve traces and times the retrieve, recording whether it was a hit (a miss is not an error)
+func cacheRetrieve(ctx context.Context, c cache.Cache, key string) ([]byte, error) {
+	start := time.Now()
+	spanCtx, span := startCacheSpan(ctx, c, "retrieve")
+	value, err := cache.RetrieveCtx(spanCtx, c, key)
+	access_log.FromContext(ctx).AddTiming("cache", time.Since(start))
+
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if err == cache.ErrNotFound {
+		span.End()
+	} else {
+		tracing.EndSpan(span, err)
+	}
+	return value, err
+}
+
+// cacheInsert traces and times the insert
+func cacheInsert(ctx context.Context, c cache.Cache, key string, value []byte, ttl time.Duration) error {
+	start := time.Now()
+	_, span := startCacheSpan(ctx, c, "insert")
+	err := c.InsertTTL(key, value, ttl)
+	access_log.FromContext(ctx).AddTiming("cache", time.Since(start))
+	tracing.EndSpan(span, err)
+	return err
+}
 
 // Todo: Also, add function context to logger
 // User cache name and UUID in logger.With()?
 
 // CacheRetrieveUUIDEntry searches the cache based on Username, expecting a UUID in response
-func (mc *McClient) CacheRetrieveUUIDEntry(logger log.Logger, username string) (uuidEntry uuid.UUIDEntry, err error) {
+func (mc *McClient) CacheRetrieveUUIDEntry(ctx context.Context, logger log.Logger, username string) (uuidEntry uuid.UUIDEntry, err error) {
 	// logger should already be With() the username
 	username = strings.ToLower(username)
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	uuidBytes, err := mc.Caches.UUID.Retrieve(username)
+	uuidBytes, err := cacheRetrieve(ctx, mc.Caches.UUID, username)
 	// Observe Cache retrieve
 	if err != nil {
 		// Return an error (and log based on severity)
@@ -41,6 +81,10 @@ func (mc *McClient) CacheRetrieveUUIDEntry(logger log.Logger, username string) (
 			// Metrics stat "Miss"
 			logger.Debugf("Did not find username in %s", mc.Caches.UUID.Name())
 			return
+		} else if isContextErr(err) {
+			// The request ended before the cache lookup completed
+			logger.Debugf("Abandoned lookup in cache: %v", err)
+			return
 		} else {
 			// Metrics stat Cache RetrieveError
 			logger.Errorf("Failed to lookup up username in %s: %v", mc.Caches.UUID.Name(), err)
@@ -65,15 +109,13 @@ func (mc *McClient) CacheRetrieveUUIDEntry(logger log.Logger, username string) (
 // CacheInsertUUIDEntry takes a valid UUIDEntry and caches it
 // There is no sanity checking on the input
 // The item is cached witha TTL assuming it's brand-new
-func (mc *McClient) CacheInsertUUIDEntry(logger log.Logger, username string, uuidEntry uuid.UUIDEntry) (err error) {
+func (mc *McClient) CacheInsertUUIDEntry(ctx context.Context, logger log.Logger, username string, uuidEntry uuid.UUIDEntry) (err error) {
 	// logger should already be With() the username and UUID
 	username = strings.ToLower(username)
 	// Technically this could be empty / nil???
 	uuidBytes := uuidEntry.Encode()
 
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	err = mc.Caches.UUID.InsertTTL(username, uuidBytes, uuidEntry.TTL())
+	err = cacheInsert(ctx, mc.Caches.UUID, username, uuidBytes, uuidEntry.TTL())
 	// Observe Cache insert
 	if err != nil {
 		// stats.CacheUUID("error")
@@ -82,12 +124,10 @@ func (mc *McClient) CacheInsertUUIDEntry(logger log.Logger, username string, uui
 	return
 }
 
-func (mc *McClient) CacheRetrieveMcUser(logger log.Logger, uuid string) (user mcuser.McUser, err error) {
+func (mc *McClient) CacheRetrieveMcUser(ctx context.Context, logger log.Logger, uuid string) (user mcuser.McUser, err error) {
 	// logger should already be With() the UUID (and maybe username)
 	uuid = strings.ToLower(uuid)
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	userBytes, err := mc.Caches.UserData.Retrieve(uuid)
+	userBytes, err := cacheRetrieve(ctx, mc.Caches.UserData, uuid)
 	// Observe Cache retrieve
 	if err != nil {
 		// Return an error (and log based on severity)
@@ -95,6 +135,10 @@ func (mc *McClient) CacheRetrieveMcUser(logger log.Logger, uuid string) (user mc
 			// Metrics stat "Miss"
 			logger.Debugf("Did not find uuid in %s", mc.Caches.UserData.Name())
 			return
+		} else if isContextErr(err) {
+			// The request ended before the cache lookup completed
+			logger.Debugf("Abandoned lookup in cache: %v", err)
+			return
 		} else {
 			// Metrics stat Cache RetrieveError
 			logger.Errorf("Failed to lookup up uuid in %s: %v", mc.Caches.UserData.Name(), err)
@@ -113,7 +157,7 @@ func (mc *McClient) CacheRetrieveMcUser(logger log.Logger, uuid string) (user mc
 	return
 }
 
-func (mc *McClient) CacheInsertMcUser(logger log.Logger, uuid string, user mcuser.McUser) (err error) {
+func (mc *McClient) CacheInsertMcUser(ctx context.Context, logger log.Logger, uuid string, user mcuser.McUser) (err error) {
 	// logger should already be With() the UUID (and maybe username)
 	uuid = strings.ToLower(uuid)
 	packedUserBytes, err := user.Compress()
@@ -123,9 +167,7 @@ func (mc *McClient) CacheInsertMcUser(logger log.Logger, uuid string, user mcuse
 		return
 	}
 
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	err = mc.Caches.UserData.InsertTTL(uuid, packedUserBytes, user.TTL())
+	err = cacheInsert(ctx, mc.Caches.UserData, uuid, packedUserBytes, user.TTL())
 	// Observe Cache insert
 	if err != nil {
 		// stats.CacheUser("insert_error")
@@ -135,7 +177,7 @@ func (mc *McClient) CacheInsertMcUser(logger log.Logger, uuid string, user mcuse
 }
 
 // Remember to close the mcuser.TextureIO.ReadCloser if error is nil
-func (mc *McClient) CacheRetrieveTexture(logger log.Logger, textureKey string) (textureIO mcuser.TextureIO, err error) {
+func (mc *McClient) CacheRetrieveTexture(ctx context.Context, logger log.Logger, textureKey string) (textureIO mcuser.TextureIO, err error) {
 	if mc.Caches.Textures == nil {
 		// Cache is disabled
 		return textureIO, ErrCacheDisabled
@@ -143,9 +185,7 @@ func (mc *McClient) CacheRetrieveTexture(logger log.Logger, textureKey string) (
 	// We intentionally leave the case of the texture URL untouched (though it appears to always be lowercase anyway)
 	// logger should already be With() the skinPath/texturePath (and UUID and Username)
 
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	textureBytes, err := mc.Caches.Textures.Retrieve(textureKey)
+	textureBytes, err := cacheRetrieve(ctx, mc.Caches.Textures, textureKey)
 	// Observe Cache retrieve
 	if err != nil {
 		// Return an error (and log based on severity)
@@ -153,6 +193,10 @@ func (mc *McClient) CacheRetrieveTexture(logger log.Logger, textureKey string) (
 			// Metrics stat "Miss"
 			logger.Debugf("Did not find texture in %s", mc.Caches.Textures.Name())
 			return
+		} else if isContextErr(err) {
+			// The request ended before the cache lookup completed
+			logger.Debugf("Abandoned lookup in cache: %v", err)
+			return
 		} else {
 			// Metrics stat Cache RetrieveError
 			logger.Errorf("Failed to lookup up texture in %s: %v", mc.Caches.Textures.Name(), err)
@@ -160,6 +204,18 @@ func (mc *McClient) CacheRetrieveTexture(logger log.Logger, textureKey string) (
 		}
 	}
 
+	if len(textureBytes) == 1 {
+		// A failed texture is cached as just its Status (a PNG is always longer)
+		logger.Debugf("Found failed texture in %s", mc.Caches.Textures.Name())
+		return textureIO, status.Status(textureBytes[0]).GetError()
+	}
+	// The texture was fully decoded before it was cached, so only the header is checked
+	if err = mcuser.ValidateSkinHeader(textureBytes); err != nil {
+		// Eg. cached before textures were validated, so it's re-requested
+		logger.Warnf("Invalid texture in %s: %v", mc.Caches.Textures.Name(), err)
+		return textureIO, cache.ErrNotFound
+	}
+
 	textureIO.ReadCloser = io.NopCloser(bytes.NewReader(textureBytes))
 	textureIO.TextureID = textureKey
 
@@ -168,7 +224,7 @@ func (mc *McClient) CacheRetrieveTexture(logger log.Logger, textureKey string) (
 	return
 }
 
-func (mc *McClient) CacheInsertTexture(logger log.Logger, textureKey string, textureBytes []byte) (err error) {
+func (mc *McClient) CacheInsertTexture(ctx context.Context, logger log.Logger, textureKey string, textureBytes []byte) (err error) {
 	if mc.Caches.Textures == nil {
 		// Cache is disabled
 		return nil
@@ -178,9 +234,7 @@ func (mc *McClient) CacheInsertTexture(logger log.Logger, textureKey string, tex
 
 	//textureBytes, err := io.ReadAll(textureIO.ReadCloser)
 
-	// Metrics timer / tracing
-	// Though - is this useless when using a TieredCache which is inherentantly varied?
-	err = mc.Caches.Textures.InsertTTL(textureKey, textureBytes, skinTTL)
+	err = cacheInsert(ctx, mc.Caches.Textures, textureKey, textureBytes, skinTTL)
 	// Observe Cache insert
 	if err != nil {
 		// stats.CacheUser("insert_error")
@@ -188,3 +242,17 @@ func (mc *McClient) CacheInsertTexture(logger log.Logger, textureKey string, tex
 	}
 	return
 }
+
+// CacheInsertTextureStatus caches the failed texture as its Status (with the Status' texture TTL)
+func (mc *McClient) CacheInsertTextureStatus(ctx context.Context, logger log.Logger, textureKey string, textureStatus status.Status) (err error) {
+	if mc.Caches.Textures == nil {
+		// Cache is disabled
+		return nil
+	}
+
+	err = cacheInsert(ctx, mc.Caches.Textures, textureKey, []byte{textureStatus.Byte()}, textureStatus.DurationTexture())
+	if err != nil {
+		logger.Errorf("Failed Insert texture status into cache %s: %v", mc.Caches.Textures.Name(), err)
+	}
+	return
+}
@@ -2,24 +2,50 @@ package mcclient
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/minotar/imgd/pkg/cache/util/config"
+	"github.com/minotar/imgd/pkg/mcclient/status"
 	"github.com/minotar/imgd/pkg/minecraft"
 	"github.com/minotar/imgd/pkg/minecraft/minecraft_trace"
+	"github.com/minotar/imgd/pkg/util/health"
+	"github.com/minotar/imgd/pkg/util/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// upstreamErrorWindow is how far back the upstream error rate (for readiness) looks
+const upstreamErrorWindow = time.Minute
+
+// ReloadableSettings are the settings which Reload applies (a "." suffix covers each setting in the section)
+var ReloadableSettings = []string{
+	"mcclient.upstream-timeout",
+	"mcclient.uuid-timeout",
+	"mcclient.userdata-timeout",
+	"mcclient.texture-timeout",
+	"mcclient.useragent",
+	"mcclient.sessionserver-url",
+	"mcclient.profile-url",
+	"mcclient.textures-url",
+	"mcclient.ttl.",
+}
+
 type Config struct {
-	UpstreamTimeout  time.Duration `yaml:"upstream_timeout"`
-	UserAgent        string        `yaml:"useragent"`
-	SessionServerURL string        `yaml:"sessionserver_url"`
-	ProfileURL       string        `yaml:"profile_url"`
+	UpstreamTimeout  time.Duration
+	UUIDTimeout      time.Duration
+	UserDataTimeout  time.Duration
+	TextureTimeout   time.Duration
+	UserAgent        string
+	SessionServerURL string
+	ProfileURL       string
 	TexturesBaseURL  string
-	CacheUUID        *config.Config `yaml:"cache_uuid"`
-	CacheUserData    *config.Config `yaml:"cache_userdata"`
-	CacheTextures    *config.Config `yaml:"cache_textures"`
+	CacheUUID        *config.Config
+	CacheUserData    *config.Config
+	CacheTextures    *config.Config
+	TTLPolicy        status.TTLPolicy
 }
 
 // RegisterFlags registers flag.
@@ -30,6 +56,9 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.CacheTextures = &config.Config{}
 
 	f.DurationVar(&c.UpstreamTimeout, "mcclient.upstream-timeout", 10*time.Second, "Timeout for Minecraft API Client")
+	f.DurationVar(&c.UUIDTimeout, "mcclient.uuid-timeout", 5*time.Second, "Deadline for a Username -> UUID lookup (0 to only use the upstream-timeout)")
+	f.DurationVar(&c.UserDataTimeout, "mcclient.userdata-timeout", 5*time.Second, "Deadline for a UUID -> SessionProfile lookup (0 to only use the upstream-timeout)")
+	f.DurationVar(&c.TextureTimeout, "mcclient.texture-timeout", 5*time.Second, "Deadline for a texture fetch (0 to only use the upstream-timeout)")
 	f.StringVar(&c.UserAgent, "mcclient.useragent", "minotar/imgd (https://github.com/minotar/imgd) - default", "UserAgent for Minecraft API Client")
 	f.StringVar(&c.SessionServerURL, "mcclient.sessionserver-url", "https://sessionserver.mojang.com/session/minecraft/profile/", "API for UUID -> Texture Properties")
 	f.StringVar(&c.ProfileURL, "mcclient.profile-url", "https://api.mojang.com/users/profiles/minecraft/", "API for Username -> UUID lookups")
@@ -37,12 +66,48 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.CacheUUID.RegisterFlags(f, "UUID")
 	c.CacheUserData.RegisterFlags(f, "UserData")
 	c.CacheTextures.RegisterFlags(f, "Textures")
+	c.TTLPolicy.RegisterFlags(f, "mcclient.ttl.")
 
 }
 
-func NewMcClient(cfg *Config) *McClient {
+func (c *Config) Validate() error {
+	for name, apiURL := range map[string]string{
+		"mcclient.sessionserver-url": c.SessionServerURL,
+		"mcclient.profile-url":       c.ProfileURL,
+	} {
+		if u, err := url.Parse(apiURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s \"%s\" should be an absolute URL", name, apiURL)
+		}
+	}
+	for name, timeout := range map[string]time.Duration{
+		"mcclient.upstream-timeout": c.UpstreamTimeout,
+		"mcclient.uuid-timeout":     c.UUIDTimeout,
+		"mcclient.userdata-timeout": c.UserDataTimeout,
+		"mcclient.texture-timeout":  c.TextureTimeout,
+	} {
+		if timeout < 0 {
+			return fmt.Errorf("%s should not be negative", name)
+		}
+	}
+	for _, cacheCfg := range []*config.Config{c.CacheUUID, c.CacheUserData} {
+		if strings.ToLower(cacheCfg.CacheType) == "none" {
+			return fmt.Errorf("cache \"%s\" is required", cacheCfg.Name)
+		}
+	}
+	for _, cacheCfg := range []*config.Config{c.CacheUUID, c.CacheUserData, c.CacheTextures} {
+		if err := cacheCfg.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.TTLPolicy.Validate(); err != nil {
+		return fmt.Errorf("mcclient.%w", err)
+	}
+	return nil
+}
 
-	minecraftCfg := minecraft.Config{
+// minecraftConfig is the API client config (the URLs, UserAgent and timeout)
+func minecraftConfig(cfg *Config) minecraft.Config {
+	return minecraft.Config{
 		UUIDAPIConfig: minecraft.UUIDAPIConfig{
 			SessionServerURL: cfg.SessionServerURL,
 			ProfileURL:       cfg.ProfileURL,
@@ -50,6 +115,11 @@ func NewMcClient(cfg *Config) *McClient {
 		UserAgent:      cfg.UserAgent,
 		RequestTimeout: cfg.UpstreamTimeout,
 	}
+}
+
+func NewMcClient(cfg *Config) *McClient {
+
+	minecraftCfg := minecraftConfig(cfg)
 
 	mc := &minecraft.Minecraft{
 		Client: &http.Client{
@@ -67,14 +137,50 @@ func NewMcClient(cfg *Config) *McClient {
 		GotFirstResponseByte: apiClientTraceDuration.MustCurryWith(prometheus.Labels{"event": "timeToFirstByte"}),
 	}
 
-	mc.Client.Transport = minecraft_trace.InstrumentRoundTripperInFlight(apiClientInflight,
-		minecraft_trace.InstrumentRoundTripperTrace(trace,
-			minecraft_trace.InstrumentRoundTripperDuration(apiClientDuration, http.DefaultTransport),
+	mc.Client.Transport = tracing.Transport("mcclient",
+		minecraft_trace.InstrumentRoundTripperInFlight(apiClientInflight,
+			minecraft_trace.InstrumentRoundTripperTrace(trace,
+				minecraft_trace.InstrumentRoundTripperDuration(apiClientDuration, http.DefaultTransport),
+			),
 		),
 	)
 
-	return &McClient{
+	mcClient := &McClient{
 		API:             mc,
 		TexturesBaseURL: cfg.TexturesBaseURL,
+		Timeouts:        configTimeouts(cfg),
+		UpstreamErrors:  health.NewErrorRate(upstreamErrorWindow),
+	}
+	status.SetTTLPolicy(cfg.TTLPolicy)
+
+	return mcClient
+}
+
+func configTimeouts(cfg *Config) Timeouts {
+	return Timeouts{
+		UUID:     cfg.UUIDTimeout,
+		UserData: cfg.UserDataTimeout,
+		Texture:  cfg.TextureTimeout,
 	}
 }
+
+// Reload swaps in the upstream URLs, UserAgent, timeouts and TTL policy (the caches are untouched)
+// Requests already in progress finish with the previous settings
+func (mc *McClient) Reload(cfg *Config) {
+	current := mc.upstream()
+	api := &minecraft.Minecraft{
+		// The instrumented Transport (and its connections) are kept
+		Client: &http.Client{
+			Timeout:   cfg.UpstreamTimeout,
+			Transport: current.api.Client.Transport,
+		},
+		Cfg: minecraftConfig(cfg),
+	}
+
+	mc.reloaded.Store(&upstream{
+		api:             api,
+		texturesBaseURL: cfg.TexturesBaseURL,
+		timeouts:        configTimeouts(cfg),
+	})
+	status.SetTTLPolicy(cfg.TTLPolicy)
+}
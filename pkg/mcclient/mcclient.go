@@ -2,15 +2,27 @@
 package mcclient
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/health"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/mcclient/status"
 	mc_uuid "github.com/minotar/imgd/pkg/mcclient/uuid"
 	"github.com/minotar/imgd/pkg/minecraft"
 )
 
-// Todo: tracing
+var tracer = tracing.Tracer("mcclient")
+
 // Todo: Counters also support exemplars! eg. cache error metric + Request ID
 
 // Todo: Could have a base logger which we then apply context to when needed
@@ -22,52 +34,148 @@ type McClient struct {
 	}
 	API             *minecraft.Minecraft
 	TexturesBaseURL string
+	Timeouts        Timeouts
+	// The upstream settings swapped in by Reload (replacing API/TexturesBaseURL/Timeouts)
+	reloaded atomic.Value
+	// Recent upstream results, used by the readiness check
+	UpstreamErrors *health.ErrorRate
+	// Cache inserts running in the background, which Close waits for
+	pendingInserts sync.WaitGroup
+}
+
+// Timeouts are the deadlines for each upstream stage of a request (0 is no extra deadline)
+type Timeouts struct {
+	UUID     time.Duration
+	UserData time.Duration
+	Texture  time.Duration
+}
+
+// upstream is the set of settings used for each API request
+type upstream struct {
+	api             *minecraft.Minecraft
+	texturesBaseURL string
+	timeouts        Timeouts
+}
+
+// upstream returns the reloaded settings, or otherwise the ones the McClient was created with
+func (mc *McClient) upstream() *upstream {
+	if u, ok := mc.reloaded.Load().(*upstream); ok {
+		return u
+	}
+	return &upstream{
+		api:             mc.API,
+		texturesBaseURL: mc.TexturesBaseURL,
+		timeouts:        mc.Timeouts,
+	}
+}
+
+// goInsert runs a cache insert in the background (tracked so Close can wait for it)
+func (mc *McClient) goInsert(insert func()) {
+	mc.pendingInserts.Add(1)
+	go func() {
+		defer mc.pendingInserts.Done()
+		insert()
+	}()
+}
+
+// Close waits for any pending cache inserts, then stops and closes each cache
+func (mc *McClient) Close() {
+	mc.pendingInserts.Wait()
+	for _, c := range []cache.Cache{mc.Caches.UUID, mc.Caches.UserData, mc.Caches.Textures} {
+		if c != nil {
+			c.Close()
+		}
+	}
 }
 
-// Todo: I need to be providing logging and request context in here
 // This Method will decode the buffer into a Texture (fine for processing, but avoid if you are serving the plain skin)
-func (mc *McClient) GetSkinFromReq(logger log.Logger, userReq UserReq) minecraft.Skin {
-	logger, textureIO := mc.GetSkinBufferFromReq(logger, userReq)
+func (mc *McClient) GetSkinFromReq(ctx context.Context, logger log.Logger, userReq UserReq) minecraft.Skin {
+	logger, textureIO := mc.GetSkinBufferFromReq(ctx, logger, userReq)
 
+	_, span := tracer.Start(ctx, "Texture decode", trace.WithAttributes(attribute.String("texture", textureIO.TextureID)))
+	defer span.End()
+	defer access_log.FromContext(ctx).AddTimingSince("decode", time.Now())
 	// Return decoded skin (or Steve)
 	return textureIO.MustDecodeSkin(logger)
 }
 
+// FallbackReason names why the Steve skin was used (kept to a few values as it's a metric label)
+func FallbackReason(err error) string {
+	switch {
+	case isContextErr(err):
+		return "abandoned"
+	case err == status.StatusErrorUnknownUser:
+		return "unknown_user"
+	case err == status.StatusErrorRateLimit:
+		return "rate_limit"
+	case err == status.StatusErrorGeneric:
+		return "lookup_error"
+	case err == status.StatusErrorInvalidTexture:
+		return "texture_invalid"
+	default:
+		return "error"
+	}
+}
+
+// TextureFallbackReason names why the Steve skin was used when the skin texture failed
+func TextureFallbackReason(err error) string {
+	if err == status.StatusErrorInvalidTexture {
+		return FallbackReason(err)
+	}
+	return "texture_error"
+}
+
+// Remember to close the mcuser.TextureIO.ReadCloser!
+func (mc *McClient) GetSkinBufferFromReq(ctx context.Context, logger log.Logger, userReq UserReq) (log.Logger, mcuser.TextureIO) {
+	logger, textureIO, _ := mc.GetSkinBufferOrFallback(ctx, logger, userReq)
+	return logger, textureIO
+}
+
+// GetSkinBufferOrFallback returns the skin of the user, or Steve with the FallbackReason (empty when it's the skin of the user)
 // Remember to close the mcuser.TextureIO.ReadCloser!
-func (mc *McClient) GetSkinBufferFromReq(logger log.Logger, userReq UserReq) (log.Logger, mcuser.TextureIO) {
-	logger, mcUser, err := mc.GetMcUserFromReq(logger, userReq)
+func (mc *McClient) GetSkinBufferOrFallback(ctx context.Context, logger log.Logger, userReq UserReq) (log.Logger, mcuser.TextureIO, string) {
+	logger, mcUser, err := mc.GetMcUserFromReq(ctx, logger, userReq)
+	if err != nil {
+		logger.Debugf("Falling back to Steve: %v", err)
+		reason := FallbackReason(err)
+		access_log.FromContext(ctx).SetFallback(reason)
+		return logger, mcuser.GetSteveTextureIO(), reason
+	}
+
+	textureIO, err := mc.GetSkinTexture(ctx, logger, mcUser)
 	if err != nil {
 		logger.Debugf("Falling back to Steve: %v", err)
-		return logger, mcuser.GetSteveTextureIO()
+		reason := TextureFallbackReason(err)
+		access_log.FromContext(ctx).SetFallback(reason)
+		return logger, mcuser.GetSteveTextureIO(), reason
 	}
 
+	return logger, textureIO, ""
+}
+
+// GetSkinTexture returns the skin of the McUser (from the cache, or the textures server)
+// Remember to close the mcuser.TextureIO.ReadCloser if error is nil
+func (mc *McClient) GetSkinTexture(ctx context.Context, logger log.Logger, mcUser mcuser.McUser) (mcuser.TextureIO, error) {
 	// We use the SkinPath (which is either just the hash, or a full URL if the base URL changes)
 	textureKey := mcUser.Textures.SkinPath
 	var textureURL string
-	if mc.TexturesBaseURL == "" {
+	if texturesBaseURL := mc.upstream().texturesBaseURL; texturesBaseURL == "" {
 		textureURL = mcUser.Textures.SkinURL()
 	} else {
-		textureURL = mcUser.Textures.CustomSkinURL(mc.TexturesBaseURL)
-	}
-
-	textureIO, err := mc.GetTexture(logger, textureKey, textureURL)
-
-	if err != nil {
-		logger.Debugf("Falling back to Steve: %v", err)
-		return logger, mcuser.GetSteveTextureIO()
+		textureURL = mcUser.Textures.CustomSkinURL(texturesBaseURL)
 	}
 
-	return logger, textureIO
+	return mc.GetTexture(ctx, logger, textureKey, textureURL)
 }
 
-func (mc *McClient) GetMcUserFromReq(logger log.Logger, userReq UserReq) (log.Logger, mcuser.McUser, error) {
-	logger, uuid, err := userReq.GetUUID(logger, mc)
+func (mc *McClient) GetMcUserFromReq(ctx context.Context, logger log.Logger, userReq UserReq) (log.Logger, mcuser.McUser, error) {
+	logger, uuid, err := userReq.GetUUID(ctx, logger, mc)
 	if err != nil {
 		return logger, mcuser.McUser{}, err
 	}
 
 	logger = logger.With("uuid", uuid)
-	mcUser, err := mc.GetMcUser(logger, uuid)
+	mcUser, err := mc.GetMcUser(ctx, logger, uuid)
 	if err != nil {
 		return logger, mcuser.McUser{}, err
 	}
@@ -83,95 +191,105 @@ func (mc *McClient) GetMcUserFromReq(logger log.Logger, userReq UserReq) (log.Lo
 // Todo: Do we want a WaitGroup here?
 // Only real downside is that we can't goroutine to insert into cache?
 // Unless we have 2 locks? 1 here, and then one that blocks reads when writing?
-func (mc *McClient) GetUUIDEntry(logger log.Logger, username string) (uuidEntry mc_uuid.UUIDEntry, err error) {
-	uuidEntry, err = mc.CacheRetrieveUUIDEntry(logger, username)
+func (mc *McClient) GetUUIDEntry(ctx context.Context, logger log.Logger, username string) (uuidEntry mc_uuid.UUIDEntry, err error) {
+	uuidEntry, err = mc.CacheRetrieveUUIDEntry(ctx, logger, username)
 	if err != nil {
 		if err == cache.ErrNotFound {
 			// We cache missed (cache.ErrNotFound)
-			uuidCacheStatus.Miss()
+			uuidCacheStatus.Miss(ctx)
 			// Let's request from API
-			uuidEntry = mc.RequestUUIDEntry(logger, username, uuidEntry)
+			uuidEntry = mc.RequestUUIDEntry(ctx, logger, username, uuidEntry)
 			// We need to generate a new error though
 			return uuidEntry, uuidEntry.Status.GetError()
+		} else if isContextErr(err) {
+			return
 		} else {
 			// Cache experieneed a proper error (already would be logged)
-			uuidCacheStatus.Error()
+			uuidCacheStatus.Error(ctx)
 			return
 		}
 	}
 
 	// Cache was a hit (though still might be a bad result)
-	uuidCacheStatus.Hit()
+	uuidCacheStatus.Hit(ctx)
 
 	if uuidEntry.IsValid() {
 		if uuidEntry.IsFresh() {
 			// Great success - we have a cached result
-			uuidCacheStatus.Fresh()
+			uuidCacheStatus.Fresh(ctx)
 			return
 		}
 		// A stale result should be re-requested
-		uuidCacheStatus.Stale()
+		uuidCacheStatus.Stale(ctx)
 		logger.Debugf("Stale UUIDEntry was dated: %v", uuidEntry.Timestamp.Time())
-		return mc.RequestUUIDEntry(logger, username, uuidEntry), nil
+		return mc.RequestUUIDEntry(ctx, logger, username, uuidEntry), nil
 	}
 
 	// A bad result was returned from the cache, generate an error from it
 	return uuidEntry, uuidEntry.Status.GetError()
 }
 
-func (mc *McClient) GetMcUser(logger log.Logger, uuid string) (mcUser mcuser.McUser, err error) {
-	mcUser, err = mc.CacheRetrieveMcUser(logger, uuid)
+func (mc *McClient) GetMcUser(ctx context.Context, logger log.Logger, uuid string) (mcUser mcuser.McUser, err error) {
+	mcUser, err = mc.CacheRetrieveMcUser(ctx, logger, uuid)
 	if err != nil {
 		if err == cache.ErrNotFound {
 			// We cache missed (cache.ErrNotFound)
-			userdataCacheStatus.Miss()
+			userdataCacheStatus.Miss(ctx)
 			// Let's request from API
-			mcUser = mc.RequestMcUser(logger, uuid, mcUser)
+			mcUser = mc.RequestMcUser(ctx, logger, uuid, mcUser)
 			// We need to generate a new error though
 			return mcUser, mcUser.Status.GetError()
+		} else if isContextErr(err) {
+			return
 		} else {
 			// Cache experieneed a proper error (already would be logged)
-			userdataCacheStatus.Error()
+			userdataCacheStatus.Error(ctx)
 			return
 		}
 	}
 
 	// Cache was a hit (though still might be a bad result)
-	userdataCacheStatus.Hit()
+	userdataCacheStatus.Hit(ctx)
 
 	if mcUser.IsValid() {
 		if mcUser.IsFresh() {
 			// Known positive result
-			userdataCacheStatus.Fresh()
+			userdataCacheStatus.Fresh(ctx)
 			return
 		}
 		// A stale result should be re-requested
-		userdataCacheStatus.Stale()
+		userdataCacheStatus.Stale(ctx)
 		logger.Debugf("Stale McUser was dated: %v", mcUser.Timestamp.Time())
-		return mc.RequestMcUser(logger, uuid, mcUser), nil
+		return mc.RequestMcUser(ctx, logger, uuid, mcUser), nil
 	}
 
 	// A bad result was returned from the cache, generate an error from it
 	return mcUser, mcUser.Status.GetError()
 }
 
-func (mc *McClient) GetTexture(logger log.Logger, textureKey string, textureURL string) (textureIO mcuser.TextureIO, err error) {
-	textureIO, err = mc.CacheRetrieveTexture(logger, textureKey)
+func (mc *McClient) GetTexture(ctx context.Context, logger log.Logger, textureKey string, textureURL string) (textureIO mcuser.TextureIO, err error) {
+	textureIO, err = mc.CacheRetrieveTexture(ctx, logger, textureKey)
 	if err == ErrCacheDisabled {
-		return mc.RequestTexture(logger, textureKey, textureURL)
+		return mc.RequestTexture(ctx, logger, textureKey, textureURL)
 	} else if err == cache.ErrNotFound {
 		// We cache missed (cache.ErrNotFound)
-		textureCacheStatus.Miss()
+		textureCacheStatus.Miss(ctx)
 		// Let's request from API
-		return mc.RequestTexture(logger, textureKey, textureURL)
+		return mc.RequestTexture(ctx, logger, textureKey, textureURL)
+	} else if isContextErr(err) {
+		return
+	} else if err == status.StatusErrorInvalidTexture {
+		// A recently invalid texture is not re-requested until its TTL expires
+		textureCacheStatus.Hit(ctx)
+		return
 	} else if err != nil {
 		// Cache experieneed a proper error (already would be logged)
-		textureCacheStatus.Error()
+		textureCacheStatus.Error(ctx)
 		// Let's re-request anyway - there is no ratelimit
-		return mc.RequestTexture(logger, textureKey, textureURL)
+		return mc.RequestTexture(ctx, logger, textureKey, textureURL)
 	}
 
-	// Cache was a hit (we don't have logic to cache bad textures)
-	textureCacheStatus.Hit()
+	// Cache was a hit (with a valid texture)
+	textureCacheStatus.Hit(ctx)
 	return
 }
@@ -1,12 +1,15 @@
 package mcclient
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	"github.com/minotar/imgd/pkg/mcclient/status"
 	"github.com/minotar/imgd/pkg/minecraft"
 	"github.com/minotar/imgd/pkg/minecraft/mockminecraft"
 	"github.com/minotar/imgd/pkg/util/log"
@@ -55,11 +58,12 @@ func newMcClient(t testReporter, size int) (*McClient, func()) {
 }
 
 func TestUsername(t *testing.T) {
+	ctx := context.Background()
 	logger := log.NewBuiltinLogger(1)
 	mcClient, shutdown := newMcClient(t, 5)
 	defer shutdown()
 
-	uuidEntry, err := mcClient.GetUUIDEntry(logger, "lukehandle")
+	uuidEntry, err := mcClient.GetUUIDEntry(ctx, logger, "lukehandle")
 	if err != nil {
 		t.Fatalf("Get UUID ENtry failed: %v", err)
 	}
@@ -69,16 +73,190 @@ func TestUsername(t *testing.T) {
 	}
 }
 
+func TestAbandonedRequest(t *testing.T) {
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 5)
+	defer shutdown()
+
+	// The client went away before the lookup
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := mcClient.GetUUIDEntry(ctx, logger, "lukehandle"); err == nil {
+		t.Fatal("Abandoned request should have errored")
+	}
+	if len := mcClient.Caches.UUID.Len(); len != 0 {
+		t.Errorf("Abandoned request should not be cached, but cache has %d keys", len)
+	}
+}
+
+func TestStageDeadline(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 5)
+	defer shutdown()
+	mcClient.Timeouts.UUID = time.Nanosecond
+
+	// An upstream which is too slow is an API error, so it is cached (with the shorter error TTL)
+	if _, err := mcClient.GetUUIDEntry(ctx, logger, "lukehandle"); err != status.StatusErrorGeneric {
+		t.Fatalf("Deadline should have caused a generic error, not: %v", err)
+	}
+	uuidEntry, err := mcClient.CacheRetrieveUUIDEntry(ctx, logger, "lukehandle")
+	if err != nil || uuidEntry.Status != status.StatusErrorGeneric {
+		t.Errorf("Error result should have been cached: %v %v", uuidEntry, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 5)
+	defer shutdown()
+	defer status.SetTTLPolicy(status.DefaultTTLPolicy)
+
+	cfg := &Config{
+		SessionServerURL: mcClient.API.Cfg.SessionServerURL,
+		ProfileURL:       mcClient.API.Cfg.ProfileURL,
+		UUIDTimeout:      time.Nanosecond,
+		TTLPolicy:        status.DefaultTTLPolicy,
+	}
+	cfg.TTLPolicy.UUIDError = 42 * time.Minute
+	mcClient.Reload(cfg)
+
+	if ttl := status.StatusErrorGeneric.DurationUUID(); ttl != 42*time.Minute {
+		t.Errorf("Reloaded TTL policy should be used, not: %v", ttl)
+	}
+	if mcClient.upstream().api.Client.Transport != mcClient.API.Client.Transport {
+		t.Error("Reloaded API client should keep the Transport")
+	}
+	// The reloaded deadline applies to new requests
+	if _, err := mcClient.GetUUIDEntry(ctx, logger, "lukehandle"); err != status.StatusErrorGeneric {
+		t.Errorf("Reloaded deadline should have caused a generic error, not: %v", err)
+	}
+}
+
+func TestCloseWaitsForInserts(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 5)
+	defer shutdown()
+
+	// The Username -> UUID mapping from the SessionProfile is cached in the background
+	if _, err := mcClient.GetMcUser(ctx, logger, "5c115ca73efd41178213a0aff8ef11e0"); err != nil {
+		t.Fatalf("GetMcUser failed: %v", err)
+	}
+	mcClient.Close()
+
+	if _, err := mcClient.Caches.UUID.Retrieve("lukehandle"); err != nil {
+		t.Errorf("Close should wait for the pending UUID insert: %v", err)
+	}
+}
+
+func TestInvalidTexture(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 5)
+	defer shutdown()
+
+	// An invalid texture cached before validation is re-requested (and the upstream /200 is empty)
+	mcClient.Caches.Textures.Insert("invalid", []byte("<html>Error</html>"))
+	for i := 0; i < 2; i++ {
+		if _, err := mcClient.GetTexture(ctx, logger, "invalid", "http://example.com/200"); err != status.StatusErrorInvalidTexture {
+			t.Fatalf("Expected an invalid texture error, not: %v", err)
+		}
+		// The failure is cached instead of the bytes
+		cached, err := mcClient.Caches.Textures.Retrieve("invalid")
+		if err != nil || len(cached) != 1 || status.Status(cached[0]) != status.StatusErrorInvalidTexture {
+			t.Errorf("Expected the invalid texture status to be cached, not: %v %v", cached, err)
+		}
+	}
+
+	ttl, err := mcClient.Caches.Textures.TTL("invalid")
+	if err != nil || ttl > status.DefaultTTLPolicy.TextureInvalid {
+		t.Errorf("Expected the invalid texture TTL, not: %v %v", ttl, err)
+	}
+}
+
+func TestRefreshUser(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 10)
+	defer shutdown()
+
+	_, mcUser, err := mcClient.RefreshUser(ctx, logger, UserReq{Username: "clone1018"})
+	if err != nil {
+		t.Fatalf("RefreshUser failed: %v", err)
+	}
+	if mcUser.UUID != "d9135e082f2244c89cb0bee234155292" {
+		t.Errorf("McUser was not expected: %v", mcUser)
+	}
+
+	cached, err := mcClient.CacheRetrieveMcUser(ctx, logger, mcUser.UUID)
+	if err != nil || cached.Textures.SkinPath != mcUser.Textures.SkinPath {
+		t.Errorf("Refreshed McUser should have been cached: %v %v", cached, err)
+	}
+}
+
+func TestRefreshUserFailed(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 10)
+	defer shutdown()
+
+	_, mcUser, err := mcClient.RefreshUser(ctx, logger, UserReq{Username: "clone1018"})
+	if err != nil {
+		t.Fatalf("RefreshUser failed: %v", err)
+	}
+	// A valid cached McUser for a UUID whose session request fails
+	uuid := "00000000000000000000000000000007"
+	mcClient.CacheInsertMcUser(ctx, logger, uuid, mcUser)
+
+	if _, _, err := mcClient.RefreshUser(ctx, logger, UserReq{UUID: uuid}); err == nil {
+		t.Error("RefreshUser should fail when the request failed and the cached McUser was kept")
+	}
+	cached, err := mcClient.CacheRetrieveMcUser(ctx, logger, uuid)
+	if err != nil || !cached.IsValid() {
+		t.Errorf("The cached McUser should not have been replaced: %v %v", cached, err)
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewBuiltinLogger(1)
+	mcClient, shutdown := newMcClient(t, 10)
+	defer shutdown()
+
+	mcClient.GetSkinFromReq(ctx, logger, UserReq{Username: "clone1018"})
+	// Allow the goroutine caching the Username -> UUID to finish
+	time.Sleep(50 * time.Millisecond)
+	if len := mcClient.Caches.UUID.Len(); len != 3 {
+		t.Fatalf("Cache should have the UUIDEntry, McUser and texture, not %d keys", len)
+	}
+
+	// Purging by UUID should also find the Username and texture
+	purged, err := mcClient.PurgeUser(ctx, logger, UserReq{UUID: "d9135e082f2244c89cb0bee234155292"})
+	if err != nil {
+		t.Fatalf("PurgeUser failed: %v", err)
+	}
+	if len(purged) != 3 {
+		t.Errorf("PurgeUser should have removed 3 keys, not: %v", purged)
+	}
+	if len := mcClient.Caches.UUID.Len(); len != 0 {
+		t.Errorf("Cache should be empty after the purge, not %d keys", len)
+	}
+}
+
 func BenchmarkSkinCacheHit(b *testing.B) {
+	ctx := context.Background()
 	logger := log.NewBuiltinLogger(1)
 	mcClient, shutdown := newMcClient(b, 5)
 	defer shutdown()
-	mcClient.GetSkinFromReq(logger, UserReq{Username: "clone1018"})
+	mcClient.GetSkinFromReq(ctx, logger, UserReq{Username: "clone1018"})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		userReq := UserReq{Username: "clone1018"}
-		skin := mcClient.GetSkinFromReq(logger, userReq)
+		skin := mcClient.GetSkinFromReq(ctx, logger, userReq)
 		if skin.Hash != "a04a26d10218668a632e419ab073cf57" {
 			b.Fatalf("Skin hash was not as expected: %v", skin)
 		}
@@ -86,6 +264,7 @@ func BenchmarkSkinCacheHit(b *testing.B) {
 }
 
 func BenchmarkSkinCacheMiss(b *testing.B) {
+	ctx := context.Background()
 	logger := log.NewBuiltinLogger(1)
 	// Cache size of 1 means we'll be constantly inserting/evicting
 	mcClient, shutdown := newMcClient(b, 1)
@@ -94,7 +273,7 @@ func BenchmarkSkinCacheMiss(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		userReq := UserReq{Username: "clone1018"}
-		skin := mcClient.GetSkinFromReq(logger, userReq)
+		skin := mcClient.GetSkinFromReq(ctx, logger, userReq)
 		if skin.Hash != "a04a26d10218668a632e419ab073cf57" {
 			b.Fatalf("Skin hash was not as expected: %v", skin)
 		}
@@ -102,15 +281,16 @@ func BenchmarkSkinCacheMiss(b *testing.B) {
 }
 
 func BenchmarkSkinBufCacheHit(b *testing.B) {
+	ctx := context.Background()
 	logger := log.NewBuiltinLogger(1)
 	mcClient, shutdown := newMcClient(b, 5)
 	defer shutdown()
-	mcClient.GetSkinFromReq(logger, UserReq{Username: "clone1018"})
+	mcClient.GetSkinFromReq(ctx, logger, UserReq{Username: "clone1018"})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		userReq := UserReq{Username: "clone1018"}
-		_, textureIO := mcClient.GetSkinBufferFromReq(logger, userReq)
+		_, textureIO := mcClient.GetSkinBufferFromReq(ctx, logger, userReq)
 		bytes, err := io.ReadAll(textureIO)
 		if err != nil {
 			b.Fatalf("oops")
@@ -122,6 +302,7 @@ func BenchmarkSkinBufCacheHit(b *testing.B) {
 }
 
 func BenchmarkSkinBufCacheMiss(b *testing.B) {
+	ctx := context.Background()
 	logger := log.NewBuiltinLogger(1)
 	// Cache size of 1 means we'll be constantly inserting/evicting
 	mcClient, shutdown := newMcClient(b, 1)
@@ -130,7 +311,7 @@ func BenchmarkSkinBufCacheMiss(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		userReq := UserReq{Username: "clone1018"}
-		_, textureIO := mcClient.GetSkinBufferFromReq(logger, userReq)
+		_, textureIO := mcClient.GetSkinBufferFromReq(ctx, logger, userReq)
 		bytes, err := io.ReadAll(textureIO)
 		if err != nil {
 			b.Fatalf("oops")
@@ -77,6 +77,7 @@ func decodeMcUserProtobuf(protoBytes []byte) (McUser, error) {
 		},
 		Textures: Textures{
 			SkinPath: pb.SkinPath,
+			SkinSlim: pb.SkinSlim,
 		},
 	}
 
@@ -95,6 +96,7 @@ func (u McUser) EncodeProtobuf() ([]byte, error) {
 		Username: u.Username,
 		UUID:     u.UUID,
 		SkinPath: u.Textures.SkinPath,
+		SkinSlim: u.Textures.SkinSlim,
 	}
 
 	if u.Textures.TexturesMcNet {
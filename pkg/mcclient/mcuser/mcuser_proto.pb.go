@@ -1,13 +1,12 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.23.0
+// 	protoc-gen-go v1.27.1
 // 	protoc        v3.17.3
 // source: pkg/mcclient/mcuser/mcuser_proto.proto
 
 package mcuser
 
 import (
-	proto "github.com/golang/protobuf/proto"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,10 +20,6 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// This is a compile-time assertion that a sufficiently up-to-date version
-// of the legacy proto package is being used.
-const _ = proto.ProtoPackageIsVersion4
-
 type McUserProto_UserStatus int32
 
 const (
@@ -137,7 +132,8 @@ type McUserProto struct {
 	Username string                 `protobuf:"bytes,4,opt,name=Username,proto3" json:"Username,omitempty"`
 	UUID     string                 `protobuf:"bytes,5,opt,name=UUID,proto3" json:"UUID,omitempty"`
 	BaseURL  McUserProto_URLType    `protobuf:"varint,7,opt,name=BaseURL,proto3,enum=mcuser.McUserProto_URLType" json:"BaseURL,omitempty"`
-	//bool SkinSlim = 8
+	// The skin uses the slim (Alex) model rather than classic (Steve)
+	SkinSlim bool   `protobuf:"varint,8,opt,name=SkinSlim,proto3" json:"SkinSlim,omitempty"`
 	SkinPath string `protobuf:"bytes,9,opt,name=SkinPath,proto3" json:"SkinPath,omitempty"` //string CapePath = 10;
 }
 
@@ -208,6 +204,13 @@ func (x *McUserProto) GetBaseURL() McUserProto_URLType {
 	return McUserProto_UNKNOWN
 }
 
+func (x *McUserProto) GetSkinSlim() bool {
+	if x != nil {
+		return x.SkinSlim
+	}
+	return false
+}
+
 func (x *McUserProto) GetSkinPath() string {
 	if x != nil {
 		return x.SkinPath
@@ -221,7 +224,7 @@ var file_pkg_mcclient_mcuser_mcuser_proto_proto_rawDesc = []byte{
 	0x0a, 0x26, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x63, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x6d,
 	0x63, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x6d, 0x63, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x6d, 0x63, 0x75, 0x73, 0x65, 0x72,
-	0x22, 0xeb, 0x02, 0x0a, 0x0b, 0x4d, 0x63, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0x87, 0x03, 0x0a, 0x0b, 0x4d, 0x63, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x74, 0x6f,
 	0x12, 0x12, 0x0a, 0x04, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
 	0x54, 0x69, 0x6d, 0x65, 0x12, 0x36, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02,
 	0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x6d, 0x63, 0x75, 0x73, 0x65, 0x72, 0x2e, 0x4d, 0x63,
@@ -233,21 +236,23 @@ var file_pkg_mcclient_mcuser_mcuser_proto_proto_rawDesc = []byte{
 	0x42, 0x61, 0x73, 0x65, 0x55, 0x52, 0x4c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e,
 	0x6d, 0x63, 0x75, 0x73, 0x65, 0x72, 0x2e, 0x4d, 0x63, 0x55, 0x73, 0x65, 0x72, 0x50, 0x72, 0x6f,
 	0x74, 0x6f, 0x2e, 0x55, 0x52, 0x4c, 0x54, 0x79, 0x70, 0x65, 0x52, 0x07, 0x42, 0x61, 0x73, 0x65,
-	0x55, 0x52, 0x4c, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x50, 0x61, 0x74, 0x68, 0x18,
-	0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x50, 0x61, 0x74, 0x68, 0x22,
-	0x60, 0x0a, 0x0a, 0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x09, 0x0a,
-	0x05, 0x55, 0x4e, 0x53, 0x45, 0x54, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x01,
-	0x12, 0x11, 0x0a, 0x0d, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x49,
-	0x43, 0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x55, 0x4e, 0x4b,
-	0x4e, 0x4f, 0x57, 0x4e, 0x5f, 0x55, 0x53, 0x45, 0x52, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x45,
-	0x52, 0x52, 0x4f, 0x52, 0x5f, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x10,
-	0x04, 0x22, 0x2b, 0x0a, 0x07, 0x55, 0x52, 0x4c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07,
-	0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x54, 0x45, 0x58,
-	0x54, 0x55, 0x52, 0x45, 0x53, 0x5f, 0x4d, 0x43, 0x5f, 0x4e, 0x45, 0x54, 0x10, 0x01, 0x42, 0x2d,
-	0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x69, 0x6e,
-	0x6f, 0x74, 0x61, 0x72, 0x2f, 0x69, 0x6d, 0x67, 0x64, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x63,
-	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x6d, 0x63, 0x75, 0x73, 0x65, 0x72, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x55, 0x52, 0x4c, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x53, 0x6c, 0x69, 0x6d, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x53, 0x6c, 0x69, 0x6d, 0x12,
+	0x1a, 0x0a, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x50, 0x61, 0x74, 0x68, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x53, 0x6b, 0x69, 0x6e, 0x50, 0x61, 0x74, 0x68, 0x22, 0x60, 0x0a, 0x0a, 0x55,
+	0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53,
+	0x45, 0x54, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d,
+	0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x49, 0x43, 0x10, 0x02, 0x12,
+	0x16, 0x0a, 0x12, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e,
+	0x5f, 0x55, 0x53, 0x45, 0x52, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x45, 0x52, 0x52, 0x4f, 0x52,
+	0x5f, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x10, 0x04, 0x22, 0x2b, 0x0a,
+	0x07, 0x55, 0x52, 0x4c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e,
+	0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x54, 0x45, 0x58, 0x54, 0x55, 0x52, 0x45,
+	0x53, 0x5f, 0x4d, 0x43, 0x5f, 0x4e, 0x45, 0x54, 0x10, 0x01, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x69, 0x6e, 0x6f, 0x74, 0x61, 0x72,
+	0x2f, 0x69, 0x6d, 0x67, 0x64, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x63, 0x63, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x2f, 0x6d, 0x63, 0x75, 0x73, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -21,6 +21,7 @@ func testUser() McUser {
 		},
 		Textures: Textures{
 			SkinPath:      "6f736b4c3e2286cfad9b0d738fd7d9630d9e0a27721b7586e423cebce420da",
+			SkinSlim:      true,
 			TexturesMcNet: true,
 		},
 	}
@@ -1,6 +1,10 @@
 package mcuser
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
 	"io"
 	"strings"
 
@@ -10,14 +14,52 @@ import (
 
 const TexturesBaseURL = "http://textures.minecraft.net/texture/"
 
+// MaxTextureBytes is the largest texture accepted (a 1024x1024 skin is well within it)
+const MaxTextureBytes = 4 << 20
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ValidateSkinBytes checks the bytes are a PNG of a supported skin size, which fully decodes (eg. it is not truncated)
+func ValidateSkinBytes(textureBytes []byte) error {
+	// The size is checked before the (larger) image is decoded
+	if err := ValidateSkinHeader(textureBytes); err != nil {
+		return err
+	}
+	if _, err := png.Decode(bytes.NewReader(textureBytes)); err != nil {
+		return fmt.Errorf("unable to decode texture: %w", err)
+	}
+	return nil
+}
+
+// ValidateSkinHeader checks the bytes are a PNG of a supported skin size (only the header is decoded)
+// This is enough for a texture which was already checked by ValidateSkinBytes, eg. when it was cached
+func ValidateSkinHeader(textureBytes []byte) error {
+	if len(textureBytes) > MaxTextureBytes {
+		return fmt.Errorf("texture is too large: over %d bytes", MaxTextureBytes)
+	}
+	if !bytes.HasPrefix(textureBytes, pngSignature) {
+		return errors.New("texture is not a PNG")
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(textureBytes))
+	if err != nil {
+		return fmt.Errorf("unable to decode texture header: %w", err)
+	}
+	return minecraft.ValidateSkinSize(config.Width, config.Height)
+}
+
 type TextureIO struct {
 	io.ReadCloser
 	TextureID string
+	// decoded is returned instead of decoding the ReadCloser (eg. the shared Steve skin)
+	decoded *minecraft.Skin
 }
 
 // DecodeTexture reads and closes the ReadCloser, returning a minecraft.Texture (and optional error)
 func (tio TextureIO) DecodeTexture() (texture minecraft.Texture, err error) {
 	defer tio.ReadCloser.Close()
+	if tio.decoded != nil {
+		return tio.decoded.Texture, nil
+	}
 	err = texture.Decode(tio.ReadCloser)
 
 	//if err != nil {
@@ -29,33 +71,40 @@ func (tio TextureIO) DecodeTexture() (texture minecraft.Texture, err error) {
 }
 
 // MustDecodeSkin reads and closes the ReadCloser, returning a minecraft.Skin
+// A skin with unsupported dimensions is replaced by Steve, and a legacy (64x32) skin is upgraded to the 64x64 layout
 func (tio TextureIO) MustDecodeSkin(logger log.Logger) (skin minecraft.Skin) {
 	texture, err := tio.DecodeTexture()
+	if err == nil {
+		skin.Texture = texture
+		err = skin.Validate()
+	}
 	if err != nil {
 		logger.Debugf("Falling back to Steve: %v", err)
-		skin, _ = minecraft.FetchSkinForSteve()
+		skin, _ = minecraft.SteveSkin()
 		return
 	}
-	skin.Texture = texture
+	skin.Normalise()
 	return
 }
 
+// GetSteveTextureIO reads the shared Steve bytes, and carries the already decoded Steve skin
 func GetSteveTextureIO() TextureIO {
-	// Todo: Can we optmize the Steve delivery - keep the bytes in memory and re-use?
-	// is there a more efficient way to reuse the Steve bytes between requests (vs. a new buffer)?
-	steve, _ := minecraft.GetSteveBytes()
-	return TextureIO{
-		ReadCloser: io.NopCloser(steve),
+	tio := TextureIO{
+		ReadCloser: io.NopCloser(minecraft.SteveReader()),
 		TextureID:  minecraft.SteveHash,
 	}
-
+	if steve, err := minecraft.SteveSkin(); err == nil {
+		tio.decoded = &steve
+	}
+	return tio
 }
 
 type Textures struct {
 	// SkinPath changes based on whether the Texture's URL was prefixed by the TexturesBaseURL.
 	// It will either be just the "hash" (part after the TexturesBaseURL) or a full URL
 	SkinPath string
-	//SkinSlim bool (for "alex" support)
+	// SkinSlim is true for the slim (Alex) model, rather than classic (Steve)
+	SkinSlim bool
 	//CapePath string
 
 	// TexturesMcNet is true when the SkinPath is just the part after the TexturesBaseURL
@@ -93,7 +142,9 @@ func NewTexturesFromSessionProfile(sessionProfile minecraft.SessionProfileRespon
 		t.SkinPath = profileTextureProperty.Textures.Skin.URL
 	}
 
-	// Other logic here for the Model / Slim skin, Capes etc.
+	t.SkinSlim = profileTextureProperty.Textures.Skin.Metadata.Model == "slim"
+
+	// Other logic here for Capes etc.
 
 	return t, nil
 }
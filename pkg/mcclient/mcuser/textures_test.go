@@ -0,0 +1,98 @@
+package mcuser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"testing"
+
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/sample_skin"
+)
+
+func TestSteveTextureIO(t *testing.T) {
+	// Steve is a legacy skin, upgraded to the 64x64 layout
+	skin := GetSteveTextureIO().MustDecodeSkin(log.NewBuiltinLogger(1))
+	if skin.Hash == "" || skin.Image.Bounds().Dy() != 64 {
+		t.Errorf("Expected the decoded Steve skin, not: %s %v", skin.Hash, skin.Image.Bounds())
+	}
+
+	// The bytes can still be read (eg. to deliver the skin)
+	steveBytes, err := ioutil.ReadAll(GetSteveTextureIO())
+	if err != nil {
+		t.Fatalf("Unable to read Steve: %s", err)
+	}
+	expected, _ := minecraft.GetSteveBytes()
+	if string(steveBytes) != expected.String() {
+		t.Errorf("Expected the Steve bytes (%d), not: %d bytes", expected.Len(), len(steveBytes))
+	}
+}
+
+func TestMustDecodeSkinSize(t *testing.T) {
+	logger := log.NewBuiltinLogger(1)
+	for _, size := range []image.Rectangle{image.Rect(0, 0, 100, 50), image.Rect(0, 0, 128, 128)} {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewNRGBA(size))
+
+		skin := TextureIO{ReadCloser: ioutil.NopCloser(&buf)}.MustDecodeSkin(logger)
+		// An unsupported size falls back to Steve, but an HD skin is kept
+		isSteve := skin.Hash == minecraft.SteveHash
+		if isSteve != (size.Dx() == 100) {
+			t.Errorf("Unexpected skin for %v: %s %v", size, skin.Hash, skin.Image.Bounds())
+		}
+	}
+}
+
+func TestValidateSkinBytes(t *testing.T) {
+	steveBytes, _ := minecraft.GetSteveBytes()
+	if err := ValidateSkinBytes(steveBytes.Bytes()); err != nil {
+		t.Errorf("Steve should be valid: %v", err)
+	}
+
+	var oddSize bytes.Buffer
+	png.Encode(&oddSize, image.NewNRGBA(image.Rect(0, 0, 100, 50)))
+	for name, textureBytes := range map[string][]byte{
+		"Empty":     nil,
+		"HTML":      []byte("<html>Error</html>"),
+		"Truncated": steveBytes.Bytes()[:12],
+		"Partial":   steveBytes.Bytes()[:steveBytes.Len()-32],
+		"Size":      oddSize.Bytes(),
+		"Large":     append(steveBytes.Bytes(), make([]byte, MaxTextureBytes)...),
+	} {
+		if err := ValidateSkinBytes(textureBytes); err == nil {
+			t.Errorf("%s texture should be invalid", name)
+		}
+	}
+}
+
+// BenchmarkDecodeTexture decodes a paletted PNG (the sample skin) and an NRGBA PNG (Steve, which needs no conversion)
+func BenchmarkDecodeTexture(b *testing.B) {
+	sampleBytes, err := base64.StdEncoding.DecodeString(sample_skin.SampleSkinBase64)
+	if err != nil {
+		b.Fatalf("Unable to get sample skin: %s", err)
+	}
+	steveBytes, _ := minecraft.GetSteveBytes()
+
+	for name, textureBytes := range map[string][]byte{"Paletted": sampleBytes, "NRGBA": steveBytes.Bytes()} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				textureIO := TextureIO{ReadCloser: ioutil.NopCloser(bytes.NewReader(textureBytes))}
+				if _, err := textureIO.DecodeTexture(); err != nil {
+					b.Fatalf("Unable to decode texture: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeSteve(b *testing.B) {
+	logger := log.NewBuiltinLogger(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetSteveTextureIO().MustDecodeSkin(logger)
+	}
+}
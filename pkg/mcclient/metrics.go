@@ -1,6 +1,9 @@
 package mcclient
 
 import (
+	"context"
+
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -41,6 +44,15 @@ var (
 		}, []string{"source", "event"},
 	)
 
+	apiAbandoned = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "mcclient_api",
+			Name:      "abandoned_requests_total",
+			Help:      "API requests abandoned as the client request ended.",
+		}, []string{"source"},
+	)
+
 	cacheStatus = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: metricsNamespace,
@@ -51,28 +63,35 @@ var (
 	)
 )
 
+// cacheStatusRecorder counts cache outcomes, also recording them on the access log Entry of the request
 type cacheStatusRecorder struct {
+	name    string
 	counter *prometheus.CounterVec
 }
 
-func (c *cacheStatusRecorder) Hit() {
-	c.counter.WithLabelValues("hit").Inc()
+func (c *cacheStatusRecorder) record(ctx context.Context, status string) {
+	c.counter.WithLabelValues(status).Inc()
+	access_log.FromContext(ctx).SetCache(c.name, status)
+}
+
+func (c *cacheStatusRecorder) Hit(ctx context.Context) {
+	c.record(ctx, "hit")
 }
-func (c *cacheStatusRecorder) Miss() {
-	c.counter.WithLabelValues("miss").Inc()
+func (c *cacheStatusRecorder) Miss(ctx context.Context) {
+	c.record(ctx, "miss")
 }
-func (c *cacheStatusRecorder) Fresh() {
-	c.counter.WithLabelValues("fresh").Inc()
+func (c *cacheStatusRecorder) Fresh(ctx context.Context) {
+	c.record(ctx, "fresh")
 }
-func (c *cacheStatusRecorder) Stale() {
-	c.counter.WithLabelValues("stale").Inc()
+func (c *cacheStatusRecorder) Stale(ctx context.Context) {
+	c.record(ctx, "stale")
 }
-func (c *cacheStatusRecorder) Error() {
-	c.counter.WithLabelValues("error").Inc()
+func (c *cacheStatusRecorder) Error(ctx context.Context) {
+	c.record(ctx, "error")
 }
 
 var (
-	uuidCacheStatus     = cacheStatusRecorder{cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheUUID"})}
-	userdataCacheStatus = cacheStatusRecorder{cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheUserData"})}
-	textureCacheStatus  = cacheStatusRecorder{cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheTextures"})}
+	uuidCacheStatus     = cacheStatusRecorder{"uuid", cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheUUID"})}
+	userdataCacheStatus = cacheStatusRecorder{"userdata", cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheUserData"})}
+	textureCacheStatus  = cacheStatusRecorder{"textures", cacheStatus.MustCurryWith(prometheus.Labels{"cache": "CacheTextures"})}
 )
@@ -7,6 +7,7 @@ import (
 	"github.com/minotar/imgd/pkg/util/log"
 )
 
+// Defaults of the TTLPolicy
 const (
 	day = 24 * time.Hour
 
@@ -23,6 +24,9 @@ const (
 	userUnknownTTL   = 7 * day
 	userRateLimitTTL = 1 * time.Hour
 	userErrorTTL     = 30 * time.Minute
+
+	// Re-request an invalid texture soon, in case it was a transient upstream error
+	textureInvalidTTL = 10 * time.Minute
 )
 
 // Todo: Username vs. UUID logic??
@@ -32,6 +36,8 @@ const (
 	StatusErrorGeneric
 	StatusErrorUnknownUser
 	StatusErrorRateLimit
+	// The texture was not a valid skin PNG (eg. an HTML error page)
+	StatusErrorInvalidTexture
 )
 
 // Status is for recording the API response status for a specific request
@@ -59,6 +65,8 @@ func (s Status) Error() string {
 		return "user not found"
 	case StatusErrorRateLimit:
 		return "rate limited"
+	case StatusErrorInvalidTexture:
+		return "invalid texture"
 	default:
 		return "unknown lookup failure"
 	}
@@ -73,34 +81,43 @@ func (s Status) GetError() error {
 	return s
 }
 
+// DurationUUID is the cache TTL of a UUIDEntry with this Status (see SetTTLPolicy)
 func (s Status) DurationUUID() time.Duration {
+	policy := CurrentTTLPolicy()
 	switch s {
 	case StatusOk:
-		return uuidTTL
+		return policy.UUID
 	case StatusErrorUnknownUser:
-		return uuidUnknownTTL
+		return policy.UUIDUnknown
 	case StatusErrorRateLimit:
-		return uuidRateLimitTTL
+		return policy.UUIDRateLimit
 	default:
 		// StatusUnSet, StatusErrorGeneric, Others
-		return uuidErrorTTL
+		return policy.UUIDError
 	}
 }
 
+// DurationUser is the cache TTL of an McUser with this Status (see SetTTLPolicy)
 func (s Status) DurationUser() time.Duration {
+	policy := CurrentTTLPolicy()
 	switch s {
 	case StatusOk:
-		return userTTL
+		return policy.User
 	case StatusErrorUnknownUser:
-		return userUnknownTTL
+		return policy.UserUnknown
 	case StatusErrorRateLimit:
-		return userRateLimitTTL
+		return policy.UserRateLimit
 	default:
 		// StatusUnSet, StatusErrorGeneric, Others
-		return userErrorTTL
+		return policy.UserError
 	}
 }
 
+// DurationTexture is the cache TTL of a failed texture with this Status (see SetTTLPolicy)
+func (s Status) DurationTexture() time.Duration {
+	return CurrentTTLPolicy().TextureInvalid
+}
+
 // Todo: remove the `query` here as it should already be tagged on the logger
 // NOTE: This will record metrics based on the given error
 func NewStatusFromError(logger log.Logger, query string, err error) Status {
@@ -176,3 +193,7 @@ func NewStatusFromError(logger log.Logger, query string, err error) Status {
 func MetricTextureFetchError() {
 	apiGetErrors.WithLabelValues("TextureFetch", "Generic").Inc()
 }
+
+func MetricTextureInvalid() {
+	apiGetErrors.WithLabelValues("TextureFetch", "Invalid").Inc()
+}
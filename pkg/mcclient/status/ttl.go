@@ -0,0 +1,86 @@
+package status
+
+import (
+	"flag"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// TTLPolicy is how long a UUIDEntry/McUser (or failed texture) is cached for, based on its Status
+type TTLPolicy struct {
+	UUID          time.Duration
+	UUIDUnknown   time.Duration
+	UUIDRateLimit time.Duration
+	UUIDError     time.Duration
+
+	User          time.Duration
+	UserUnknown   time.Duration
+	UserRateLimit time.Duration
+	UserError     time.Duration
+
+	TextureInvalid time.Duration
+}
+
+var DefaultTTLPolicy = TTLPolicy{
+	UUID:          uuidTTL,
+	UUIDUnknown:   uuidUnknownTTL,
+	UUIDRateLimit: uuidRateLimitTTL,
+	UUIDError:     uuidErrorTTL,
+
+	User:          userTTL,
+	UserUnknown:   userUnknownTTL,
+	UserRateLimit: userRateLimitTTL,
+	UserError:     userErrorTTL,
+
+	TextureInvalid: textureInvalidTTL,
+}
+
+// RegisterFlags registers flag.
+func (p *TTLPolicy) RegisterFlags(f *flag.FlagSet, prefix string) {
+	f.DurationVar(&p.UUID, prefix+"uuid", DefaultTTLPolicy.UUID, "Cache TTL of a Username -> UUID")
+	f.DurationVar(&p.UUIDUnknown, prefix+"uuid-unknown", DefaultTTLPolicy.UUIDUnknown, "Cache TTL of an unknown Username")
+	f.DurationVar(&p.UUIDRateLimit, prefix+"uuid-rate-limit", DefaultTTLPolicy.UUIDRateLimit, "Cache TTL of a rate limited Username lookup")
+	f.DurationVar(&p.UUIDError, prefix+"uuid-error", DefaultTTLPolicy.UUIDError, "Cache TTL of an errored Username lookup")
+	f.DurationVar(&p.User, prefix+"userdata", DefaultTTLPolicy.User, "Cache TTL of a UUID -> UserData")
+	f.DurationVar(&p.UserUnknown, prefix+"userdata-unknown", DefaultTTLPolicy.UserUnknown, "Cache TTL of an unknown UUID")
+	f.DurationVar(&p.UserRateLimit, prefix+"userdata-rate-limit", DefaultTTLPolicy.UserRateLimit, "Cache TTL of a rate limited UUID lookup")
+	f.DurationVar(&p.UserError, prefix+"userdata-error", DefaultTTLPolicy.UserError, "Cache TTL of an errored UUID lookup")
+	f.DurationVar(&p.TextureInvalid, prefix+"texture-invalid", DefaultTTLPolicy.TextureInvalid, "Cache TTL of an invalid texture (eg. not a skin PNG)")
+}
+
+func (p *TTLPolicy) Validate() error {
+	for name, ttl := range map[string]time.Duration{
+		"uuid":                p.UUID,
+		"uuid-unknown":        p.UUIDUnknown,
+		"uuid-rate-limit":     p.UUIDRateLimit,
+		"uuid-error":          p.UUIDError,
+		"userdata":            p.User,
+		"userdata-unknown":    p.UserUnknown,
+		"userdata-rate-limit": p.UserRateLimit,
+		"userdata-error":      p.UserError,
+		"texture-invalid":     p.TextureInvalid,
+	} {
+		if ttl <= 0 {
+			return fmt.Errorf("ttl.%s should be positive", name)
+		}
+	}
+	return nil
+}
+
+// The policy is shared by every Status, so it can be swapped at runtime (eg. to cache errors for longer during an incident)
+var ttlPolicy atomic.Value
+
+func init() {
+	ttlPolicy.Store(DefaultTTLPolicy)
+}
+
+// SetTTLPolicy is used by every Status from now on (entries already cached keep their TTL)
+func SetTTLPolicy(p TTLPolicy) {
+	ttlPolicy.Store(p)
+}
+
+// CurrentTTLPolicy is the policy in use
+func CurrentTTLPolicy() TTLPolicy {
+	return ttlPolicy.Load().(TTLPolicy)
+}
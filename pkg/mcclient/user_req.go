@@ -1,6 +1,7 @@
 package mcclient
 
 import (
+	"context"
 	"errors"
 
 	"github.com/minotar/imgd/pkg/minecraft"
@@ -9,7 +10,7 @@ import (
 
 type UserReq minecraft.User
 
-func (ur UserReq) GetUUID(logger log.Logger, mc *McClient) (newLogger log.Logger, uuid string, err error) {
+func (ur UserReq) GetUUID(ctx context.Context, logger log.Logger, mc *McClient) (newLogger log.Logger, uuid string, err error) {
 	// If we were given a UUID, use it..!
 	if ur.UUID != "" {
 		return logger.With("uuid", ur.UUID), ur.UUID, nil
@@ -22,7 +23,7 @@ func (ur UserReq) GetUUID(logger log.Logger, mc *McClient) (newLogger log.Logger
 
 	// With given Username, get the UUID
 	newLogger = logger.With("username", ur.Username)
-	uuidEntry, err := mc.GetUUIDEntry(newLogger, ur.Username)
+	uuidEntry, err := mc.GetUUIDEntry(ctx, newLogger, ur.Username)
 	if err != nil {
 		return
 	}
@@ -54,17 +54,21 @@ func (mc *Minecraft) GetAPIProfile(username string) (APIProfileResponse, error)
 	return mc.GetAPIProfileCtx(context.Background(), username)
 }
 
+// GetUUIDCtx is the same as GetUUID, but with Context on the Request
+func (mc *Minecraft) GetUUIDCtx(ctx context.Context, username string) (string, error) {
+	apiProfile, err := mc.GetAPIProfileCtx(ctx, username)
+	return apiProfile.UUID, err
+}
+
 // GetUUID returns the UUID for a given username (shorthand for GetAPIProfile)
 func (mc *Minecraft) GetUUID(username string) (string, error) {
-	apiProfile, err := mc.GetAPIProfile(username)
-	return apiProfile.UUID, err
+	return mc.GetUUIDCtx(context.Background(), username)
 }
 
-// NormalizePlayerForUUID takes either a Username or UUID and returns a UUID
-// formatted without dashes, or an error (eg. no account or an API error)
-func (mc *Minecraft) NormalizePlayerForUUID(player string) (string, error) {
+// NormalizePlayerForUUIDCtx is the same as NormalizePlayerForUUID, but with Context on the Request
+func (mc *Minecraft) NormalizePlayerForUUIDCtx(ctx context.Context, player string) (string, error) {
 	if RegexUsername.MatchString(player) {
-		return mc.GetUUID(player)
+		return mc.GetUUIDCtx(ctx, player)
 	} else if RegexUUID.MatchString(player) {
 		return strings.Replace(player, "-", "", 4), nil
 	}
@@ -73,6 +77,12 @@ func (mc *Minecraft) NormalizePlayerForUUID(player string) (string, error) {
 	return "", errors.New("unable to NormalizePlayerForUUID due to invalid Username/UUID")
 }
 
+// NormalizePlayerForUUID takes either a Username or UUID and returns a UUID
+// formatted without dashes, or an error (eg. no account or an API error)
+func (mc *Minecraft) NormalizePlayerForUUID(player string) (string, error) {
+	return mc.NormalizePlayerForUUIDCtx(context.Background(), player)
+}
+
 // GetSessionProfileCtx is the same as GetSessionProfile, but with Context on the Request
 func (mc *Minecraft) GetSessionProfileCtx(ctx context.Context, uuid string) (SessionProfileResponse, error) {
 	ctx = CtxWithSource(ctx, "GetSessionProfile")
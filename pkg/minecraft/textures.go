@@ -107,7 +107,8 @@ func (mc *Minecraft) TextureBodyFromUsernameCtx(ctx context.Context, username st
 	return mc.ApiRequestCtx(ctx, baseURL+username+".png")
 }
 
-func (mc *Minecraft) FetchTexturesWithSessionProfile(sessionProfile SessionProfileResponse) (User, Skin, Cape, error) {
+// FetchTexturesWithSessionProfileCtx is the same as FetchTexturesWithSessionProfile, but with Context on the Requests
+func (mc *Minecraft) FetchTexturesWithSessionProfileCtx(ctx context.Context, sessionProfile SessionProfileResponse) (User, Skin, Cape, error) {
 	//  We have a sessionProfile!
 	user := User{UUID: sessionProfile.UUID, Username: sessionProfile.Username}
 	skin := Skin{Texture{Mc: mc}}
@@ -120,14 +121,18 @@ func (mc *Minecraft) FetchTexturesWithSessionProfile(sessionProfile SessionProfi
 
 	// We got oursleves a profileTextureProperty - now we can get a Skin/Cape
 
-	err = skin.FetchWithTextureProperty(profileTextureProperty, TextureSkin)
+	err = skin.FetchWithTexturePropertyCtx(ctx, profileTextureProperty, TextureSkin)
 	if err != nil {
 		return user, skin, cape, fmt.Errorf("unable to retrieve skin: %w", err)
 	}
 
-	err = cape.FetchWithTextureProperty(profileTextureProperty, TextureCape)
+	err = cape.FetchWithTexturePropertyCtx(ctx, profileTextureProperty, TextureCape)
 	if err != nil {
 		return user, skin, cape, fmt.Errorf("unable to retrieve cape: %w", err)
 	}
 	return user, skin, cape, nil
 }
+
+func (mc *Minecraft) FetchTexturesWithSessionProfile(sessionProfile SessionProfileResponse) (User, Skin, Cape, error) {
+	return mc.FetchTexturesWithSessionProfileCtx(context.Background(), sessionProfile)
+}
@@ -1,25 +1,38 @@
 package minecraft
 
-import _ "image/png" // If we work with PNGs we need this
+import (
+	"context"
+	_ "image/png" // If we work with PNGs we need this
+)
 
 type Cape struct {
 	Texture
 }
 
-func (mc *Minecraft) FetchCapeUUID(uuid string) (Cape, error) {
+// FetchCapeUUIDCtx is the same as FetchCapeUUID, but with Context on the Requests
+func (mc *Minecraft) FetchCapeUUIDCtx(ctx context.Context, uuid string) (Cape, error) {
 	cape := Cape{Texture{Mc: mc}}
 
 	// Must be careful to not request same profile from session server more than once per ~30 seconds
-	sessionProfile, err := mc.GetSessionProfile(uuid)
+	sessionProfile, err := mc.GetSessionProfileCtx(ctx, uuid)
 	if err != nil {
 		return cape, err
 	}
 
-	return cape, cape.FetchWithSessionProfile(sessionProfile, TextureCape)
+	return cape, cape.FetchWithSessionProfileCtx(ctx, sessionProfile, TextureCape)
 }
 
-func (mc *Minecraft) FetchCapeUsername(username string) (Cape, error) {
+func (mc *Minecraft) FetchCapeUUID(uuid string) (Cape, error) {
+	return mc.FetchCapeUUIDCtx(context.Background(), uuid)
+}
+
+// FetchCapeUsernameCtx is the same as FetchCapeUsername, but with Context on the Request
+func (mc *Minecraft) FetchCapeUsernameCtx(ctx context.Context, username string) (Cape, error) {
 	cape := Cape{Texture{Mc: mc}}
 
-	return cape, cape.FetchWithUsername(username, TextureCape)
+	return cape, cape.FetchWithUsernameCtx(ctx, username, TextureCape)
+}
+
+func (mc *Minecraft) FetchCapeUsername(username string) (Cape, error) {
+	return mc.FetchCapeUsernameCtx(context.Background(), username)
 }
@@ -1,25 +1,74 @@
 package minecraft
 
-import _ "image/png" // If we work with PNGs we need this
+import (
+	"context"
+	"image"
+	_ "image/png" // If we work with PNGs we need this
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SkinWidth is the width of a standard skin (an HD skin is a multiple of it)
+	SkinWidth = 64
+	// MaxSkinScale is the largest multiple of the SkinWidth accepted (1024x1024)
+	MaxSkinScale = 16
+)
 
 type Skin struct {
 	Texture
 }
 
-func (mc *Minecraft) FetchSkinUUID(uuid string) (Skin, error) {
+// ValidateSkinSize checks the skin is a multiple of 64 wide (up to the MaxSkinScale),
+// and either square or half the height (a legacy skin)
+func ValidateSkinSize(width, height int) error {
+	if width <= 0 || width%SkinWidth != 0 || width > SkinWidth*MaxSkinScale {
+		return errors.Errorf("unsupported skin width: %dx%d", width, height)
+	}
+	if height != width && height*2 != width {
+		return errors.Errorf("unsupported skin height: %dx%d", width, height)
+	}
+	return nil
+}
+
+// SkinScale is the multiple of the standard (64 wide) skin, eg. 2 for a 128x128 skin
+func SkinScale(img image.Image) int {
+	if scale := img.Bounds().Dx() / SkinWidth; scale > 1 {
+		return scale
+	}
+	return 1
+}
+
+// Validate checks the dimensions of the skin can be rendered
+func (s *Skin) Validate() error {
+	bounds := s.Image.Bounds()
+	return ValidateSkinSize(bounds.Dx(), bounds.Dy())
+}
+
+// FetchSkinUUIDCtx is the same as FetchSkinUUID, but with Context on the Requests
+func (mc *Minecraft) FetchSkinUUIDCtx(ctx context.Context, uuid string) (Skin, error) {
 	skin := &Skin{Texture{Mc: mc}}
 
 	// Must be careful to not request same profile from session server more than once per ~30 seconds
-	sessionProfile, err := mc.GetSessionProfile(uuid)
+	sessionProfile, err := mc.GetSessionProfileCtx(ctx, uuid)
 	if err != nil {
 		return *skin, err
 	}
 
-	return *skin, skin.FetchWithSessionProfile(sessionProfile, TextureSkin)
+	return *skin, skin.FetchWithSessionProfileCtx(ctx, sessionProfile, TextureSkin)
 }
 
-func (mc *Minecraft) FetchSkinUsername(username string) (Skin, error) {
+func (mc *Minecraft) FetchSkinUUID(uuid string) (Skin, error) {
+	return mc.FetchSkinUUIDCtx(context.Background(), uuid)
+}
+
+// FetchSkinUsernameCtx is the same as FetchSkinUsername, but with Context on the Request
+func (mc *Minecraft) FetchSkinUsernameCtx(ctx context.Context, username string) (Skin, error) {
 	skin := &Skin{Texture{Mc: mc}}
 
-	return *skin, skin.FetchWithUsername(username, TextureSkin)
+	return *skin, skin.FetchWithUsernameCtx(ctx, username, TextureSkin)
+}
+
+func (mc *Minecraft) FetchSkinUsername(username string) (Skin, error) {
+	return mc.FetchSkinUsernameCtx(context.Background(), username)
 }
@@ -0,0 +1,95 @@
+package minecraft
+
+import (
+	"image"
+)
+
+// Legacy skins (from before 1.8) are half the height: 64x32, with no left limbs or overlay layers (other than the hat)
+// The 1.8 layout is 64x64, with the left limbs below the torso, and an overlay for each body part
+
+// legacyLimbCopies is where the faces of the right leg and arm are mirrored into the left limbs (as Minecraft does)
+// Each is the x, y, width and height of a face (in 64x32 units), and the offset of the copy
+var legacyLimbCopies = [...]struct {
+	x, y, w, h, dx, dy int
+}{
+	// Right leg -> Left leg
+	{4, 16, 4, 4, 16, 32},
+	{8, 16, 4, 4, 16, 32},
+	{0, 20, 4, 12, 24, 32},
+	{4, 20, 4, 12, 16, 32},
+	{8, 20, 4, 12, 8, 32},
+	{12, 20, 4, 12, 16, 32},
+	// Right arm -> Left arm
+	{44, 16, 4, 4, -8, 32},
+	{48, 16, 4, 4, -8, 32},
+	{40, 20, 4, 12, 0, 32},
+	{44, 20, 4, 12, -8, 32},
+	{48, 20, 4, 12, -16, 32},
+	{52, 20, 4, 12, -8, 32},
+}
+
+// IsLegacySkin is true for the (pre 1.8) half height layout
+func IsLegacySkin(img image.Image) bool {
+	bounds := img.Bounds()
+	return bounds.Dx() == bounds.Dy()*2
+}
+
+// UpgradeLegacySkin converts a legacy skin into the 64x64 layout (a 1.8 skin is returned as is)
+// The left limbs are mirrored from the right limbs, and the new overlay layers are transparent
+// A hat without any transparency is assumed to be an opaque background, and is removed
+func UpgradeLegacySkin(img *image.NRGBA) *image.NRGBA {
+	if !IsLegacySkin(img) {
+		return img
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	scale := SkinScale(img)
+
+	upgraded := image.NewNRGBA(image.Rect(0, 0, width, height*2))
+	for y := 0; y < height; y++ {
+		srcOff := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		copy(upgraded.Pix[y*upgraded.Stride:], img.Pix[srcOff:srcOff+width*4])
+	}
+
+	removeHatMatte(upgraded, scale)
+
+	for _, face := range legacyLimbCopies {
+		x, y, w, h := face.x*scale, face.y*scale, face.w*scale, face.h*scale
+		dx, dy := face.dx*scale, face.dy*scale
+		for row := 0; row < h; row++ {
+			for col := 0; col < w; col++ {
+				srcOff := upgraded.PixOffset(x+col, y+row)
+				// Mirrored horizontally
+				dstOff := upgraded.PixOffset(x+dx+w-1-col, y+dy+row)
+				copy(upgraded.Pix[dstOff:dstOff+4], upgraded.Pix[srcOff:srcOff+4])
+			}
+		}
+	}
+	return upgraded
+}
+
+// removeHatMatte makes the hat transparent when it has no transparent pixels (as Minecraft does for a legacy skin)
+// Such a skin was drawn on an opaque background, which would otherwise cover the head
+// As with Minecraft, the whole right half (32x32) is checked, though only the hat (the top 32x16) is changed
+func removeHatMatte(img *image.NRGBA, scale int) {
+	half := image.Rect(32*scale, 0, 64*scale, 32*scale)
+	for y := half.Min.Y; y < half.Max.Y; y++ {
+		for x := half.Min.X; x < half.Max.X; x++ {
+			if img.Pix[img.PixOffset(x, y)+3] < 128 {
+				return
+			}
+		}
+	}
+	for y := half.Min.Y; y < 16*scale; y++ {
+		for x := half.Min.X; x < half.Max.X; x++ {
+			img.Pix[img.PixOffset(x, y)+3] = 0
+		}
+	}
+}
+
+// Normalise upgrades a legacy skin into the 64x64 layout, so that it can be rendered as any other
+// The Hash and AlphaSig are of the original skin
+func (s *Skin) Normalise() {
+	if img, ok := s.Image.(*image.NRGBA); ok {
+		s.Image = UpgradeLegacySkin(img)
+	}
+}
@@ -0,0 +1,69 @@
+// textures_skin_legacy_test.go
+package minecraft
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpgradeLegacySkin(t *testing.T) {
+
+	Convey("Test UpgradeLegacySkin", t, func() {
+
+		Convey("Legacy skins should be mirrored into the 64x64 layout", func() {
+			for _, scale := range []int{1, 2} {
+				legacy := image.NewNRGBA(image.Rect(0, 0, 64*scale, 32*scale))
+				// Mark the outer edge of the Right leg and arm fronts
+				red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+				legacy.SetNRGBA(4*scale, 20*scale, red)
+				legacy.SetNRGBA(44*scale, 20*scale, blue)
+
+				upgraded := UpgradeLegacySkin(legacy)
+
+				So(IsLegacySkin(upgraded), ShouldBeFalse)
+				So(upgraded.Bounds(), ShouldResemble, image.Rect(0, 0, 64*scale, 64*scale))
+				So(upgraded.NRGBAAt(4*scale, 20*scale), ShouldResemble, red)
+				// The Left leg and arm fronts are mirrored (so the mark is on the far side)
+				So(upgraded.NRGBAAt(24*scale-1, 52*scale), ShouldResemble, red)
+				So(upgraded.NRGBAAt(40*scale-1, 52*scale), ShouldResemble, blue)
+				// The overlays are transparent
+				So(upgraded.NRGBAAt(4*scale, 52*scale).A, ShouldEqual, 0)
+			}
+		})
+
+		Convey("A legacy hat without transparency should be removed", func() {
+			legacy := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+			draw.Draw(legacy, image.Rect(32, 0, 64, 32), image.NewUniform(color.NRGBA{255, 0, 255, 255}), image.Point{}, draw.Src)
+
+			upgraded := UpgradeLegacySkin(legacy)
+
+			So(upgraded.NRGBAAt(40, 8).A, ShouldEqual, 0)
+			// The right arm is kept
+			So(upgraded.NRGBAAt(44, 20).A, ShouldEqual, 255)
+
+			// Any transparency means the hat is kept
+			legacy.SetNRGBA(63, 31, color.NRGBA{})
+			upgraded = UpgradeLegacySkin(legacy)
+
+			So(upgraded.NRGBAAt(40, 8).A, ShouldEqual, 255)
+		})
+
+		Convey("1.8 skins should be unchanged", func() {
+			modern := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+
+			So(UpgradeLegacySkin(modern), ShouldEqual, modern)
+		})
+
+		Convey("Steve should already be Normalised", func() {
+			steve, err := SteveSkin()
+
+			So(err, ShouldBeNil)
+			So(steve.Image.Bounds(), ShouldResemble, image.Rect(0, 0, 64, 64))
+		})
+
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"image"
+	"sync"
 
 	// If we work with PNGs we need this
 	_ "image/png"
@@ -30,13 +31,52 @@ func (s *Skin) FetchSteve() error {
 	return nil
 }
 
+// steve is decoded once (and shared), as it's the fallback for every failed lookup
+var steve struct {
+	once     sync.Once
+	bytes    []byte
+	bytesErr error
+	skin     Skin
+	skinErr  error
+}
+
+func decodeSteve() {
+	steve.once.Do(func() {
+		steve.bytes, steve.bytesErr = base64.StdEncoding.DecodeString(SteveBase64)
+		if steve.bytesErr != nil {
+			steve.bytesErr = errors.Wrap(steve.bytesErr, "failed to GetSteveBytes")
+			steve.skinErr = steve.bytesErr
+			return
+		}
+		if err := steve.skin.Decode(bytes.NewReader(steve.bytes)); err != nil {
+			steve.skinErr = errors.Wrap(err, "failed to decode Steve skin")
+			return
+		}
+		steve.skin.Normalise()
+	})
+}
+
+// GetSteveBytes returns a copy of the Steve PNG
 func GetSteveBytes() (*bytes.Buffer, error) {
-	steveImgBytes, err := base64.StdEncoding.DecodeString(SteveBase64)
-	if err != nil {
-		return bytes.NewBuffer([]byte{}), errors.Wrap(err, "failed to GetSteveBytes")
+	decodeSteve()
+	if steve.bytesErr != nil {
+		return bytes.NewBuffer([]byte{}), steve.bytesErr
 	}
 
-	return bytes.NewBuffer(steveImgBytes), nil
+	return bytes.NewBuffer(append([]byte(nil), steve.bytes...)), nil
+}
+
+// SteveReader reads the shared Steve PNG (without a copy)
+func SteveReader() *bytes.Reader {
+	decodeSteve()
+	return bytes.NewReader(steve.bytes)
+}
+
+// SteveSkin returns the shared, already decoded (and Normalised), Steve skin
+// Its Image is shared between every caller, so it must not be modified
+func SteveSkin() (Skin, error) {
+	decodeSteve()
+	return steve.skin, steve.skinErr
 }
 
 func FetchImageForSteve() (image.Image, error) {
@@ -0,0 +1,34 @@
+// textures_skin_test.go
+package minecraft
+
+import (
+	"image"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSkinSize(t *testing.T) {
+
+	Convey("Test ValidateSkinSize", t, func() {
+
+		Convey("Standard, legacy and HD skins should be valid", func() {
+			for _, size := range [][2]int{{64, 64}, {64, 32}, {128, 128}, {128, 64}, {1024, 1024}} {
+				So(ValidateSkinSize(size[0], size[1]), ShouldBeNil)
+			}
+		})
+
+		Convey("Other sizes should be invalid", func() {
+			for _, size := range [][2]int{{0, 0}, {32, 32}, {96, 96}, {64, 48}, {128, 32}, {2048, 2048}} {
+				So(ValidateSkinSize(size[0], size[1]), ShouldNotBeNil)
+			}
+		})
+
+	})
+
+	Convey("Test SkinScale", t, func() {
+		So(SkinScale(image.NewNRGBA(image.Rect(0, 0, 64, 64))), ShouldEqual, 1)
+		So(SkinScale(image.NewNRGBA(image.Rect(0, 0, 256, 128))), ShouldEqual, 4)
+		So(SkinScale(image.NewNRGBA(image.Rect(0, 0, 8, 8))), ShouldEqual, 1)
+	})
+}
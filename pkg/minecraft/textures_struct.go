@@ -30,18 +30,19 @@ type Texture struct {
 // CastToNRGBA takes image bytes and converts to NRGBA format if needed
 func (t *Texture) CastToNRGBA(r io.Reader) error {
 	// Decode the skin
-	textureImg, format, err := image.Decode(r)
+	textureImg, _, err := image.Decode(r)
 	if err != nil {
 		return errors.Wrap(err, "unable to CastToNRGBA")
 	}
 
-	// Convert it to NRGBA if necessary
-	textureFinal := textureImg
-	if format != "NRGBA" {
-		bounds := textureImg.Bounds()
-		textureFinal = image.NewNRGBA(bounds)
-		draw.Draw(textureFinal.(draw.Image), bounds, textureImg, image.Pt(0, 0), draw.Src)
+	// Convert it to NRGBA if necessary (a PNG with an alpha channel is already NRGBA)
+	if nrgba, ok := textureImg.(*image.NRGBA); ok && nrgba.Rect.Min == image.Pt(0, 0) {
+		t.Image = nrgba
+		return nil
 	}
+	bounds := textureImg.Bounds()
+	textureFinal := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(textureFinal, textureFinal.Bounds(), textureImg, bounds.Min, draw.Src)
 
 	t.Image = textureFinal
 	return nil
@@ -79,35 +80,50 @@ func (t *Texture) loadTextureBody(texBody io.ReadCloser, err error) error {
 	return t.Decode(texBody)
 }
 
-// FetchWithTextureProperty takes a already decoded Texture Property and will request either Skin or Cape as instructed
-func (t *Texture) FetchWithTextureProperty(sptp SessionProfileTextureProperty, texType textureType) error {
-	err := t.loadTextureBody(t.Mc.TextureBodyFromTexturePropertyCtx(context.Background(), sptp, texType))
+// FetchWithTexturePropertyCtx is the same as FetchWithTextureProperty, but with Context on the Request
+func (t *Texture) FetchWithTexturePropertyCtx(ctx context.Context, sptp SessionProfileTextureProperty, texType textureType) error {
+	err := t.loadTextureBody(t.Mc.TextureBodyFromTexturePropertyCtx(ctx, sptp, texType))
 	if err != nil {
 		return fmt.Errorf("FetchWithTextureProperty failed: %w", err)
 	}
 	return nil
 }
 
-// FetchWithSessionProfile will decode the Texture Property for you and request the Skin or Cape as instructed
-// If requesting both Skin and Cape, this would result in 2 x decoding - use FetchWithTextureProperty instead
-func (t *Texture) FetchWithSessionProfile(sessionProfile SessionProfileResponse, texType textureType) error {
+// FetchWithTextureProperty takes a already decoded Texture Property and will request either Skin or Cape as instructed
+func (t *Texture) FetchWithTextureProperty(sptp SessionProfileTextureProperty, texType textureType) error {
+	return t.FetchWithTexturePropertyCtx(context.Background(), sptp, texType)
+}
+
+// FetchWithSessionProfileCtx is the same as FetchWithSessionProfile, but with Context on the Request
+func (t *Texture) FetchWithSessionProfileCtx(ctx context.Context, sessionProfile SessionProfileResponse, texType textureType) error {
 	sptp, err := DecodeTextureProperty(sessionProfile)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	err = t.FetchWithTextureProperty(sptp, texType)
+	err = t.FetchWithTexturePropertyCtx(ctx, sptp, texType)
 	if err != nil {
 		return fmt.Errorf("FetchWithSessionProfile failed: %w", err)
 	}
 	return nil
 }
 
-// FetchWithUsername takes a username and will then request from UsernameAPI as specified in the Minecraft struct
-func (t *Texture) FetchWithUsername(username string, texType textureType) error {
-	err := t.loadTextureBody(t.Mc.TextureBodyFromUsernameCtx(context.Background(), username, texType))
+// FetchWithSessionProfile will decode the Texture Property for you and request the Skin or Cape as instructed
+// If requesting both Skin and Cape, this would result in 2 x decoding - use FetchWithTextureProperty instead
+func (t *Texture) FetchWithSessionProfile(sessionProfile SessionProfileResponse, texType textureType) error {
+	return t.FetchWithSessionProfileCtx(context.Background(), sessionProfile, texType)
+}
+
+// FetchWithUsernameCtx is the same as FetchWithUsername, but with Context on the Request
+func (t *Texture) FetchWithUsernameCtx(ctx context.Context, username string, texType textureType) error {
+	err := t.loadTextureBody(t.Mc.TextureBodyFromUsernameCtx(ctx, username, texType))
 	if err != nil {
 		return fmt.Errorf("FetchWithUsername failed: %w", err)
 	}
 	return nil
 }
+
+// FetchWithUsername takes a username and will then request from UsernameAPI as specified in the Minecraft struct
+func (t *Texture) FetchWithUsername(username string, texType textureType) error {
+	return t.FetchWithUsernameCtx(context.Background(), username, texType)
+}
@@ -0,0 +1,109 @@
+package processd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+)
+
+// grpcSkinLookup is the SkinLookupWrapper logic when using the skind gRPC API (rather than the SkindURL)
+// The skin metadata (eg. TextureID) is returned alongside the bytes, so no headers need parsing
+// A (stale) cached skin is confirmed with skind using its TextureID
+func (p *Processd) grpcSkinLookup(w http.ResponseWriter, r *http.Request, logger log.Logger, settings route_helpers.HandlerSettings, timeout time.Duration, userReq mcclient.UserReq, userLookup string, cached *cachedUser, processFunc skind.SkinProcessor) {
+	ctx := r.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	skinReq := &skindpb.UserRequest{Username: userReq.Username, Uuid: userReq.UUID}
+	textures := p.textures()
+
+	if settings.RedirectUsername && userReq.UUID == "" {
+		if _, ok := mux.Vars(r)["resource"]; ok {
+			lookupStart := time.Now()
+			resolved, err := p.SkindClient.ResolveUsername(ctx, skinReq)
+			access_log.FromContext(r.Context()).AddTimingSince("skind", lookupStart)
+
+			// As with skind, a failed lookup is redirected to Steve
+			uuid := minecraft.SteveUUID
+			if err != nil {
+				logger.Debugf("Redirecting username to Steve UUID: %v", err)
+			} else {
+				uuid = resolved.GetUuid()
+				textures.insertRedirect(userReq.Username, uuid)
+			}
+			redirectToUUID(w, r, settings, uuid)
+			return
+		}
+		logger.Warnf("Unable to decode resource for redirect: %s", r.URL.Path)
+	}
+
+	reqETag := r.Header.Get("If-None-Match")
+	if cached != nil {
+		skinReq.IfNoneMatch = cached.textureID
+	} else if settings.UseETags {
+		skinReq.IfNoneMatch = reqETag
+	}
+
+	lookupStart := time.Now()
+	resp, err := p.SkindClient.GetSkin(ctx, skinReq)
+	access_log.FromContext(r.Context()).AddTimingSince("skind", lookupStart)
+	if err != nil {
+		sErr := &skindError{class: classifyGRPCError(r.Context(), err), err: err}
+		skindResponses.WithLabelValues(sErr.class).Inc()
+		p.handleSkindError(w, r, logger, settings, processFunc, sErr)
+		return
+	}
+	class := classifyGRPCStatus(resp)
+	skindResponses.WithLabelValues(class).Inc()
+	if class != classOK && class != classNotModified {
+		// skind fell back to Steve, which is served with the status of the failed lookup
+		p.handleSkindError(w, r, logger, settings, processFunc, &skindError{class: class, err: fmt.Errorf("skind returned %s", resp.GetStatus())})
+		return
+	}
+
+	// Only the skin of the user (with an OK Status) is cached or confirmed
+	if cached != nil && class == classNotModified && resp.GetTextureId() == cached.textureID {
+		textures.confirm(userLookup, cached.textureID)
+		access_log.FromContext(r.Context()).SetCache("processd", textureCacheRevalidated)
+		p.serveCachedSkin(w, r, logger, settings, cached, processFunc)
+		return
+	}
+	if textures != nil && class == classOK {
+		textures.insert(userLookup, resp.GetTextureId(), resp.GetSkin())
+		access_log.FromContext(r.Context()).SetCache("processd", textureCacheMiss)
+	}
+
+	w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(settings.CacheControlTTL.Seconds())))
+
+	if settings.UseETags {
+		// ETag is always included (even for 304 responses)
+		w.Header().Set("ETag", resp.GetTextureId())
+
+		if resp.GetNotModified() || reqETag == resp.GetTextureId() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	skinIO := mcuser.TextureIO{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(resp.GetSkin())),
+		TextureID:  resp.GetTextureId(),
+	}
+
+	p.render(w, r, logger, processFunc, skinIO)
+}
@@ -5,11 +5,18 @@ package mcskin
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("mcskin")
+
 // Will deliver an avatar Head when ServeHTTP  is called
 func HandlerHead(logger log.Logger, skinIO mcuser.TextureIO) http.HandlerFunc {
 	mcSkin := &McSkin{Skin: skinIO.MustDecodeSkin(logger)}
@@ -71,7 +78,11 @@ func (skin *McSkin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if skin.Processor != nil {
 		// If the Processor is set, use it to create the Processed image
 		skin.Width, skin.Type = GetWidthType(r)
+		_, span := tracer.Start(r.Context(), "Skin process", trace.WithAttributes(attribute.Int("width", skin.Width)))
+		processStart := time.Now()
 		skin.Processor()
+		access_log.FromContext(r.Context()).AddTimingSince("render", processStart)
+		span.End()
 	} else if skin.Processed == nil {
 		// Otherwise, if there was no Processor and the Processed hadn't already
 		// been set, then throw an error
@@ -81,6 +92,13 @@ func (skin *McSkin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The pooled images are reused once the image is encoded
+	defer skin.Release()
+
+	_, span := tracer.Start(r.Context(), "Skin encode", trace.WithAttributes(attribute.String("type", string(skin.Type))))
+	defer span.End()
+	defer access_log.FromContext(r.Context()).AddTimingSince("encode", time.Now())
+
 	switch skin.Type {
 	case ImageTypePNG:
 		w.Header().Add("Content-Type", string(ImageTypePNG))
@@ -0,0 +1,47 @@
+package mcskin
+
+import (
+	"image"
+)
+
+// layer is a face of a part of the model, with the rectangles of its base (first) layer and its overlay (second) layer
+// The rectangles are standard skin offsets (which the crop scales for an HD skin)
+type layer struct {
+	base, overlay image.Rectangle
+}
+
+func layerRect(x, y, width, height int) image.Rectangle {
+	return image.Rect(x, y, x+width, y+height)
+}
+
+var (
+	headFront = layer{layerRect(HeadX, HeadY, HeadWidth, HeadHeight), layerRect(HelmX, HelmY, HeadWidth, HeadHeight)}
+	// The right side of the head (shown by the cube)
+	headSide = layer{layerRect(HeadX-HeadWidth, HeadY, HeadWidth, HeadHeight), layerRect(HelmX-HeadWidth, HelmY, HeadWidth, HeadHeight)}
+	headTop  = layer{layerRect(HeadX, HeadY-HeadHeight, HeadWidth, HeadHeight), layerRect(HelmX, HelmY-HeadHeight, HeadWidth, HeadHeight)}
+
+	torsoFront = layer{layerRect(TorsoX, TorsoY, TorsoWidth, TorsoHeight), layerRect(Torso2X, Torso2Y, TorsoWidth, TorsoHeight)}
+	raFront    = layer{layerRect(RaX, RaY, RaWidth, RaHeight), layerRect(Ra2X, Ra2Y, RaWidth, RaHeight)}
+	laFront    = layer{layerRect(LaX, LaY, LaWidth, LaHeight), layerRect(La2X, La2Y, LaWidth, LaHeight)}
+	rlFront    = layer{layerRect(RlX, RlY, RlWidth, RlHeight), layerRect(Rl2X, Rl2Y, RlWidth, RlHeight)}
+	llFront    = layer{layerRect(LlX, LlY, LlWidth, LlHeight), layerRect(Ll2X, Ll2Y, LlWidth, LlHeight)}
+)
+
+// composite crops the face, with the overlay (helm, jacket, sleeve or pants) drawn over it when withOverlay.
+// As with the Minecraft client, the base layer is opaque (its alpha is ignored), and the overlay is blended by its alpha.
+// A legacy skin has no overlays other than the hat (see minecraft.UpgradeLegacySkin for its matte).
+func (skin *McSkin) composite(l layer, withOverlay bool) *image.NRGBA {
+	img := skin.crop(l.base)
+	setOpaque(img)
+	if withOverlay {
+		fastDraw(img, skin.crop(l.overlay), 0, 0)
+	}
+	return img
+}
+
+// setOpaque sets the alpha of every pixel to 0xFF (keeping its colour).
+func setOpaque(img *image.NRGBA) {
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xFF
+	}
+}
@@ -0,0 +1,57 @@
+package mcskin
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/minotar/imgd/pkg/minecraft"
+)
+
+func TestComposite(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	// A transparent base pixel keeps its colour
+	img.SetNRGBA(TorsoX, TorsoY, color.NRGBA{10, 20, 30, 0})
+	// An opaque jacket, a translucent sleeve and a transparent pants pixel
+	img.SetNRGBA(Torso2X+1, Torso2Y, color.NRGBA{255, 0, 0, 255})
+	img.SetNRGBA(RaX, RaY, color.NRGBA{0, 0, 0, 255})
+	img.SetNRGBA(Ra2X, Ra2Y, color.NRGBA{0, 0, 255, 128})
+	img.SetNRGBA(RlX, RlY, color.NRGBA{0, 255, 0, 255})
+	img.SetNRGBA(Rl2X, Rl2Y, color.NRGBA{255, 255, 255, 0})
+
+	testCases := []struct {
+		x, y        int
+		base, armor color.NRGBA
+	}{
+		{LaWidth, HeadHeight, color.NRGBA{10, 20, 30, 255}, color.NRGBA{10, 20, 30, 255}},
+		{LaWidth + 1, HeadHeight, color.NRGBA{0, 0, 0, 255}, color.NRGBA{255, 0, 0, 255}},
+		{0, HeadHeight, color.NRGBA{0, 0, 0, 255}, color.NRGBA{0, 0, 128, 255}},
+		{LaWidth, HeadHeight + TorsoHeight, color.NRGBA{0, 255, 0, 255}, color.NRGBA{0, 255, 0, 255}},
+	}
+
+	// The SVG type is not resized
+	for _, withOverlay := range []bool{false, true} {
+		skin := &McSkin{Skin: minecraft.Skin{Texture: minecraft.Texture{Image: img}}, Type: ImageTypeSVG}
+		skin.renderBody(false, withOverlay)
+		processed := skin.Processed.(*image.NRGBA)
+
+		for _, tc := range testCases {
+			expected := tc.base
+			if withOverlay {
+				expected = tc.armor
+			}
+			if actual := processed.NRGBAAt(tc.x, tc.y); actual != expected {
+				t.Errorf("Expected %v at %d,%d (overlay %t), not: %v", expected, tc.x, tc.y, withOverlay, actual)
+			}
+		}
+		skin.Release()
+	}
+
+	// The cube's faces are opaque too (though the skew can round the alpha down)
+	skin := &McSkin{Skin: minecraft.Skin{Texture: minecraft.Texture{Image: img}}, Type: ImageTypePNG, Width: 64}
+	skin.GetCube()
+	if alpha := skin.Processed.(*image.NRGBA).NRGBAAt(48, 40).A; alpha < 250 {
+		t.Errorf("Expected the front of the cube to be opaque, not: %d", alpha)
+	}
+	skin.Release()
+}
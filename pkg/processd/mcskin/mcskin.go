@@ -7,19 +7,17 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
 	"io"
 	"math"
 	"net/http"
 	"strconv"
 
-	svg "github.com/ajstarks/svgo"
 	"github.com/disintegration/gift"
-	"github.com/disintegration/imaging"
 	"github.com/gorilla/mux"
 	"github.com/minotar/imgd/pkg/minecraft"
 )
 
+// The offsets of each part, in a standard 64x64 skin (an HD skin's are scaled by its multiple of 64)
 const (
 	HeadX      = 8
 	HeadY      = 8
@@ -140,378 +138,218 @@ type McSkin struct {
 	Processor func() error
 	Type      ImageType
 	Width     int
+	// Images from the pool (returned by the Release)
+	pooled []*image.NRGBA
 }
 
 // Sets skin.Processed to the face of the user.
 func (skin *McSkin) GetHead() error {
-	skin.Processed = skin.cropHead(skin.Image)
-	skin.resize(imaging.NearestNeighbor)
+	skin.Processed = skin.composite(headFront, false)
+	skin.resize()
 	return nil
 }
 
 // Sets skin.Processed to the face of the user overlaid with their helmet.
 func (skin *McSkin) GetHelm() error {
-	skin.Processed = skin.cropHelm(skin.Image)
-	skin.resize(imaging.NearestNeighbor)
+	skin.Processed = skin.composite(headFront, true)
+	skin.resize()
 	return nil
 }
 
-// Sets skin.Processed to an isometric render of the head from a top-left angle (showing 3 sides).
-func (skin *McSkin) GetCube() error {
-	width := skin.Width
-	// Crop out the top of the head
-	topFlat := imaging.Crop(skin.Image, image.Rect(8, 0, 16, 8))
-	// Resize appropriately, so that it fills the `width` when rotated 45 def.
-	topFlat = imaging.Resize(topFlat, int(float64(width)*math.Sqrt(2)/3+1), 0, imaging.NearestNeighbor)
-	// Create the Gift filter
+// cubeTopFilter rotates the top of the head (the images are small, so it's not parallelized)
+var cubeTopFilter = func() *gift.GIFT {
 	filter := gift.New(
 		gift.Rotate(45, color.Transparent, gift.LinearInterpolation),
 	)
-	bounds := filter.Bounds(topFlat.Bounds())
-	top := image.NewNRGBA(bounds)
-	// Draw it on the filter, then smush it!
-	filter.Draw(top, topFlat)
-	top = imaging.Resize(top, width+2, width/3, imaging.NearestNeighbor)
-	// Skew the front and sides at 15 degree angles to match up with the
-	// head that has been smushed
-	front := skin.cropHead(skin.Image).(*image.NRGBA)
-	side := imaging.Crop(skin.Image, image.Rect(0, 8, 8, 16))
-	front = imaging.Resize(front, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	side = imaging.Resize(side, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	front = skewVertical(front, math.Pi/12)
-	side = skewVertical(imaging.FlipH(side), math.Pi/-12)
-
-	// Create a new image to assemble upon
-	skin.Processed = image.NewNRGBA(image.Rect(0, 0, width, width))
-	// Draw each side
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(0, width/6, width/2, width), side, image.Pt(0, 0), draw.Src)
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(width/2, width/6, width, width), front, image.Pt(0, 0), draw.Src)
-	// Draw the top we created
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(-1, 0, width+1, width/3), top, image.Pt(0, 0), draw.Over)
-
-	return nil
-}
+	filter.SetParallelization(false)
+	return filter
+}()
 
-// Sets skin.Processed to an isometric render of the head from a top-left angle (showing 3 sides).
-func (skin *McSkin) GetCubeHelm() error {
+// cubeTop rotates and smushes the top of the head to fit the top of the cube
+func (skin *McSkin) cubeTop(topFlat *image.NRGBA) *image.NRGBA {
 	width := skin.Width
-	// Crop out the top of the head
-	topFlat := imaging.Crop(skin.Image, image.Rect(8, 0, 16, 8))
-	// Resize appropriately, so that it fills the `width` when rotated 45 def.
-	topFlat = imaging.Resize(topFlat, int(float64(width)*math.Sqrt(2)/3+1), 0, imaging.NearestNeighbor)
-	// Create the Gift filter
-	filter := gift.New(
-		gift.Rotate(45, color.Transparent, gift.LinearInterpolation),
-	)
-
-	bounds := filter.Bounds(topFlat.Bounds())
-	top := image.NewNRGBA(bounds)
-	// Draw it on the filter, then smush it!
-	filter.Draw(top, topFlat)
-	top = imaging.Resize(top, width+2, width/3, imaging.NearestNeighbor)
-
-	// Crop out the top of the head
-	topFlatHelm := imaging.Crop(skin.Image, image.Rect(40, 0, 48, 8))
 	// Resize appropriately, so that it fills the `width` when rotated 45 def.
-	topFlatHelm = imaging.Resize(topFlatHelm, int(float64(width)*math.Sqrt(2)/3+1), 0, imaging.NearestNeighbor)
-
-	boundshelm := filter.Bounds(topFlatHelm.Bounds())
-	tophelm := image.NewNRGBA(boundshelm)
+	topFlat = skin.scale(topFlat, int(float64(width)*math.Sqrt(2)/3+1), 0)
+	top := skin.newNRGBA(cubeTopFilter.Bounds(topFlat.Bounds()))
 	// Draw it on the filter, then smush it!
-	filter.Draw(tophelm, topFlatHelm)
-	tophelm = imaging.Resize(tophelm, width+2, width/3, imaging.NearestNeighbor)
-
-	// Skew the front and sides at 15 degree angles to match up with the
-	// head that has been smushed
-	side := imaging.Crop(skin.Image, image.Rect(0, 8, 8, 16))
-	side = imaging.Resize(side, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	side = skewVertical(imaging.FlipH(side), math.Pi/-12)
-
-	sidehelm := imaging.Crop(skin.Image, image.Rect(32, 8, 40, 16))
-	sidehelm = imaging.Resize(sidehelm, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	sidehelm = skewVertical(imaging.FlipH(sidehelm), math.Pi/-12)
+	cubeTopFilter.Draw(top, topFlat)
+	return skin.scale(top, width+2, width/3)
+}
 
-	front := skin.cropHead(skin.Image).(*image.NRGBA)
-	front = imaging.Resize(front, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	front = skewVertical(front, math.Pi/12)
+// cubeSide skews the front (or side) at 15 degree angles to match up with the head that has been smushed
+func (skin *McSkin) cubeSide(img *image.NRGBA, front bool) *image.NRGBA {
+	width := skin.Width
+	img = skin.scale(img, width/2, int(float64(width)/1.75))
+	if front {
+		return skin.skewVertical(img, math.Pi/12)
+	}
+	return skin.skewVertical(skin.flipH(img), math.Pi/-12)
+}
 
-	fronthelm := skin.cropHelm(skin.Image).(*image.NRGBA)
-	fronthelm = imaging.Resize(fronthelm, width/2, int(float64(width)/1.75), imaging.NearestNeighbor)
-	fronthelm = skewVertical(fronthelm, math.Pi/12)
+// renderCube draws the isometric head, with each face composited (with the helm when withHelm) before it's transformed.
+func (skin *McSkin) renderCube(withHelm bool) {
+	width := skin.Width
+	top := skin.cubeTop(skin.composite(headTop, withHelm))
+	front := skin.cubeSide(skin.composite(headFront, withHelm), true)
+	side := skin.cubeSide(skin.composite(headSide, withHelm), false)
 
 	// Create a new image to assemble upon
-	skin.Processed = image.NewNRGBA(image.Rect(0, 0, width, width))
+	processed := skin.newNRGBA(image.Rect(0, 0, width, width))
 	// Draw each side
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(0, width/6, width/2, width), side, image.Pt(0, 0), draw.Src)
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(0, width/6, width/2, width), sidehelm, image.Pt(0, 0), draw.Over)
-
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(width/2, width/6, width, width), front, image.Pt(0, 0), draw.Src)
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(width/2, width/6, width, width), fronthelm, image.Pt(0, 0), draw.Over)
+	draw.Draw(processed, image.Rect(0, width/6, width/2, width), side, image.Pt(0, 0), draw.Src)
+	draw.Draw(processed, image.Rect(width/2, width/6, width, width), front, image.Pt(0, 0), draw.Src)
 	// Draw the top we created
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(-1, 0, width+1, width/3), top, image.Pt(0, 0), draw.Over)
-	draw.Draw(skin.Processed.(draw.Image), image.Rect(-1, 0, width+1, width/3), tophelm, image.Pt(0, 0), draw.Over)
+	draw.Draw(processed, image.Rect(-1, 0, width+1, width/3), top, image.Pt(0, 0), draw.Over)
+
+	skin.Processed = processed
+}
+
+// Sets skin.Processed to an isometric render of the head from a top-left angle (showing 3 sides).
+func (skin *McSkin) GetCube() error {
+	skin.renderCube(false)
+	return nil
+}
 
+// Sets skin.Processed to an isometric render of the head from a top-left angle (showing 3 sides) with the helm.
+func (skin *McSkin) GetCubeHelm() error {
+	skin.renderCube(true)
 	return nil
 }
 
 // Sets skin.Processed to the upper portion of the body (slightly higher cutoff than waist).
 func (skin *McSkin) GetBust() error {
-	headImg := skin.cropHead(skin.Image).(*image.NRGBA)
-	upperBodyImg := skin.renderUpperBody()
-
-	bustImg := skin.addHead(upperBodyImg, headImg)
-
-	bustImg.Rect.Max.Y = BustHeight
-	skin.Processed = bustImg
-
-	skin.resize(imaging.NearestNeighbor)
-
+	skin.renderBody(true, false)
 	return nil
 }
 
 // Sets skin.Processed to the upper portion of the body (slightly higher cutoff than waist) but with any armor which the user has.
 func (skin *McSkin) GetArmorBust() error {
-	helmImg := skin.cropHelm(skin.Image).(*image.NRGBA)
-	upperArmorImg := skin.renderUpperArmor()
-
-	bustImg := skin.addHead(upperArmorImg, helmImg)
-
-	bustImg.Rect.Max.Y = BustHeight
-	skin.Processed = bustImg
-
-	skin.resize(imaging.NearestNeighbor)
-
+	skin.renderBody(true, true)
 	return nil
 }
 
 // Sets skin.Processed to a front render of the body.
 func (skin *McSkin) GetBody() error {
-	headImg := skin.cropHead(skin.Image).(*image.NRGBA)
-	upperBodyImg := skin.renderUpperBody()
-	lowerBodyImg := skin.renderLowerBody()
-
-	bodyImg := skin.addHead(upperBodyImg, headImg)
-	skin.Processed = skin.addLegs(bodyImg, lowerBodyImg)
-
-	skin.resize(imaging.NearestNeighbor)
-
+	skin.renderBody(false, false)
 	return nil
 }
 
 // Sets skin.Processed to a front render of the body but with any armor which the user has.
 func (skin *McSkin) GetArmorBody() error {
-	helmImg := skin.cropHelm(skin.Image).(*image.NRGBA)
-	upperArmorImg := skin.renderUpperArmor()
-	lowerArmorImg := skin.renderLowerArmor()
-
-	bodyImg := skin.addHead(upperArmorImg, helmImg)
-	skin.Processed = skin.addLegs(bodyImg, lowerArmorImg)
-
-	skin.resize(imaging.NearestNeighbor)
-
+	skin.renderBody(false, true)
 	return nil
 }
 
-// Returns the torso and arms.
-func (skin *McSkin) renderUpperBody() *image.NRGBA {
-	// This will be the base.
-	upperBodyImg := image.NewNRGBA(image.Rect(0, 0, LaWidth+TorsoWidth+RaWidth, TorsoHeight))
-
-	torsoImg := imaging.Crop(skin.Image, image.Rect(TorsoX, TorsoY, TorsoX+TorsoWidth, TorsoY+TorsoHeight))
-	raImg := imaging.Crop(skin.Image, image.Rect(RaX, RaY, RaX+RaWidth, RaY+TorsoHeight))
-
-	// If it's an old skin, they don't have a Left Arm, so we'll just flip their right.
-	var laImg image.Image
-	if skin.is18Skin() {
-		laImg = imaging.Crop(skin.Image, image.Rect(LaX, LaY, LaX+LaWidth, LaY+TorsoHeight))
-	} else {
-		laImg = imaging.FlipH(raImg)
-	}
-
-	return skin.drawUpper(upperBodyImg, torsoImg, raImg, laImg.(*image.NRGBA))
-}
-
-// Returns the torso and arms but with any armor which the user has.
-func (skin *McSkin) renderUpperArmor() *image.NRGBA {
-	// This will be the base.
-	upperArmorBodyImg := skin.renderUpperBody()
-
-	// If it's an old skin, they don't have armor here.
-	if skin.is18Skin() {
-		// Get the armor layers from the skin and remove the Alpha.
-		torso2Img := imaging.Crop(skin.Image, image.Rect(Torso2X, Torso2Y, Torso2X+TorsoWidth, Torso2Y+TorsoHeight))
-		skin.removeAlpha(torso2Img)
-
-		la2Img := imaging.Crop(skin.Image, image.Rect(La2X, La2Y, La2X+LaWidth, La2Y+TorsoHeight))
-		skin.removeAlpha(la2Img)
-
-		ra2Img := imaging.Crop(skin.Image, image.Rect(Ra2X, Ra2Y, Ra2X+RaWidth, Ra2Y+TorsoHeight))
-		skin.removeAlpha(ra2Img)
-
-		return skin.drawUpper(upperArmorBodyImg, torso2Img, ra2Img, la2Img)
+// renderBody draws the front of the body (cut off for a bust), with each part composited with its overlay when withOverlay.
+// The Right Arm and Leg are on the left, as they're seen from the front.
+func (skin *McSkin) renderBody(bust, withOverlay bool) {
+	scale := skin.textureScale()
+	height := HeadHeight + TorsoHeight
+	if !bust {
+		height += LlHeight
 	}
-	return upperArmorBodyImg
-}
-
-// Given a base, torso and arms, it will return them all arranged correctly.
-func (skin *McSkin) drawUpper(base, torso, la, ra *image.NRGBA) *image.NRGBA {
-	// Torso
-	fastDraw(base, torso, LaWidth, 0)
-	// Left Arm
-	fastDraw(base, la, 0, 0)
-	// Right Arm
-	fastDraw(base, ra, LaWidth+TorsoWidth, 0)
-
-	return base
-}
-
-// Returns the legs.
-func (skin *McSkin) renderLowerBody() *image.NRGBA {
-	// This will be the base.
-	lowerBodyImg := image.NewNRGBA(image.Rect(0, 0, LlWidth+RlWidth, LlHeight))
+	bodyImg := skin.newNRGBA(skin.scaleRect(image.Rect(0, 0, LaWidth+TorsoWidth+RaWidth, height)))
 
-	rlImg := imaging.Crop(skin.Image, image.Rect(RlX, RlY, RlX+RlWidth, RlY+RlHeight))
+	fastDraw(bodyImg, skin.composite(headFront, withOverlay), LaWidth*scale, 0)
+	fastDraw(bodyImg, skin.composite(torsoFront, withOverlay), LaWidth*scale, HeadHeight*scale)
+	fastDraw(bodyImg, skin.composite(raFront, withOverlay), 0, HeadHeight*scale)
+	fastDraw(bodyImg, skin.composite(laFront, withOverlay), (LaWidth+TorsoWidth)*scale, HeadHeight*scale)
 
-	// If it's an old skin, they don't have a Left Leg, so we'll just flip their right.
-	var llImg image.Image
-	if skin.is18Skin() {
-		llImg = imaging.Crop(skin.Image, image.Rect(LlX, LlY, LlX+LlWidth, LlY+LlHeight))
+	if bust {
+		bodyImg.Rect.Max.Y = BustHeight * scale
 	} else {
-		llImg = imaging.FlipH(rlImg)
+		fastDraw(bodyImg, skin.composite(rlFront, withOverlay), LaWidth*scale, (HeadHeight+TorsoHeight)*scale)
+		fastDraw(bodyImg, skin.composite(llFront, withOverlay), (LaWidth+LlWidth)*scale, (HeadHeight+TorsoHeight)*scale)
 	}
+	skin.Processed = bodyImg
 
-	return skin.drawLower(lowerBodyImg, rlImg, llImg.(*image.NRGBA))
-}
-
-// Returns the legs but with any armor which the user has.
-func (skin *McSkin) renderLowerArmor() *image.NRGBA {
-	// This will be the base.
-	lowerArmorBodyImg := skin.renderLowerBody()
-
-	// If it's an old skin, they don't have armor here.
-	if skin.is18Skin() {
-		// Get the armor layers from the skin and remove the Alpha.
-		ll2Img := imaging.Crop(skin.Image, image.Rect(Ll2X, Ll2Y, Ll2X+LlWidth, Ll2Y+LlHeight))
-		skin.removeAlpha(ll2Img)
-
-		rl2Img := imaging.Crop(skin.Image, image.Rect(Rl2X, Rl2Y, Rl2X+RlWidth, Rl2Y+RlHeight))
-		skin.removeAlpha(rl2Img)
-
-		return skin.drawLower(lowerArmorBodyImg, rl2Img, ll2Img)
-	}
-	return lowerArmorBodyImg
-}
-
-// Given a base and legs, it will return them all arranged correctly.
-func (skin *McSkin) drawLower(base, ll, rl *image.NRGBA) *image.NRGBA {
-	// Left Leg
-	fastDraw(base, ll, 0, 0)
-	// Right Leg
-	fastDraw(base, rl, LlWidth, 0)
-
-	return base
-}
-
-// Rams the head onto the base (hopefully body...) to return a Frankenstein.
-func (skin *McSkin) addHead(base, head *image.NRGBA) *image.NRGBA {
-	base.Pix = append(make([]uint8, HeadHeight*base.Stride), base.Pix...)
-	base.Rect.Max.Y += HeadHeight
-	fastDraw(base, head, LaWidth, 0)
-
-	return base
-}
-
-// Attached the legs onto the base (likely body).
-func (skin *McSkin) addLegs(base, legs *image.NRGBA) *image.NRGBA {
-	base.Pix = append(base.Pix, make([]uint8, LlHeight*base.Stride)...)
-	base.Rect.Max.Y += LlHeight
-	fastDraw(base, legs, LaWidth, HeadHeight+TorsoHeight)
-
-	return base
+	skin.resize()
 }
 
 // Writes the *processed* image as a PNG to the given writer.
 func (skin *McSkin) WritePNG(w io.Writer) error {
-	return png.Encode(w, skin.Processed)
-}
-
-// Writes the processed image as an svg.
-func (skin *McSkin) WriteSVG(w io.Writer) error {
-	canvas := svg.New(w)
-	bounds := skin.Processed.Bounds()
-	img := skin.Processed.(*image.NRGBA)
-
-	// Make a canvas the same size as the image.
-	canvas.Start(bounds.Max.X, bounds.Max.Y, `shape-rendering="crispEdges"`)
-	// Loop through every pixel in the image.
-	for y := 0; y < bounds.Max.Y; y += 1 {
-		for x := 0; x < bounds.Max.X; x += 1 {
-			ptr := y*img.Stride + x*4
-
-			// Only draw opaque pixels.
-			if img.Pix[ptr+3] == 0xFF {
-				canvas.Rect(x, y, 1, 1, "fill:rgb("+
-					strconv.Itoa(int(img.Pix[ptr]))+","+
-					strconv.Itoa(int(img.Pix[ptr+1]))+","+
-					strconv.Itoa(int(img.Pix[ptr+2]))+")")
-			}
-
-		}
-	}
-	canvas.End()
-	return nil
+	return pngEncoder.Encode(w, skin.Processed)
 }
 
 // Writes the *original* skin image as a png to the given writer.
 func (skin *McSkin) WriteSkin(w io.Writer) error {
-	return png.Encode(w, skin.Image)
+	return pngEncoder.Encode(w, skin.Image)
 }
 
 // Resizes the skin to the given dimensions, keeping aspect ratio.
-func (skin *McSkin) resize(filter imaging.ResampleFilter) {
+func (skin *McSkin) resize() {
 	if skin.Type != ImageTypeSVG {
-		skin.Processed = imaging.Resize(skin.Processed, skin.Width, 0, filter)
+		skin.Processed = skin.scale(skin.Processed.(*image.NRGBA), skin.Width, 0)
 	}
 }
 
-// Removes the skin's alpha matte from the given image.
-func (skin *McSkin) removeAlpha(img *image.NRGBA) {
-	// If it's already a transparent image, do nothing
-	if skin.AlphaSig[3] == 0 {
-		return
+// scale resizes the image (with a pooled image), picking the nearest pixels as imaging.NearestNeighbor
+// A width or height of 0 keeps the aspect ratio.
+func (skin *McSkin) scale(src *image.NRGBA, width, height int) *image.NRGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	if width == 0 {
+		width = int(math.Max(1.0, math.Floor(float64(height)*float64(srcW)/float64(srcH)+0.5)))
+	}
+	if height == 0 {
+		height = int(math.Max(1.0, math.Floor(float64(width)*float64(srcH)/float64(srcW)+0.5)))
+	}
+	if width <= 0 || height <= 0 || srcW <= 0 || srcH <= 0 {
+		return &image.NRGBA{}
 	}
 
-	// Otherwise loop through all the pixels. Check to see which ones match
-	// the alpha signature and set their opacity to be zero.
-	for i := 0; i < len(img.Pix); i += 4 {
-		if img.Pix[i+0] == skin.AlphaSig[0] &&
-			img.Pix[i+1] == skin.AlphaSig[1] &&
-			img.Pix[i+2] == skin.AlphaSig[2] &&
-			img.Pix[i+3] == skin.AlphaSig[3] {
-			img.Pix[i+3] = 0
+	dst := skin.newNRGBA(image.Rect(0, 0, width, height))
+	dx := float64(srcW) / float64(width)
+	dy := float64(srcH) / float64(height)
+	for y := 0; y < height; y++ {
+		srcOff0 := src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+int((float64(y)+0.5)*dy))
+		dstOff := y * dst.Stride
+		for x := 0; x < width; x++ {
+			srcOff := srcOff0 + int((float64(x)+0.5)*dx)*4
+			copy(dst.Pix[dstOff:dstOff+4], src.Pix[srcOff:srcOff+4])
+			dstOff += 4
 		}
 	}
+	return dst
 }
 
-// Checks if the skin is a 1.8 skin using its height.
-func (skin *McSkin) is18Skin() bool {
-	bounds := skin.Image.Bounds()
-	return bounds.Max.Y == 64
+// textureScale is the multiple of the standard (64 wide) skin, which the offsets are scaled by.
+func (skin *McSkin) textureScale() int {
+	return minecraft.SkinScale(skin.Image)
 }
 
-// Returns the head of the skin image.
-func (skin *McSkin) cropHead(img image.Image) image.Image {
-	return imaging.Crop(img, image.Rect(HeadX, HeadY, HeadX+HeadWidth, HeadY+HeadHeight))
+// scaleRect scales the standard skin offsets to the skin.
+func (skin *McSkin) scaleRect(r image.Rectangle) image.Rectangle {
+	scale := skin.textureScale()
+	return image.Rectangle{Min: r.Min.Mul(scale), Max: r.Max.Mul(scale)}
 }
 
-// Returns the head of the skin image overlayed with the helm.
-func (skin *McSkin) cropHelm(img image.Image) image.Image {
-	headImg := skin.cropHead(img)
-	helmImg := imaging.Crop(img, image.Rect(HelmX, HelmY, HelmX+HeadWidth, HelmY+HeadHeight))
-	skin.removeAlpha(helmImg)
-	fastDraw(headImg.(*image.NRGBA), helmImg, 0, 0)
+// crop copies the rectangle (given in standard skin offsets) of the skin (with a pooled image).
+func (skin *McSkin) crop(r image.Rectangle) *image.NRGBA {
+	src := skin.Image.(*image.NRGBA)
+	r = skin.scaleRect(r).Intersect(src.Rect)
 
-	return headImg
+	dst := skin.newNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for y := 0; y < r.Dy(); y++ {
+		srcOff := src.PixOffset(r.Min.X, r.Min.Y+y)
+		copy(dst.Pix[y*dst.Stride:(y+1)*dst.Stride], src.Pix[srcOff:srcOff+dst.Stride])
+	}
+	return dst
+}
+
+// flipH mirrors the image horizontally (with a pooled image).
+func (skin *McSkin) flipH(src *image.NRGBA) *image.NRGBA {
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dst := skin.newNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcOff := src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y+y)
+		dstOff := y*dst.Stride + (width-1)*4
+		for x := 0; x < width; x++ {
+			copy(dst.Pix[dstOff:dstOff+4], src.Pix[srcOff:srcOff+4])
+			srcOff += 4
+			dstOff -= 4
+		}
+	}
+	return dst
 }
 
 // Draws the "src" onto the "dst" image at the given x/y bounds, maintaining
@@ -576,7 +414,8 @@ func floatToUint(color *[4]float32, final *[4]uint8) {
 	final[3] = uint8(color[3] * 255)
 }
 
-func skewVertical(src *image.NRGBA, degrees float64) *image.NRGBA {
+// skewVertical skews the image (with a pooled image).
+func (skin *McSkin) skewVertical(src *image.NRGBA, degrees float64) *image.NRGBA {
 	bounds := src.Bounds()
 	maxY := bounds.Max.Y
 	maxX := bounds.Max.X * 4
@@ -588,7 +427,7 @@ func skewVertical(src *image.NRGBA, degrees float64) *image.NRGBA {
 	}
 
 	newHeight := maxY + int(1+distance)
-	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Max.X, newHeight))
+	dst := skin.newNRGBA(image.Rect(0, 0, bounds.Max.X, newHeight))
 
 	step := distance
 	for x := 0; x < maxX; x += 4 {
@@ -615,7 +454,7 @@ func skewVertical(src *image.NRGBA, degrees float64) *image.NRGBA {
 	}
 
 	if shouldFlip {
-		return imaging.FlipH(dst)
+		return skin.flipH(dst)
 	} else {
 		return dst
 	}
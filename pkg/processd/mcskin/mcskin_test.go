@@ -1,6 +1,9 @@
 package mcskin_test
 
 import (
+	"bytes"
+	"image"
+	"io"
 	"os"
 	"testing"
 
@@ -54,3 +57,106 @@ func TestRenderArmorBody(t *testing.T) {
 	mcSkin.GetArmorBody()
 	writeMcSkin(mcSkin, "test_render_armor_body.png")
 }
+
+// renderers of each resource, as used by the Handlers
+var renderers = map[string]func(*mcskin.McSkin) func() error{
+	"Avatar":    func(s *mcskin.McSkin) func() error { return s.GetHead },
+	"Helm":      func(s *mcskin.McSkin) func() error { return s.GetHelm },
+	"Cube":      func(s *mcskin.McSkin) func() error { return s.GetCube },
+	"CubeHelm":  func(s *mcskin.McSkin) func() error { return s.GetCubeHelm },
+	"Bust":      func(s *mcskin.McSkin) func() error { return s.GetBust },
+	"ArmorBust": func(s *mcskin.McSkin) func() error { return s.GetArmorBust },
+	"Body":      func(s *mcskin.McSkin) func() error { return s.GetBody },
+	"ArmorBody": func(s *mcskin.McSkin) func() error { return s.GetArmorBody },
+}
+
+// TestRenderRelease checks that a render is unchanged by reusing the (released) pooled images
+func TestRenderRelease(t *testing.T) {
+	mcSkin, err := getMcSkin()
+	if err != nil {
+		t.Fatalf("Unable to get mcSkin: %s", err)
+	}
+	steve, err := minecraft.SteveSkin()
+	if err != nil {
+		t.Fatalf("Unable to get Steve: %s", err)
+	}
+
+	for name, renderer := range renderers {
+		var renders [2]bytes.Buffer
+		for i := range renders {
+			for _, width := range []int{mcskin.MaxWidth, mcskin.MinWidth} {
+				// Steve is an upgraded legacy skin (rendered with the same pooled images)
+				for _, skin := range []minecraft.Skin{mcSkin.Skin, steve} {
+					skin := &mcskin.McSkin{Skin: skin, Type: mcskin.ImageTypePNG, Width: width}
+					renderer(skin)()
+					if err := skin.WritePNG(&renders[i]); err != nil {
+						t.Fatalf("Unable to write %s: %s", name, err)
+					}
+					skin.Release()
+				}
+			}
+		}
+		if !bytes.Equal(renders[0].Bytes(), renders[1].Bytes()) {
+			t.Errorf("%s changed when rendered with released images", name)
+		}
+	}
+}
+
+// upscale enlarges the skin by the scale, as an HD skin of the same pixels
+func upscale(skin minecraft.Skin, scale int) minecraft.Skin {
+	src := skin.Image.(*image.NRGBA)
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			dst.SetNRGBA(x, y, src.NRGBAAt(x/scale, y/scale))
+		}
+	}
+	skin.Image = dst
+	return skin
+}
+
+// TestRenderHD checks that an HD skin (of the same pixels) renders the same as the 64x64 skin
+func TestRenderHD(t *testing.T) {
+	mcSkin, err := getMcSkin()
+	if err != nil {
+		t.Fatalf("Unable to get mcSkin: %s", err)
+	}
+
+	for name, renderer := range renderers {
+		for _, width := range []int{mcskin.MinWidth, mcskin.DefaultWidth, mcskin.MaxWidth} {
+			var renders [3]bytes.Buffer
+			for i, scale := range []int{1, 2, 4} {
+				skin := &mcskin.McSkin{Skin: upscale(mcSkin.Skin, scale), Type: mcskin.ImageTypePNG, Width: width}
+				renderer(skin)()
+				if err := skin.WritePNG(&renders[i]); err != nil {
+					t.Fatalf("Unable to write %s: %s", name, err)
+				}
+				skin.Release()
+			}
+			if !bytes.Equal(renders[0].Bytes(), renders[1].Bytes()) || !bytes.Equal(renders[0].Bytes(), renders[2].Bytes()) {
+				t.Errorf("%s (width %d) of an HD skin differs from the 64x64 skin", name, width)
+			}
+		}
+	}
+}
+
+// BenchmarkRender processes and encodes each resource (at the default width)
+func BenchmarkRender(b *testing.B) {
+	mcSkin, err := getMcSkin()
+	if err != nil {
+		b.Fatalf("Unable to get mcSkin: %s", err)
+	}
+
+	for name, renderer := range renderers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				skin := &mcskin.McSkin{Skin: mcSkin.Skin, Type: mcskin.ImageTypePNG, Width: mcskin.DefaultWidth}
+				renderer(skin)()
+				skin.WritePNG(io.Discard)
+				skin.Release()
+			}
+		})
+	}
+}
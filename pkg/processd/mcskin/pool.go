@@ -0,0 +1,68 @@
+package mcskin
+
+import (
+	"image"
+	"image/png"
+	"math/bits"
+	"sync"
+)
+
+// Each render needs an image for every crop, resize and skew (and the PNG encoder its zlib state)
+// These are pooled between renders, rather than allocated for each one
+
+// nrgbaPools are by size class, each holding images with a Pix capacity of 1<<class
+var nrgbaPools [32]sync.Pool
+
+// pngEncoder reuses its buffers between encodes
+var pngEncoder = &png.Encoder{BufferPool: &pngBufferPool{}}
+
+type pngBufferPool struct {
+	pool sync.Pool
+}
+
+func (p *pngBufferPool) Get() *png.EncoderBuffer {
+	buffer, _ := p.pool.Get().(*png.EncoderBuffer)
+	return buffer
+}
+
+func (p *pngBufferPool) Put(buffer *png.EncoderBuffer) {
+	p.pool.Put(buffer)
+}
+
+func sizeClass(size int) int {
+	if size <= 1 {
+		return 0
+	}
+	return bits.Len(uint(size - 1))
+}
+
+// newNRGBA returns a transparent image from the pool, which is kept until the Release
+func (skin *McSkin) newNRGBA(r image.Rectangle) *image.NRGBA {
+	size := r.Dx() * r.Dy() * 4
+	class := sizeClass(size)
+
+	img, ok := nrgbaPools[class].Get().(*image.NRGBA)
+	if ok {
+		img.Pix = img.Pix[:size]
+		for i := range img.Pix {
+			img.Pix[i] = 0
+		}
+	} else {
+		img = &image.NRGBA{Pix: make([]uint8, size, 1<<class)}
+	}
+	img.Stride = r.Dx() * 4
+	img.Rect = r
+
+	skin.pooled = append(skin.pooled, img)
+	return img
+}
+
+// Release returns the images of the render to the pool
+// The Processed image is one of them, so it is unset (and must not be used after)
+func (skin *McSkin) Release() {
+	for _, img := range skin.pooled {
+		nrgbaPools[sizeClass(cap(img.Pix))].Put(img)
+	}
+	skin.pooled = nil
+	skin.Processed = nil
+}
@@ -0,0 +1,118 @@
+package mcskin
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	svg "github.com/ajstarks/svgo"
+)
+
+// svgRect is a rectangle of pixels of the same colour (an index of the palette)
+type svgRect struct {
+	x, y, w, h int
+	colour     int
+}
+
+// svgRun is the key of a rectangle which can still grow downwards (a run of the same x, width and colour)
+type svgRun struct {
+	x, w, colour int
+}
+
+// svgRects merges the (visible) pixels into rectangles, returning them with the palette of colours
+// Each row is split into runs of the same colour, and a run matching one of the row above extends that rectangle
+func svgRects(img *image.NRGBA) (rects []svgRect, palette []uint32) {
+	colours := make(map[uint32]int)
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	// colourAt returns the palette index of the pixel, or -1 when it's transparent
+	colourAt := func(x, y int) int {
+		ptr := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+		if img.Pix[ptr+3] == 0 {
+			return -1
+		}
+		rgba := uint32(img.Pix[ptr])<<24 | uint32(img.Pix[ptr+1])<<16 | uint32(img.Pix[ptr+2])<<8 | uint32(img.Pix[ptr+3])
+		colour, ok := colours[rgba]
+		if !ok {
+			colour = len(palette)
+			colours[rgba] = colour
+			palette = append(palette, rgba)
+		}
+		return colour
+	}
+
+	open := make(map[svgRun]int)
+	for y := 0; y < height; y++ {
+		stillOpen := make(map[svgRun]int)
+		for x := 0; x < width; {
+			colour := colourAt(x, y)
+			runEnd := x + 1
+			for runEnd < width && colourAt(runEnd, y) == colour {
+				runEnd++
+			}
+
+			if colour >= 0 {
+				run := svgRun{x: x, w: runEnd - x, colour: colour}
+				if i, ok := open[run]; ok {
+					rects[i].h++
+					stillOpen[run] = i
+				} else {
+					rects = append(rects, svgRect{x: x, y: y, w: run.w, h: 1, colour: colour})
+					stillOpen[run] = len(rects) - 1
+				}
+			}
+			x = runEnd
+		}
+		open = stillOpen
+	}
+	return rects, palette
+}
+
+// svgFill is the CSS of the colour, with a fill-opacity when it's not opaque
+func svgFill(rgba uint32) string {
+	r, g, b, a := rgba>>24, rgba>>16&0xFF, rgba>>8&0xFF, rgba&0xFF
+	fill := fmt.Sprintf("fill:#%02x%02x%02x", r, g, b)
+	// Shorten to #rgb when each pair repeats
+	if fill[6] == fill[7] && fill[8] == fill[9] && fill[10] == fill[11] {
+		fill = "fill:#" + string([]byte{fill[6], fill[8], fill[10]})
+	}
+	if a != 0xFF {
+		opacity := strconv.FormatFloat(math.Round(float64(a)/255*1000)/1000, 'f', -1, 64)
+		fill += ";fill-opacity:" + strings.TrimPrefix(opacity, "0")
+	}
+	return fill
+}
+
+// Writes the processed image as an svg.
+// Pixels are merged into rectangles, drawn as one path per colour (with an inline fill, as
+// classes of a <style> would apply to every SVG inlined in the same document),
+// and the image is scaled to the Width with the viewBox (as the Processed image is not resized).
+func (skin *McSkin) WriteSVG(w io.Writer) error {
+	img := skin.Processed.(*image.NRGBA)
+	imgWidth, imgHeight := img.Rect.Dx(), img.Rect.Dy()
+	rects, palette := svgRects(img)
+
+	// The height keeps the aspect ratio (as with the PNG resize)
+	width := skin.Width
+	if width <= 0 {
+		width = imgWidth
+	}
+	height := int(math.Max(1.0, math.Floor(float64(width)*float64(imgHeight)/float64(imgWidth)+0.5)))
+
+	canvas := svg.New(w)
+	canvas.Start(width, height, fmt.Sprintf(`viewBox="0 0 %d %d"`, imgWidth, imgHeight), `shape-rendering="crispEdges"`)
+
+	paths := make([]strings.Builder, len(palette))
+	for _, rect := range rects {
+		fmt.Fprintf(&paths[rect.colour], "M%d %dh%dv%dh-%dz", rect.x, rect.y, rect.w, rect.h, rect.w)
+	}
+	for colour := range paths {
+		canvas.Path(paths[colour].String(), svgFill(palette[colour]))
+	}
+
+	canvas.End()
+	return nil
+}
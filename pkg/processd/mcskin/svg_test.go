@@ -0,0 +1,117 @@
+package mcskin
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/minotar/imgd/pkg/minecraft"
+)
+
+type testSVG struct {
+	Width   string   `xml:"width,attr"`
+	Height  string   `xml:"height,attr"`
+	ViewBox string   `xml:"viewBox,attr"`
+	Styles  []string `xml:"style"`
+	Paths   []struct {
+		D     string `xml:"d,attr"`
+		Style string `xml:"style,attr"`
+	} `xml:"path"`
+}
+
+// rasterize draws the paths of the SVG, returning the fill of each pixel
+func (s testSVG) rasterize(t *testing.T, width, height int) [][]string {
+	pixels := make([][]string, height)
+	for y := range pixels {
+		pixels[y] = make([]string, width)
+	}
+	for _, path := range s.Paths {
+		for _, rect := range strings.Split(strings.TrimSuffix(path.D, "z"), "z") {
+			var x, y, w, h, w2 int
+			if _, err := fmt.Sscanf(rect, "M%d %dh%dv%dh-%d", &x, &y, &w, &h, &w2); err != nil || w != w2 {
+				t.Fatalf("Unexpected path %s: %v", rect, err)
+			}
+			for py := y; py < y+h; py++ {
+				for px := x; px < x+w; px++ {
+					if pixels[py][px] != "" {
+						t.Fatalf("Pixel %d,%d is drawn twice", px, py)
+					}
+					pixels[py][px] = path.Style
+				}
+			}
+		}
+	}
+	return pixels
+}
+
+func TestWriteSVG(t *testing.T) {
+	steve, err := minecraft.SteveSkin()
+	if err != nil {
+		t.Fatalf("Unable to get Steve: %s", err)
+	}
+
+	renders := map[string]func(*McSkin) error{
+		"Helm":      (*McSkin).GetHelm,
+		"Cube":      (*McSkin).GetCube,
+		"ArmorBust": (*McSkin).GetArmorBust,
+		"ArmorBody": (*McSkin).GetArmorBody,
+	}
+	for name, render := range renders {
+		skin := &McSkin{Skin: steve, Type: ImageTypeSVG, Width: 64}
+		render(skin)
+		var buf bytes.Buffer
+		skin.WriteSVG(&buf)
+
+		var parsed testSVG
+		if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+			t.Fatalf("%s is not a valid SVG: %s", name, err)
+		}
+		img := skin.Processed.(*image.NRGBA)
+		width, height := img.Rect.Dx(), img.Rect.Dy()
+		if parsed.Width != "64" || parsed.ViewBox != fmt.Sprintf("0 0 %d %d", width, height) {
+			t.Errorf("%s should be scaled to the width 64 with the viewBox, not: %s (%s)", name, parsed.Width, parsed.ViewBox)
+		}
+		// A <style> would also apply to any other SVG inlined in the same document
+		if len(parsed.Styles) != 0 {
+			t.Errorf("%s should not have a <style>: %v", name, parsed.Styles)
+		}
+
+		// Every visible pixel is drawn once in its colour, and no other pixels are drawn
+		pixels := parsed.rasterize(t, width, height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				ptr := img.PixOffset(x, y)
+				expected := ""
+				if img.Pix[ptr+3] != 0 {
+					rgba := uint32(img.Pix[ptr])<<24 | uint32(img.Pix[ptr+1])<<16 | uint32(img.Pix[ptr+2])<<8 | uint32(img.Pix[ptr+3])
+					expected = svgFill(rgba)
+				}
+				if pixels[y][x] != expected {
+					t.Fatalf("%s pixel %d,%d expected \"%s\", not: \"%s\"", name, x, y, expected, pixels[y][x])
+				}
+			}
+		}
+		skin.Release()
+	}
+}
+
+func TestSVGFill(t *testing.T) {
+	testCases := []struct {
+		rgba uint32
+		fill string
+	}{
+		{0x112233FF, "fill:#123"},
+		{0x102030FF, "fill:#102030"},
+		{0xFF000080, "fill:#f00;fill-opacity:.502"},
+		{0x00000001, "fill:#000;fill-opacity:.004"},
+	}
+	for _, tc := range testCases {
+		if fill := svgFill(tc.rgba); fill != tc.fill {
+			t.Errorf("Expected %08x to be %s, not: %s", tc.rgba, tc.fill, fill)
+		}
+	}
+
+}
@@ -18,4 +18,47 @@ var (
 			Help:      "Type of processd User requested.",
 		}, []string{"type"},
 	)
+
+	skindEjections = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "processd",
+			Name:      "skind_ejections_total",
+			Help:      "Times a skind instance was ejected (failed lookups or /ready checks).",
+		},
+	)
+	skindRetries = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "processd",
+			Name:      "skind_retries_total",
+			Help:      "Skin lookups retried on the next skind instance.",
+		},
+	)
+	skindResponses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "processd",
+			Name:      "skind_responses_total",
+			Help:      "skind responses (including each retry) by class.",
+		}, []string{"class"},
+	)
+
+	renderQueueDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "processd",
+			Name:      "render_queue_seconds",
+			Help:      "Time (in seconds) each render waited for the render limit.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+	)
+	renderShed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "processd",
+			Name:      "render_shed_total",
+			Help:      "Renders shed by the render limit (with a 503) by reason.",
+		}, []string{"reason"},
+	)
 )
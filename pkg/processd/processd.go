@@ -1,21 +1,36 @@
 package processd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
 	"github.com/minotar/imgd/pkg/minecraft"
 	"github.com/minotar/imgd/pkg/processd/mcskin"
 	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/limiter"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/reload"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"github.com/minotar/imgd/pkg/util/tracing"
 
 	"github.com/weaveworks/common/server"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -31,20 +46,55 @@ var (
 	}
 
 	UUIDRegex = regexp.MustCompile(minecraft.ValidUUIDPlainRegex)
+
+	tracer = tracing.Tracer("processd")
+
+	// reloadableSettings can be changed without a restart (see Reload)
+	reloadableSettings = []string{
+		"processd.cors-allow-all",
+		"processd.use-etags",
+		"processd.redirect-username",
+		"processd.cache-control-ttl",
+		"processd.error-cache-control-ttl",
+		"processd.skind-url",
+		"processd.skinds.urls",
+		"processd.upstream-timeout",
+		"log.level",
+	}
 )
 
 type Config struct {
-	Server          server.Config `yaml:"server,omitempty"`
-	UpstreamTimeout time.Duration `yaml:"upstream_timeout"`
-	SkindURL        string        `yaml:"skind_url,omitempty"`
-	Logger          log.Logger
-	// Add open CORS headers to easch response
+	Server          server.Config
+	UpstreamTimeout time.Duration
+	SkindURL        string
+	SkindReadyURL   string
+	// Skins are looked up with the skind gRPC API (rather than the SkindURL) when set
+	SkindGRPCAddress string
+	Logger           log.Logger
+	// Add open CORS headers to each response
 	CorsAllowAll bool
 	// Return an ETag based on the texture ID
 	UseETags bool
 	// Return a 302 redirect for Username requests to their related UUID
 	RedirectUsername bool
 	CacheControlTTL  time.Duration
+	// Cache TTL returned with Steve when the lookup fails
+	ErrorCacheControlTTL time.Duration
+	Tracing              tracing.Config
+	Skinds               SkindsConfig
+	TextureCache         TextureCacheConfig
+	RenderLimit          RenderLimitConfig
+}
+
+// HandlerSettings are the response settings (which a reload can change)
+func (c *Config) HandlerSettings() route_helpers.HandlerSettings {
+	return route_helpers.HandlerSettings{
+		CorsAllowAll:         c.CorsAllowAll,
+		UseETags:             c.UseETags,
+		RedirectUsername:     c.RedirectUsername,
+		CacheControlTTL:      c.CacheControlTTL,
+		ErrorCacheControlTTL: c.ErrorCacheControlTTL,
+	}
 }
 
 // RegisterFlags registers flag.
@@ -53,22 +103,110 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 
 	f.DurationVar(&c.UpstreamTimeout, "processd.upstream-timeout", 15*time.Second, "Timeout for Skin lookup")
 	f.StringVar(&c.SkindURL, "processd.skind-url", "http://localhost:4643/skin/", "API for skin lookups")
+	f.StringVar(&c.SkindGRPCAddress, "processd.skind-grpc-address", "", "gRPC address of skind, used for skin lookups instead of the skind-url when set")
+	f.StringVar(&c.SkindReadyURL, "processd.skind-ready-url", "", "Readiness URL of skind (defaults to /ready on each skind host, ready when any is)")
 	f.BoolVar(&c.CorsAllowAll, "processd.cors-allow-all", true, "Permissive CORS policy")
 	f.BoolVar(&c.UseETags, "processd.use-etags", true, "Use etags to skip re-processing")
 	f.BoolVar(&c.RedirectUsername, "processd.redirect-username", true, "Redirect username requests to the UUID variant")
 	f.DurationVar(&c.CacheControlTTL, "processd.cache-control-ttl", time.Duration(6)*time.Hour, "Cache TTL returned to clients")
+	f.DurationVar(&c.ErrorCacheControlTTL, "processd.error-cache-control-ttl", time.Minute, "Cache TTL returned to clients when the skin lookup fails")
 
+	c.Skinds.RegisterFlags(f)
+	c.TextureCache.RegisterFlags(f)
+	c.RenderLimit.RegisterFlags(f)
+	c.Tracing.RegisterFlags(f)
 	c.Server.RegisterFlags(f)
 }
 
+func (c *Config) Validate() error {
+	for name, skindURL := range map[string]string{
+		"processd.skind-url":       c.SkindURL,
+		"processd.skind-ready-url": c.SkindReadyURL,
+	} {
+		if u, err := url.Parse(skindURL); skindURL != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+			return fmt.Errorf("%s \"%s\" should be an absolute URL", name, skindURL)
+		}
+	}
+	if c.SkindURL == "" {
+		return errors.New("processd.skind-url is required")
+	}
+	if c.SkindGRPCAddress != "" {
+		if _, _, err := net.SplitHostPort(c.SkindGRPCAddress); err != nil {
+			return fmt.Errorf("processd.skind-grpc-address \"%s\" should be a host:port", c.SkindGRPCAddress)
+		}
+		// The gRPC lookups use the single address (not the ring, retries or ejection of the skinds)
+		if c.Skinds.URLs != "" || c.Skinds.SRV != "" {
+			return errors.New("processd.skind-grpc-address cannot be used with processd.skinds.urls or processd.skinds.srv")
+		}
+	}
+	if c.UpstreamTimeout < 0 {
+		return errors.New("processd.upstream-timeout should not be negative")
+	}
+	if c.CacheControlTTL < 0 {
+		return errors.New("processd.cache-control-ttl should not be negative")
+	}
+	if c.ErrorCacheControlTTL < 0 {
+		return errors.New("processd.error-cache-control-ttl should not be negative")
+	}
+	if err := c.Skinds.Validate(); err != nil {
+		return err
+	}
+	if err := c.TextureCache.Validate(); err != nil {
+		return err
+	}
+	if err := c.RenderLimit.Validate(); err != nil {
+		return err
+	}
+	return c.Tracing.Validate()
+}
+
 type Processd struct {
 	Cfg Config
 
-	Server        *server.Server
-	Client        *http.Client
-	UserAgent     string
-	SkindURL      string
+	Server *server.Server
+	Client *http.Client
+	// Flushes any buffered trace spans
+	TracingShutdown func(context.Context) error
+	UserAgent       string
+	// The skind instances of the skin lookups
+	Skinds *SkindPool
+	// Checked for the readiness (rather than each of the Skinds) when set
+	SkindReadyURL string
+	// Recent skins, to skip (or shorten) repeated lookups (nil when disabled)
+	TextureCache cache.Cache
+	// Limits the renders at once (nil when disabled)
+	Renders *limiter.Weighted
+	// Set when the skind-grpc-address is used for the skin lookups
+	SkindClient   skindpb.SkindClient
+	skindConn     *grpc.ClientConn
 	ProcessRoutes map[string]skind.SkinProcessor
+	Settings      *route_helpers.LiveSettings
+	// The skind lookup swapped in by Reload (replacing Client)
+	reloaded atomic.Value
+	// Re-reads the config (with the names of the changed settings), which is required to Reload
+	ConfigLoader func() (Config, []string, error)
+	Reloader     *reload.Reloader
+}
+
+// skindLookup is the client used for each skin lookup
+type skindLookup struct {
+	client *http.Client
+}
+
+// textures wraps the TextureCache (nil when disabled)
+func (p *Processd) textures() *textureCache {
+	if p.TextureCache == nil {
+		return nil
+	}
+	return &textureCache{cfg: p.Cfg.TextureCache, cache: p.TextureCache}
+}
+
+// lookup returns the reloaded skind lookup, or otherwise the one Processd was created with
+func (p *Processd) lookup() *skindLookup {
+	if l, ok := p.reloaded.Load().(*skindLookup); ok {
+		return l
+	}
+	return &skindLookup{client: p.Client}
 }
 
 func New(cfg Config) (*Processd, error) {
@@ -77,33 +215,128 @@ func New(cfg Config) (*Processd, error) {
 	// Set the GRPC to localhost only
 	cfg.Server.GRPCListenAddress = "127.0.0.3"
 
+	tracingShutdown, err := tracing.Init(cfg.Tracing, "processd", cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := log.SetZapLevel(cfg.Server.LogLevel.String()); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout: cfg.UpstreamTimeout,
+		// The trace context is propagated to skind
+		Transport: tracing.Transport("processd", http.DefaultTransport),
+	}
+	skinds, err := NewSkindPool(cfg.Skinds, cfg.Logger, client, cfg.SkindURL)
+	if err != nil {
+		return nil, err
+	}
+	textureCache, err := NewTextureCache(cfg.TextureCache, cfg.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("texture cache: %w", err)
+	}
+	if textureCache != nil {
+		textureCache.Start()
+	}
+
 	processd := &Processd{
-		Cfg: cfg,
-		Client: &http.Client{
-			Timeout:   cfg.UpstreamTimeout,
-			Transport: http.DefaultTransport,
-		},
-		UserAgent:     "minotar/imgd/processd (https://github.com/minotar/imgd) - default",
-		SkindURL:      cfg.SkindURL,
-		ProcessRoutes: DefaultProcessRoutes,
+		Cfg:             cfg,
+		Client:          client,
+		TracingShutdown: tracingShutdown,
+		UserAgent:       "minotar/imgd/processd (https://github.com/minotar/imgd) - default",
+		Skinds:          skinds,
+		TextureCache:    textureCache,
+		Renders:         NewRenderLimiter(cfg.RenderLimit),
+		SkindReadyURL:   cfg.SkindReadyURL,
+		ProcessRoutes:   DefaultProcessRoutes,
+		Settings:        route_helpers.NewLiveSettings(cfg.HandlerSettings()),
+	}
+	processd.Reloader = reload.New(cfg.Logger, processd.applyReload, reloadableSettings)
+
+	if cfg.SkindGRPCAddress != "" {
+		// The connection is made in the background (and re-made as needed)
+		conn, err := grpc.Dial(cfg.SkindGRPCAddress,
+			grpc.WithInsecure(),
+			grpc.WithUserAgent(processd.UserAgent),
+			grpc.WithChainUnaryInterceptor(
+				tracing.UnaryClientInterceptor("processd"),
+				route_helpers.RequestIDInterceptor,
+			),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("skind gRPC dial: %w", err)
+		}
+		processd.skindConn = conn
+		processd.SkindClient = skindpb.NewSkindClient(conn)
 	}
 
 	return processd, nil
 }
 
+// readyURL is the /ready endpoint on the host of the skind URL
+func readyURL(skindURL string) (string, error) {
+	u, err := url.Parse(skindURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid skind URL: %w", err)
+	}
+	u.Path = "/ready"
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
 // need some skin lookup wrapper
 
-func handleSkinLookupError(w http.ResponseWriter, r *http.Request, logger log.Logger, processFunc skind.SkinProcessor) {
+// decodeSkin runs the processFunc (which decodes the texture) within a span
+func decodeSkin(r *http.Request, logger log.Logger, processFunc skind.SkinProcessor, skinIO mcuser.TextureIO) http.HandlerFunc {
+	_, span := tracer.Start(r.Context(), "Texture decode")
+	defer span.End()
+	defer access_log.FromContext(r.Context()).AddTimingSince("decode", time.Now())
+	return processFunc(logger, skinIO)
+}
+
+func (p *Processd) handleSkinLookupError(w http.ResponseWriter, r *http.Request, logger log.Logger, processFunc skind.SkinProcessor) {
 	skinIO := mcuser.GetSteveTextureIO()
+	access_log.FromContext(r.Context()).SetFallback("skind_error")
 
-	handler := processFunc(logger, skinIO)
-	handler.ServeHTTP(w, r)
+	p.render(w, r, logger, processFunc, skinIO)
 }
 
-func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.HandlerFunc {
-	logger := p.Cfg.Logger
+// redirectToUUID redirects the username request to the UUID, which is false when the route has no resource
+func redirectToUUID(w http.ResponseWriter, r *http.Request, settings route_helpers.HandlerSettings, uuid string) bool {
+	resource, ok := mux.Vars(r)["resource"]
+	if !ok {
+		return false
+	}
+	w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(settings.CacheControlTTL.Seconds())))
+	http.Redirect(w, r, "/"+resource+"/"+uuid, http.StatusFound)
+	return true
+}
+
+// serveCachedSkin processes a skin from the texture cache
+func (p *Processd) serveCachedSkin(w http.ResponseWriter, r *http.Request, logger log.Logger, settings route_helpers.HandlerSettings, cached *cachedUser, processFunc skind.SkinProcessor) {
+	w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(settings.CacheControlTTL.Seconds())))
+	if settings.UseETags {
+		w.Header().Set("ETag", cached.textureID)
+		if r.Header.Get("If-None-Match") == cached.textureID {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	skinIO := mcuser.TextureIO{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(cached.skin)),
+		TextureID:  cached.textureID,
+	}
+	p.render(w, r, logger, processFunc, skinIO)
+}
 
+func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		logger := access_log.Logger(r.Context(), p.Cfg.Logger)
+		settings := p.Settings.Load()
+		lookup := p.lookup()
 
 		userReq := route_helpers.MuxToUserReq(r)
 		var userLookup string
@@ -114,48 +347,58 @@ func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.Handl
 			userLookup = userReq.Username
 		} else {
 			logger.Errorf("Request came through without Username/UUID: %v", mux.Vars(r))
-			handleSkinLookupError(w, r, logger, processFunc)
+			p.handleSkinLookupError(w, r, logger, processFunc)
 			return
 		}
 
-		skinReq, err := http.NewRequestWithContext(r.Context(), "GET", fmt.Sprint(p.SkindURL, userLookup), nil)
-		if err != nil {
-			//return nil, fmt.Errorf("unable to create request: %v", err)
-			//Use Steve and call original process logic?
-			logger.Errorf("Failed to create HTTP Req: %v", err)
-			handleSkinLookupError(w, r, logger, processFunc)
+		textures := p.textures()
+		usernameRedirect := settings.RedirectUsername && userReq.UUID == ""
+		// A recent redirect or skin can be served without asking skind
+		var cached *cachedUser
+		if usernameRedirect {
+			if uuid, ok := textures.redirect(userReq.Username); ok && redirectToUUID(w, r, settings, uuid) {
+				access_log.FromContext(r.Context()).SetCache("processd", textureCacheRedirect)
+				return
+			}
+		} else if cached = textures.user(userLookup); cached != nil && cached.fresh(p.Cfg.TextureCache.FreshTTL) {
+			access_log.FromContext(r.Context()).SetCache("processd", textureCacheFresh)
+			p.serveCachedSkin(w, r, logger, settings, cached, processFunc)
+			return
+		}
+
+		if p.SkindClient != nil {
+			p.grpcSkinLookup(w, r, logger, settings, lookup.client.Timeout, userReq, userLookup, cached, processFunc)
 			return
 		}
 
 		reqETag := r.Header.Get("If-None-Match")
 
-		skinReq.Header.Set("User-Agent", p.UserAgent)
-		if p.Cfg.UseETags && reqETag != "" {
-			skinReq.Header.Set("If-None-Match", reqETag)
+		header := make(http.Header)
+		header.Set("User-Agent", p.UserAgent)
+		if cached != nil {
+			// skind confirms (with a 304) whether the cached skin is still current
+			header.Set("If-None-Match", cached.textureID)
+		} else if settings.UseETags && reqETag != "" {
+			header.Set("If-None-Match", reqETag)
 		}
-		//req.Header.Set("X-Request-ID", "magic-to-use-existing-or-add-new")
-
-		var resp *http.Response
-		if p.Cfg.RedirectUsername && userReq.UUID == "" {
-			// It's a username request, so we can listen for a redirect
-			resp, err = p.Client.Transport.RoundTrip(skinReq)
-		} else {
-			resp, err = p.Client.Do(skinReq)
+		// Forward the request ID so the skind logs can be correlated
+		if requestID := access_log.RequestID(r.Context()); requestID != "" {
+			header.Set(access_log.RequestIDHeader, requestID)
 		}
+
+		lookupStart := time.Now()
+		// When it's a username request, we can listen for a redirect
+		resp, err := p.requestSkin(r.Context(), logger, lookup, userLookup, header, usernameRedirect)
+		// Time to the skind response headers (the texture body is read when decoding)
+		access_log.FromContext(r.Context()).AddTimingSince("skind", lookupStart)
 		if err != nil {
-			//return nil, fmt.Errorf("unable to GET URL: %v", err)
-			//Use Steve and call original process logic?
-			logger.Errorf("GET failed: %v", err)
-			handleSkinLookupError(w, r, logger, processFunc)
+			p.handleSkindError(w, r, logger, settings, processFunc, asSkindError(r.Context(), err))
 			return
 		}
 		// The processFunc *MUST* close the resp.Body via the TextureIO object
 		//defer resp.Body.Close()
 
-		// Todo: Could we grab the cachecontrol from response and use that for basis of the TTL here?
-		w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(p.Cfg.CacheControlTTL.Seconds())))
-
-		if p.Cfg.RedirectUsername && userReq.UUID == "" {
+		if usernameRedirect {
 			redirect, err := resp.Location()
 			if err != nil {
 				logger.Debug("skin server response did not have a Location header, will process as normal")
@@ -164,9 +407,9 @@ func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.Handl
 				if uuid == "" {
 					logger.Warnf("UUID not found in %s", redirect.Path)
 				} else {
-					if resource, ok := mux.Vars(r)["resource"]; ok {
-						redirectPath := "/" + resource + "/" + uuid
-						http.Redirect(w, r, redirectPath, http.StatusFound)
+					if redirectToUUID(w, r, settings, uuid) {
+						resp.Body.Close()
+						textures.insertRedirect(userReq.Username, uuid)
 						return
 					}
 					logger.Warnf("Unable to decode resource for redirect: %s", redirect.Path)
@@ -174,10 +417,31 @@ func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.Handl
 			}
 		}
 
-		// Todo: Add handling for non-200/304 response codes, add handing for skind errors (API error vs. non-username)
+		respETag := resp.Header.Get("ETag")
+		if cached != nil && resp.StatusCode == http.StatusNotModified && respETag == cached.textureID {
+			resp.Body.Close()
+			textures.confirm(userLookup, cached.textureID)
+			access_log.FromContext(r.Context()).SetCache("processd", textureCacheRevalidated)
+			p.serveCachedSkin(w, r, logger, settings, cached, processFunc)
+			return
+		}
+		if textures != nil && resp.StatusCode == http.StatusOK && respETag != "" {
+			// The skin is read now, so that it can be cached
+			skin, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				p.handleSkindError(w, r, logger, settings, processFunc, &skindError{class: classifyError(r.Context(), err), err: err})
+				return
+			}
+			textures.insert(userLookup, respETag, skin)
+			access_log.FromContext(r.Context()).SetCache("processd", textureCacheMiss)
+			resp.Body = ioutil.NopCloser(bytes.NewReader(skin))
+		}
+
+		// Todo: Could we grab the cachecontrol from response and use that for basis of the TTL here?
+		w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(settings.CacheControlTTL.Seconds())))
 
-		if p.Cfg.UseETags {
-			respETag := resp.Header.Get("ETag")
+		if settings.UseETags {
 			if respETag != "" {
 				// ETag is always included (even for 304 responses)
 				w.Header().Set("ETag", respETag)
@@ -193,12 +457,80 @@ func (p *Processd) SkinLookupWrapper(processFunc skind.SkinProcessor) http.Handl
 
 		skinIO := mcuser.TextureIO{
 			ReadCloser: resp.Body,
+			TextureID:  respETag,
 		}
 
 		// Up to this point, the processing could be metric'd "generically" and the type of processing was irrelevant
-		handler := processFunc(logger, skinIO)
-		handler.ServeHTTP(w, r)
+		p.render(w, r, logger, processFunc, skinIO)
+	}
+}
+
+// requestSkin requests the skin from the skind owning the user, retrying (with a backoff) on the next skinds of the ring
+// Only a usable response is returned, otherwise the error is a *skindError with the class of the last attempt
+func (p *Processd) requestSkin(ctx context.Context, logger log.Logger, lookup *skindLookup, userLookup string, header http.Header, noRedirect bool) (*http.Response, error) {
+	backends := p.Skinds.Pick(strings.ToLower(userLookup))
+	if len(backends) == 0 {
+		return nil, &skindError{class: classError, err: errNoSkinds}
+	}
+
+	var sErr *skindError
+	for attempt := 0; attempt <= p.Skinds.cfg.Retries; attempt++ {
+		// With fewer skinds than attempts, they are tried again in turn
+		backend := backends[attempt%len(backends)]
+		if attempt > 0 {
+			if err := backoff(ctx, p.Skinds.cfg.RetryBackoff, attempt); err != nil {
+				return nil, &skindError{class: classAbandoned, err: err}
+			}
+			skindRetries.Inc()
+			logger.Warnf("Retrying skin lookup on %s: %v", backend.url, sErr)
+		}
+
+		skinReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprint(backend.url, userLookup), nil)
+		if err != nil {
+			return nil, &skindError{class: classError, err: fmt.Errorf("unable to create request: %w", err)}
+		}
+		skinReq.Header = header.Clone()
+
+		var resp *http.Response
+		if noRedirect {
+			resp, err = lookup.client.Transport.RoundTrip(skinReq)
+		} else {
+			resp, err = lookup.client.Do(skinReq)
+		}
+
+		if err != nil {
+			sErr = &skindError{class: classifyError(ctx, err), err: err}
+		} else {
+			class := classifyResponse(resp)
+			switch class {
+			case classOK, classNotModified, classRedirect:
+				skindResponses.WithLabelValues(class).Inc()
+				p.Skinds.Succeeded(backend)
+				return resp, nil
+			}
+			resp.Body.Close()
+			sErr = &skindError{
+				class:      class,
+				retryAfter: resp.Header.Get("Retry-After"),
+				err:        fmt.Errorf("%s returned %s", backend.url, resp.Status),
+			}
+			if fallback := resp.Header.Get(skind.FallbackHeader); fallback != "" {
+				sErr.err = fmt.Errorf("%s fell back to Steve: %s", backend.url, fallback)
+			}
+		}
+		skindResponses.WithLabelValues(sErr.class).Inc()
+
+		if skindFailure(sErr.class) {
+			p.Skinds.Failed(backend)
+		} else if sErr.class != classAbandoned {
+			// It responded (eg. an unknown user)
+			p.Skinds.Succeeded(backend)
+		}
+		if !retryable(sErr.class) {
+			break
+		}
 	}
+	return nil, sErr
 }
 
 func (p *Processd) Run() error {
@@ -206,6 +538,11 @@ func (p *Processd) Run() error {
 	if err := p.initServer(); err != nil {
 		return err
 	}
+	// Once the server stops (eg. SIGTERM), drain the requests
+	defer p.Shutdown()
+	p.Skinds.Start()
+	stopWatch := p.Reloader.Watch()
+	defer stopWatch()
 	// init other bits
 
 	return p.Server.Run()
@@ -213,17 +550,62 @@ func (p *Processd) Run() error {
 	//return nil
 }
 
+// Shutdown stops accepting requests and waits for the in-flight ones (up to the server.graceful-shutdown-timeout)
+func (p *Processd) Shutdown() {
+	p.Cfg.Logger.Info("Shutting down processd")
+	p.Server.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.Cfg.Server.ServerGracefulShutdownTimeout)
+	defer cancel()
+	p.Skinds.Stop()
+	if p.TextureCache != nil {
+		p.TextureCache.Stop()
+	}
+	if p.skindConn != nil {
+		p.skindConn.Close()
+	}
+	p.TracingShutdown(ctx)
+}
+
+// applyReload swaps in the response settings, the skind URLs and timeout, and the log level
+// The SRV discovered skinds are kept (the SRV settings need a restart)
+func (p *Processd) applyReload() ([]string, error) {
+	if p.ConfigLoader == nil {
+		return nil, errors.New("no config loader to reload from")
+	}
+	cfg, changed, err := p.ConfigLoader()
+	if err != nil {
+		return nil, err
+	}
+	// Nothing is applied until everything is valid (SetURLs only swaps the skinds when all of the URLs are)
+	level, err := log.ParseZapLevel(cfg.Server.LogLevel.String())
+	if err != nil {
+		return nil, err
+	}
+	if p.Cfg.Skinds.SRV == "" {
+		if err := p.Skinds.SetURLs(cfg.Skinds.staticURLs(cfg.SkindURL)); err != nil {
+			return nil, err
+		}
+	}
+	log.ZapLevel.SetLevel(level)
+	p.Settings.Store(cfg.HandlerSettings())
+	p.reloaded.Store(&skindLookup{
+		// The traced Transport (and its connections) are kept
+		client: &http.Client{Timeout: cfg.UpstreamTimeout, Transport: p.Client.Transport},
+	})
+	return changed, nil
+}
+
 func (p *Processd) initServer() error {
 	serv, err := server.New(p.Cfg.Server)
 	if err != nil {
 		return err
 	}
 
-	//serv.HTTP.Use(route_helpers.LoggingMiddleware(p.Cfg.Logger))
+	serv.HTTP.Use(route_helpers.LoggingMiddleware(p.Cfg.Logger))
+	serv.HTTP.Use(tracing.Middleware("processd"))
 
-	if p.Cfg.CorsAllowAll {
-		serv.HTTP.Use(route_helpers.CorsHandler)
-	}
+	serv.HTTP.Use(p.Settings.CorsMiddleware)
 
 	p.Server = serv
 	p.routes()
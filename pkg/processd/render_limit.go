@@ -0,0 +1,129 @@
+package processd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/processd/mcskin"
+	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/limiter"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+// Reasons a render was shed (also the metric label)
+const (
+	shedQueueFull = "queue_full"
+	shedTimeout   = "timeout"
+	shedAbandoned = "abandoned"
+)
+
+// RenderLimitConfig bounds the (CPU heavy) decoding and processing of skins
+// Each render has a weight (based on the width and resource), and the total weight rendering at once is limited
+type RenderLimitConfig struct {
+	// Total weight rendering at once (0 disables the limit)
+	Capacity int
+	// Renders waiting beyond this are shed straight away
+	MaxQueue int
+	// Renders waiting longer than this are shed
+	MaxWait time.Duration
+	// Retry-After sent with a shed render
+	RetryAfter time.Duration
+}
+
+func (c *RenderLimitConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&c.Capacity, "processd.render-limit.capacity", runtime.NumCPU()*8, "Total weight of renders at once, a default width Avatar is 4 (0 disables the limit)")
+	f.IntVar(&c.MaxQueue, "processd.render-limit.max-queue", 100, "Renders waiting for capacity, beyond which they are shed")
+	f.DurationVar(&c.MaxWait, "processd.render-limit.max-wait", 2*time.Second, "How long a render waits for capacity before being shed")
+	f.DurationVar(&c.RetryAfter, "processd.render-limit.retry-after", time.Second, "Retry-After of a shed render")
+}
+
+func (c *RenderLimitConfig) Validate() error {
+	if c.Capacity < 0 || c.MaxQueue < 0 || c.MaxWait < 0 || c.RetryAfter < 0 {
+		return errors.New("processd.render-limit options should not be negative")
+	}
+	return nil
+}
+
+// NewRenderLimiter creates the limiter, or nil when the limit is disabled
+func NewRenderLimiter(cfg RenderLimitConfig) *limiter.Weighted {
+	if cfg.Capacity == 0 {
+		return nil
+	}
+	return limiter.NewWeighted(int64(cfg.Capacity), cfg.MaxQueue)
+}
+
+// renderWeight is roughly 1 per 100x100 of output, doubled for the Body/Bust and tripled for the (rotated) Cube
+func renderWeight(r *http.Request) int64 {
+	width, _ := mcskin.GetWidthType(r)
+	weight := int64(width*width/10000) + 1
+
+	resource := strings.ToLower(mux.Vars(r)["resource"])
+	switch {
+	case strings.HasPrefix(resource, "cube"):
+		weight *= 3
+	case strings.HasSuffix(resource, "body"), strings.HasSuffix(resource, "bust"):
+		weight *= 2
+	}
+	return weight
+}
+
+// render decodes and processes the skin, within the render limit
+func (p *Processd) render(w http.ResponseWriter, r *http.Request, logger log.Logger, processFunc skind.SkinProcessor, skinIO mcuser.TextureIO) {
+	if p.Renders != nil {
+		weight := renderWeight(r)
+		if err := p.acquireRender(r, weight); err != nil {
+			skinIO.Close()
+			p.shedRender(w, r, logger, err)
+			return
+		}
+		defer p.Renders.Release(weight)
+	}
+
+	handler := decodeSkin(r, logger, processFunc, skinIO)
+	handler.ServeHTTP(w, r)
+}
+
+// acquireRender waits (up to the MaxWait) for the weight to be available
+func (p *Processd) acquireRender(r *http.Request, weight int64) error {
+	ctx := r.Context()
+	if p.Cfg.RenderLimit.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Cfg.RenderLimit.MaxWait)
+		defer cancel()
+	}
+
+	queueStart := time.Now()
+	err := p.Renders.Acquire(ctx, weight)
+	renderQueueDuration.Observe(time.Since(queueStart).Seconds())
+	access_log.FromContext(r.Context()).AddTimingSince("render_queue", queueStart)
+	return err
+}
+
+// shedRender returns a 503 (which should not be cached), asking the client to retry
+func (p *Processd) shedRender(w http.ResponseWriter, r *http.Request, logger log.Logger, err error) {
+	reason := shedTimeout
+	if errors.Is(err, limiter.ErrQueueFull) {
+		reason = shedQueueFull
+	} else if r.Context().Err() != nil {
+		reason = shedAbandoned
+	}
+	renderShed.WithLabelValues(reason).Inc()
+	logger.Debugf("Shedding render: %v", err)
+
+	w.Header().Del("ETag")
+	w.Header().Set("Cache-Control", "no-store")
+	if retryAfter := p.Cfg.RenderLimit.RetryAfter; retryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	http.Error(w, "Too many renders in progress", http.StatusServiceUnavailable)
+}
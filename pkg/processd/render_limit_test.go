@@ -0,0 +1,80 @@
+package processd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/util/limiter"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func TestRenderWeight(t *testing.T) {
+	testCases := []struct {
+		resource string
+		width    string
+		weight   int64
+	}{
+		{"avatar", "", 4},
+		{"avatar", "8", 1},
+		{"body", "100", 4},
+		{"armour/bust", "100", 4},
+		{"cube", "300", 30},
+		{"cubehelm", "180", 12},
+	}
+
+	for _, tc := range testCases {
+		vars := map[string]string{"resource": tc.resource}
+		if tc.width != "" {
+			vars["width"] = tc.width
+		}
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), vars)
+		if weight := renderWeight(req); weight != tc.weight {
+			t.Errorf("Expected %s/%s to weigh %d, not: %d", tc.resource, tc.width, tc.weight, weight)
+		}
+	}
+}
+
+func TestRenderShed(t *testing.T) {
+	p := &Processd{
+		Cfg:     Config{RenderLimit: RenderLimitConfig{MaxWait: 10 * time.Millisecond, RetryAfter: 1500 * time.Millisecond}},
+		Renders: limiter.NewWeighted(4, 1),
+	}
+	logger := log.NewBuiltinLogger(1)
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/avatar/test", nil), map[string]string{"resource": "avatar"})
+
+	// The first render fits, and the capacity is released afterwards
+	rec := httptest.NewRecorder()
+	p.render(rec, req, logger, textureIDProcessor, mcuser.GetSteveTextureIO())
+	if rec.Code != http.StatusOK || p.Renders.InUse() != 0 {
+		t.Fatalf("Expected the render to succeed (and release), not: %d (%d in use)", rec.Code, p.Renders.InUse())
+	}
+
+	// With the capacity taken, the render waits for the MaxWait (and then a full queue is shed straight away)
+	p.Renders.Acquire(context.Background(), 4)
+	for _, full := range []bool{false, true} {
+		if full {
+			go p.Renders.Acquire(context.Background(), 1)
+			for p.Renders.Queued() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		rec := httptest.NewRecorder()
+		rec.Header().Set("ETag", "texture1")
+		p.render(rec, req, logger, textureIDProcessor, mcuser.GetSteveTextureIO())
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected a shed render to be a 503, not: %d", rec.Code)
+		}
+		if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "2" {
+			t.Errorf("Expected Retry-After 2, not: %s", retryAfter)
+		}
+		if cacheControl, eTag := rec.Header().Get("Cache-Control"), rec.Header().Get("ETag"); cacheControl != "no-store" || eTag != "" {
+			t.Errorf("A shed render should not be cached, not: %s (ETag %s)", cacheControl, eTag)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/felixge/fgprof"
 	"github.com/gorilla/mux"
 	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/util/health"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -18,10 +19,23 @@ func (p *Processd) routes() {
 	p.Server.HTTP.Path("/healthcheck").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
 	})
+	p.Server.HTTP.Path("/live").Handler(health.LiveHandler())
+	p.Server.HTTP.Path("/ready").Handler(health.ReadyHandler(p.ReadyChecker()))
 
 	RegisterProcessingRoutes(p.Server.HTTP, p.SkinLookupWrapper, p.ProcessRoutes)
 }
 
+// ReadyChecker requires a skind to be ready, as every request needs a skin lookup
+func (p *Processd) ReadyChecker() *health.Checker {
+	checker := &health.Checker{}
+	if p.SkindReadyURL != "" {
+		checker.Add("skind", true, health.HTTPCheck(p.Client, p.SkindReadyURL))
+	} else {
+		checker.Add("skind", true, p.Skinds.ReadyCheck())
+	}
+	return checker
+}
+
 func RegisterProcessingRoutes(m *mux.Router, skinWrapper skind.SkinWrapper, processRoutes map[string]skind.SkinProcessor) {
 	uuidCounter := requestedUserType.MustCurryWith(prometheus.Labels{"type": "UUID"})
 	dashedCounter := requestedUserType.MustCurryWith(prometheus.Labels{"type": "DashedUUID"})
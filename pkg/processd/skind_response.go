@@ -0,0 +1,254 @@
+package processd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Classes of the skind responses (also the metric label)
+const (
+	classOK          = "ok"
+	classNotModified = "not_modified"
+	classRedirect    = "redirect"
+	classUnknownUser = "unknown_user"
+	classRateLimited = "rate_limited"
+	classUnavailable = "unavailable"
+	classServerError = "server_error"
+	classClientError = "client_error"
+	// A 200 which is not an image, eg. an HTML error page from a proxy
+	classInvalid = "invalid"
+	// The skin texture was invalid (or could not be fetched) by the skind, which another attempt would not change
+	classTexture   = "texture_error"
+	classTimeout   = "timeout"
+	classError     = "error"
+	classAbandoned = "abandoned"
+)
+
+// classifyResponse names the class of the skind response
+func classifyResponse(resp *http.Response) string {
+	// skind serves Steve when its lookup fails, naming why in the header
+	if fallback := resp.Header.Get(skind.FallbackHeader); fallback != "" {
+		return classifyFallback(fallback)
+	}
+	switch code := resp.StatusCode; {
+	case code == http.StatusOK:
+		if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "image/") {
+			return classInvalid
+		}
+		return classOK
+	case code == http.StatusNotModified:
+		return classNotModified
+	case code >= 300 && code < 400:
+		return classRedirect
+	case code == http.StatusNotFound:
+		return classUnknownUser
+	case code == http.StatusTooManyRequests:
+		return classRateLimited
+	case code == http.StatusServiceUnavailable:
+		return classUnavailable
+	case code >= 500:
+		return classServerError
+	default:
+		return classClientError
+	}
+}
+
+// classifyFallback names the class of a skind fallback (the reasons of mcclient.FallbackReason)
+func classifyFallback(reason string) string {
+	switch reason {
+	case "unknown_user":
+		return classUnknownUser
+	case "rate_limit":
+		return classRateLimited
+	case "lookup_error":
+		// The Mojang API was unavailable to the skind
+		return classUnavailable
+	case "texture_invalid", "texture_error":
+		return classTexture
+	default:
+		return classServerError
+	}
+}
+
+// classifyError names the class of a failed skind request
+func classifyError(ctx context.Context, err error) string {
+	var netErr net.Error
+	switch {
+	case ctx.Err() != nil:
+		// The client went away (which is not the fault of the skind)
+		return classAbandoned
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return classTimeout
+	default:
+		return classError
+	}
+}
+
+// classifyGRPCError names the class of a failed skind gRPC request
+func classifyGRPCError(ctx context.Context, err error) string {
+	switch grpcstatus.Code(err) {
+	case codes.NotFound:
+		return classUnknownUser
+	case codes.ResourceExhausted:
+		return classRateLimited
+	case codes.Unavailable:
+		return classUnavailable
+	case codes.DeadlineExceeded:
+		return classTimeout
+	case codes.InvalidArgument:
+		return classClientError
+	case codes.Canceled:
+		return classifyError(ctx, err)
+	default:
+		return classServerError
+	}
+}
+
+// classifyGRPCStatus names the class of a skind gRPC skin, which is a Steve fallback when the Status is not OK
+func classifyGRPCStatus(resp *skindpb.SkinResponse) string {
+	switch resp.GetStatus() {
+	case skindpb.Status_OK:
+		if resp.GetNotModified() {
+			return classNotModified
+		}
+		return classOK
+	case skindpb.Status_ERROR_UNKNOWN_USER:
+		return classUnknownUser
+	case skindpb.Status_ERROR_RATE_LIMIT:
+		return classRateLimited
+	case skindpb.Status_ERROR_INVALID_TEXTURE:
+		return classTexture
+	default:
+		return classServerError
+	}
+}
+
+// retryable classes may succeed on another attempt (or another skind)
+func retryable(class string) bool {
+	switch class {
+	case classRateLimited, classUnavailable, classServerError, classInvalid, classTimeout, classError:
+		return true
+	}
+	return false
+}
+
+// skindFailure classes count towards the ejection of the skind
+// A rate limit is from Mojang, so it is not the fault of the skind
+func skindFailure(class string) bool {
+	return retryable(class) && class != classRateLimited
+}
+
+// skindError is a skin lookup which did not return a usable response
+type skindError struct {
+	class string
+	// Retry-After of the skind response (if any)
+	retryAfter string
+	err        error
+}
+
+func (e *skindError) Error() string {
+	return fmt.Sprintf("skind lookup %s: %v", e.class, e.err)
+}
+
+func (e *skindError) Unwrap() error {
+	return e.err
+}
+
+// status is returned to the client (alongside the Steve skin)
+func (e *skindError) status() int {
+	switch e.class {
+	case classUnknownUser:
+		return http.StatusNotFound
+	case classRateLimited, classUnavailable, classAbandoned:
+		return http.StatusServiceUnavailable
+	case classTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// asSkindError keeps the class of a skindError, otherwise classifying the error
+func asSkindError(ctx context.Context, err error) *skindError {
+	var sErr *skindError
+	if errors.As(err, &sErr) {
+		return sErr
+	}
+	return &skindError{class: classifyError(ctx, err), err: err}
+}
+
+// backoff before the retry attempt (from 1), doubling each attempt with a +/-50% jitter
+func backoff(ctx context.Context, base time.Duration, attempt int) error {
+	if base <= 0 {
+		return ctx.Err()
+	}
+	delay := base << (attempt - 1)
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay)))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// statusWriter replaces the (implicit) 200 of the processFunc with the status
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	if code == http.StatusOK {
+		code = sw.status
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// handleSkindError renders Steve with the status of the failed lookup, and only a short Cache-Control
+func (p *Processd) handleSkindError(w http.ResponseWriter, r *http.Request, logger log.Logger, settings route_helpers.HandlerSettings, processFunc skind.SkinProcessor, sErr *skindError) {
+	if sErr.class == classUnknownUser {
+		logger.Debugf("Falling back to Steve: %v", sErr)
+	} else {
+		logger.Errorf("Falling back to Steve: %v", sErr)
+	}
+	access_log.FromContext(r.Context()).SetFallback("skind_" + sErr.class)
+
+	w.Header().Del("ETag")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(settings.ErrorCacheControlTTL.Seconds())))
+	if sErr.retryAfter != "" {
+		w.Header().Set("Retry-After", sErr.retryAfter)
+	}
+
+	p.render(&statusWriter{ResponseWriter: w, status: sErr.status()}, r, logger, processFunc, mcuser.GetSteveTextureIO())
+}
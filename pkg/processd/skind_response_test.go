@@ -0,0 +1,166 @@
+package processd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/skind"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"google.golang.org/grpc"
+)
+
+// textureIDProcessor writes the TextureID (the ETag of the skind response, or Steve)
+func textureIDProcessor(logger log.Logger, skinIO mcuser.TextureIO) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		skinIO.Close()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(skinIO.TextureID))
+	}
+}
+
+// skindResponse is written by the test skind for each attempt
+type skindResponse struct {
+	status      int
+	contentType string
+	retryAfter  string
+	fallback    string
+}
+
+func TestSkinLookupResponses(t *testing.T) {
+	png := skindResponse{http.StatusOK, "image/png", "", ""}
+
+	testCases := []struct {
+		name       string
+		responses  []skindResponse
+		status     int
+		maxAge     string
+		retryAfter string
+		steve      bool
+	}{
+		{"OK", []skindResponse{png}, http.StatusOK, "max-age=21600", "", false},
+		{"UnknownUser", []skindResponse{{http.StatusNotFound, "text/plain", "", ""}}, http.StatusNotFound, "max-age=60", "", true},
+		{"RateLimited", []skindResponse{{http.StatusTooManyRequests, "text/plain", "30", ""}, {http.StatusTooManyRequests, "text/plain", "30", ""}}, http.StatusServiceUnavailable, "max-age=60", "30", true},
+		{"ServerError", []skindResponse{{http.StatusInternalServerError, "text/html", "", ""}, {http.StatusBadGateway, "text/html", "", ""}}, http.StatusBadGateway, "max-age=60", "", true},
+		{"HTMLPage", []skindResponse{{http.StatusOK, "text/html", "", ""}, {http.StatusOK, "text/html", "", ""}}, http.StatusBadGateway, "max-age=60", "", true},
+		{"FallbackUnknownUser", []skindResponse{{http.StatusOK, "image/png", "", "unknown_user"}}, http.StatusNotFound, "max-age=60", "", true},
+		{"FallbackInvalidTexture", []skindResponse{{http.StatusOK, "image/png", "", "texture_invalid"}}, http.StatusBadGateway, "max-age=60", "", true},
+		{"FallbackTextureError", []skindResponse{{http.StatusOK, "image/png", "", "texture_error"}}, http.StatusBadGateway, "max-age=60", "", true},
+		{"FallbackRetrySuccess", []skindResponse{{http.StatusOK, "image/png", "", "lookup_error"}, png}, http.StatusOK, "max-age=21600", "", false},
+		{"RetrySuccess", []skindResponse{{http.StatusServiceUnavailable, "text/plain", "", ""}, png}, http.StatusOK, "max-age=21600", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			skindServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := tc.responses[attempts]
+				attempts++
+				w.Header().Set("Content-Type", resp.contentType)
+				if resp.retryAfter != "" {
+					w.Header().Set("Retry-After", resp.retryAfter)
+				}
+				if resp.fallback != "" {
+					w.Header().Set(skind.FallbackHeader, resp.fallback)
+				}
+				w.WriteHeader(resp.status)
+			}))
+			defer skindServer.Close()
+
+			cfg := Config{Logger: log.NewBuiltinLogger(1)}
+			skinds, err := NewSkindPool(SkindsConfig{Retries: 1, EjectFailures: 10}, cfg.Logger, http.DefaultClient, skindServer.URL+"/skin/")
+			if err != nil {
+				t.Fatalf("Error creating pool: %s", err)
+			}
+			p := &Processd{
+				Cfg:      cfg,
+				Client:   http.DefaultClient,
+				Skinds:   skinds,
+				Settings: route_helpers.NewLiveSettings(route_helpers.HandlerSettings{UseETags: true, CacheControlTTL: 6 * time.Hour, ErrorCacheControlTTL: time.Minute}),
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/avatar/"+minecraft.SteveUUID, nil), map[string]string{"uuid": minecraft.SteveUUID})
+			rec := httptest.NewRecorder()
+			p.SkinLookupWrapper(textureIDProcessor)(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("Expected status %d, not: %d", tc.status, rec.Code)
+			}
+			if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != "public, "+tc.maxAge {
+				t.Errorf("Expected Cache-Control %s, not: %s", tc.maxAge, cacheControl)
+			}
+			if retryAfter := rec.Header().Get("Retry-After"); retryAfter != tc.retryAfter {
+				t.Errorf("Expected Retry-After \"%s\", not: \"%s\"", tc.retryAfter, retryAfter)
+			}
+			body, _ := ioutil.ReadAll(rec.Body)
+			if steve := string(body) == minecraft.SteveHash; steve != tc.steve {
+				t.Errorf("Expected Steve to be %t, not: %t", tc.steve, steve)
+			}
+			if attempts != len(tc.responses) {
+				t.Errorf("Expected %d attempts, not: %d", len(tc.responses), attempts)
+			}
+		})
+	}
+}
+
+// testSkindClient answers GetSkin with the SkinResponse (Steve when the Status is not OK)
+type testSkindClient struct {
+	skindpb.SkindClient
+	resp *skindpb.SkinResponse
+}
+
+func (c *testSkindClient) GetSkin(ctx context.Context, in *skindpb.UserRequest, opts ...grpc.CallOption) (*skindpb.SkinResponse, error) {
+	return c.resp, nil
+}
+
+func TestGRPCSkinLookupStatus(t *testing.T) {
+	testCases := []struct {
+		status     skindpb.Status
+		httpStatus int
+		maxAge     string
+	}{
+		{skindpb.Status_OK, http.StatusOK, "max-age=21600"},
+		{skindpb.Status_ERROR_UNKNOWN_USER, http.StatusNotFound, "max-age=60"},
+		{skindpb.Status_ERROR_RATE_LIMIT, http.StatusServiceUnavailable, "max-age=60"},
+		{skindpb.Status_ERROR_GENERIC, http.StatusBadGateway, "max-age=60"},
+		{skindpb.Status_ERROR_INVALID_TEXTURE, http.StatusBadGateway, "max-age=60"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.status.String(), func(t *testing.T) {
+			resp := &skindpb.SkinResponse{Skin: []byte("skin"), TextureId: "texture1", Status: tc.status}
+			if tc.status != skindpb.Status_OK {
+				resp.TextureId = minecraft.SteveHash
+			}
+			p := &Processd{
+				Cfg:         Config{Logger: log.NewBuiltinLogger(1)},
+				Client:      http.DefaultClient,
+				SkindClient: &testSkindClient{resp: resp},
+				Settings:    route_helpers.NewLiveSettings(route_helpers.HandlerSettings{UseETags: true, CacheControlTTL: 6 * time.Hour, ErrorCacheControlTTL: time.Minute}),
+			}
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/avatar/"+testTextureUUID, nil), map[string]string{"uuid": testTextureUUID})
+			rec := httptest.NewRecorder()
+			p.SkinLookupWrapper(textureIDProcessor)(rec, req)
+
+			if rec.Code != tc.httpStatus {
+				t.Errorf("Expected status %d, not: %d", tc.httpStatus, rec.Code)
+			}
+			if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != "public, "+tc.maxAge {
+				t.Errorf("Expected Cache-Control %s, not: %s", tc.maxAge, cacheControl)
+			}
+			// A fallback has no ETag (it is not the skin of the user)
+			if eTag := rec.Header().Get("ETag"); tc.status != skindpb.Status_OK && eTag != "" {
+				t.Errorf("Expected no ETag for a fallback, not: %s", eTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package processd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minotar/imgd/pkg/util/hashring"
+	"github.com/minotar/imgd/pkg/util/health"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+var errNoSkinds = errors.New("no skind backends available")
+
+// SkindsConfig spreads the skin lookups over several skind instances
+// Each username/UUID is routed to one instance by consistent hashing, so each skind caches a separate share of the users
+type SkindsConfig struct {
+	// Comma separated skind URLs (replacing the SkindURL)
+	URLs string
+	// DNS SRV name of the skind instances, eg. "_http._tcp.skind.imgd.svc.cluster.local"
+	SRV        string
+	SRVRefresh time.Duration
+	// Further attempts (on the next skind instances of the ring) when a lookup fails
+	Retries      int
+	RetryBackoff time.Duration
+	// Consecutive failed lookups before an instance is skipped, for the EjectDuration
+	EjectFailures int
+	EjectDuration time.Duration
+	// Instances failing their /ready check are also skipped (0 disables the checks)
+	HealthInterval time.Duration
+}
+
+func (c *SkindsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.URLs, "processd.skinds.urls", "", "Comma separated skind URLs to shard the lookups over (replaces the skind-url)")
+	f.StringVar(&c.SRV, "processd.skinds.srv", "", "DNS SRV name to discover the skind instances (with the scheme and path of the skind-url)")
+	f.DurationVar(&c.SRVRefresh, "processd.skinds.srv-refresh", 30*time.Second, "Interval to re-resolve the skinds.srv")
+	f.IntVar(&c.Retries, "processd.skinds.retries", 1, "Retries of a failed lookup on the next skind of the ring")
+	f.DurationVar(&c.RetryBackoff, "processd.skinds.retry-backoff", 50*time.Millisecond, "Backoff before the first retry (doubling each retry)")
+	f.IntVar(&c.EjectFailures, "processd.skinds.eject-failures", 3, "Consecutive failed lookups before a skind is ejected")
+	f.DurationVar(&c.EjectDuration, "processd.skinds.eject-duration", 30*time.Second, "Time an ejected skind is skipped")
+	f.DurationVar(&c.HealthInterval, "processd.skinds.health-interval", 10*time.Second, "Interval to check /ready of each skind (0 disables)")
+}
+
+func (c *SkindsConfig) Validate() error {
+	if c.URLs != "" && c.SRV != "" {
+		return errors.New("only one of processd.skinds.urls or processd.skinds.srv should be set")
+	}
+	for _, skindURL := range c.staticURLs("") {
+		if u, err := url.Parse(skindURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("processd.skinds.urls \"%s\" should be an absolute URL", skindURL)
+		}
+	}
+	if c.SRV != "" && c.SRVRefresh <= 0 {
+		return errors.New("processd.skinds.srv-refresh should be positive")
+	}
+	if c.Retries < 0 {
+		return errors.New("processd.skinds.retries should not be negative")
+	}
+	if c.EjectFailures < 1 {
+		return errors.New("processd.skinds.eject-failures should be at least 1")
+	}
+	if c.RetryBackoff < 0 || c.EjectDuration < 0 || c.HealthInterval < 0 {
+		return errors.New("processd.skinds durations should not be negative")
+	}
+	return nil
+}
+
+// staticURLs are the skinds.urls, or otherwise just the skindURL
+func (c *SkindsConfig) staticURLs(skindURL string) []string {
+	var urls []string
+	for _, u := range strings.Split(c.URLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 && skindURL != "" {
+		urls = append(urls, skindURL)
+	}
+	return urls
+}
+
+// skindBackend is a skind instance of the SkindPool
+type skindBackend struct {
+	url      string
+	readyURL string
+	// Consecutive failed lookups
+	failures int32
+	// UnixNano until which the instance is skipped
+	ejectedUntil int64
+}
+
+func (b *skindBackend) ejected(now time.Time) bool {
+	return atomic.LoadInt64(&b.ejectedUntil) > now.UnixNano()
+}
+
+func (b *skindBackend) eject(d time.Duration) {
+	atomic.StoreInt64(&b.ejectedUntil, time.Now().Add(d).UnixNano())
+	atomic.StoreInt32(&b.failures, 0)
+	skindEjections.Inc()
+}
+
+func (b *skindBackend) restore() {
+	atomic.StoreInt64(&b.ejectedUntil, 0)
+	atomic.StoreInt32(&b.failures, 0)
+}
+
+// SkindPool routes each skin lookup to a skind instance (via a hashring.Ring)
+type SkindPool struct {
+	cfg    SkindsConfig
+	logger log.Logger
+	// Used for the /ready checks
+	client *http.Client
+	// Scheme and path of the skind-url, used for the SRV targets
+	template *url.URL
+
+	mu       sync.RWMutex
+	ring     *hashring.Ring
+	backends map[string]*skindBackend
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSkindPool creates the pool from the static URLs, or otherwise resolves the SRV name
+func NewSkindPool(cfg SkindsConfig, logger log.Logger, client *http.Client, skindURL string) (*SkindPool, error) {
+	template, err := url.Parse(skindURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid skind URL: %w", err)
+	}
+	sp := &SkindPool{
+		cfg:      cfg,
+		logger:   logger.With("component", "skinds"),
+		client:   client,
+		template: template,
+		ring:     hashring.New(nil, 0),
+		stop:     make(chan struct{}),
+	}
+
+	if cfg.SRV == "" {
+		if err := sp.SetURLs(cfg.staticURLs(skindURL)); err != nil {
+			return nil, err
+		}
+	} else if err := sp.resolve(); err != nil {
+		// The refresh will retry (lookups use Steve until then)
+		sp.logger.Errorf("Unable to resolve %s: %v", cfg.SRV, err)
+	}
+	return sp, nil
+}
+
+// SetURLs changes the skind instances (an instance which is kept also keeps its ejection)
+func (sp *SkindPool) SetURLs(urls []string) error {
+	backends := make(map[string]*skindBackend, len(urls))
+	for _, u := range urls {
+		ready, err := readyURL(u)
+		if err != nil {
+			return err
+		}
+		backends[u] = &skindBackend{url: u, readyURL: ready}
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	for u := range backends {
+		if existing, ok := sp.backends[u]; ok {
+			backends[u] = existing
+		}
+	}
+	if sp.ring.Len() > 0 && !sameNodes(sp.ring.Nodes(), urls) {
+		sp.logger.Infof("skind instances changed to: %s", strings.Join(urls, ", "))
+	}
+	sp.backends = backends
+	sp.ring = hashring.New(urls, 0)
+	return nil
+}
+
+func sameNodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Pick returns the instances to try for the key (the owner first, then the next of the ring)
+// Ejected instances are skipped, unless there are not enough others
+func (sp *SkindPool) Pick(key string) []*skindBackend {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	want := 1 + sp.cfg.Retries
+	now := time.Now()
+	var picked, ejected []*skindBackend
+	for _, u := range sp.ring.Lookup(key, sp.ring.Len()) {
+		backend := sp.backends[u]
+		if backend.ejected(now) {
+			ejected = append(ejected, backend)
+			continue
+		}
+		if picked = append(picked, backend); len(picked) == want {
+			return picked
+		}
+	}
+	for _, backend := range ejected {
+		if len(picked) == want {
+			break
+		}
+		picked = append(picked, backend)
+	}
+	return picked
+}
+
+// Succeeded resets the failures of the instance
+func (sp *SkindPool) Succeeded(b *skindBackend) {
+	atomic.StoreInt32(&b.failures, 0)
+}
+
+// Failed records a failed lookup, ejecting the instance after the EjectFailures in a row
+func (sp *SkindPool) Failed(b *skindBackend) {
+	if atomic.AddInt32(&b.failures, 1) >= int32(sp.cfg.EjectFailures) {
+		sp.logger.Warnf("Ejecting skind %s for %s after %d failed lookups", b.url, sp.cfg.EjectDuration, sp.cfg.EjectFailures)
+		b.eject(sp.cfg.EjectDuration)
+	}
+}
+
+// resolve updates the instances from the SRV records
+func (sp *SkindPool) resolve() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", sp.cfg.SRV)
+	if err != nil {
+		return err
+	}
+
+	urls := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		u := *sp.template
+		u.Host = net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port)))
+		urls = append(urls, u.String())
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("%s has no targets", sp.cfg.SRV)
+	}
+	return sp.SetURLs(urls)
+}
+
+// backendList is a snapshot of the instances
+func (sp *SkindPool) backendList() []*skindBackend {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+	backends := make([]*skindBackend, 0, len(sp.backends))
+	for _, backend := range sp.backends {
+		backends = append(backends, backend)
+	}
+	return backends
+}
+
+// checkHealth ejects the instances failing their /ready check, and restores those passing
+func (sp *SkindPool) checkHealth() {
+	for _, backend := range sp.backendList() {
+		ctx, cancel := context.WithTimeout(context.Background(), sp.cfg.HealthInterval)
+		err := health.HTTPCheck(sp.client, backend.readyURL)(ctx)
+		cancel()
+
+		wasEjected := backend.ejected(time.Now())
+		switch {
+		case err != nil && !wasEjected:
+			sp.logger.Warnf("Ejecting skind %s for %s: %v", backend.url, sp.cfg.EjectDuration, err)
+			backend.eject(sp.cfg.EjectDuration)
+		case err != nil:
+			// Keep it ejected until it passes again
+			backend.eject(sp.cfg.EjectDuration)
+		case wasEjected:
+			sp.logger.Infof("Restoring skind %s", backend.url)
+			backend.restore()
+		}
+	}
+}
+
+// ReadyCheck passes when any of the instances is ready
+func (sp *SkindPool) ReadyCheck() health.CheckFunc {
+	return func(ctx context.Context) error {
+		backends := sp.backendList()
+		if len(backends) == 0 {
+			return errNoSkinds
+		}
+		var errs []string
+		for _, backend := range backends {
+			err := health.HTTPCheck(sp.client, backend.readyURL)(ctx)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return errors.New(strings.Join(errs, "; "))
+	}
+}
+
+// every calls fn each interval, until Stop
+func (sp *SkindPool) every(interval time.Duration, fn func()) {
+	sp.wg.Add(1)
+	go func() {
+		defer sp.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-sp.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Start the SRV refresh and health checks (when enabled)
+func (sp *SkindPool) Start() {
+	if sp.cfg.SRV != "" {
+		sp.every(sp.cfg.SRVRefresh, func() {
+			if err := sp.resolve(); err != nil {
+				sp.logger.Errorf("Unable to resolve %s (keeping the current instances): %v", sp.cfg.SRV, err)
+			}
+		})
+	}
+	if sp.cfg.HealthInterval > 0 {
+		sp.every(sp.cfg.HealthInterval, sp.checkHealth)
+	}
+}
+
+// Stop the SRV refresh and health checks
+func (sp *SkindPool) Stop() {
+	close(sp.stop)
+	sp.wg.Wait()
+}
@@ -0,0 +1,82 @@
+package processd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func newTestPool(t *testing.T, urls ...string) *SkindPool {
+	cfg := SkindsConfig{Retries: 1, EjectFailures: 2, EjectDuration: time.Minute}
+	pool, err := NewSkindPool(cfg, log.NewBuiltinLogger(1), http.DefaultClient, urls[0])
+	if err != nil {
+		t.Fatalf("Error creating pool: %s", err)
+	}
+	if err := pool.SetURLs(urls); err != nil {
+		t.Fatalf("Error setting URLs: %s", err)
+	}
+	return pool
+}
+
+func TestSkindPoolEjection(t *testing.T) {
+	pool := newTestPool(t, "http://skind-0/skin/", "http://skind-1/skin/", "http://skind-2/skin/")
+
+	picked := pool.Pick("clone1018")
+	if len(picked) != 2 || picked[0] == picked[1] {
+		t.Fatalf("Pick should return the owner and 1 retry, not: %v", picked)
+	}
+	owner := picked[0]
+
+	pool.Failed(owner)
+	if pool.Pick("clone1018")[0] != owner {
+		t.Errorf("The owner should only be ejected after 2 failures")
+	}
+	pool.Failed(owner)
+	if next := pool.Pick("clone1018"); next[0] != picked[1] || next[1] == owner {
+		t.Errorf("The ejected owner should be skipped for the next of the ring, not: %s, %s", next[0].url, next[1].url)
+	}
+
+	// Ejection is kept through a change of the instances
+	pool.SetURLs([]string{owner.url, picked[1].url})
+	if next := pool.Pick("clone1018"); next[1] != owner {
+		t.Errorf("The ejected owner should only be used when there are not enough others")
+	}
+}
+
+func TestRequestSkinRetry(t *testing.T) {
+	var failedRequests int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedRequests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", "texture")
+	}))
+	defer working.Close()
+
+	p := &Processd{Skinds: newTestPool(t, failing.URL+"/skin/", working.URL+"/skin/")}
+	lookup := &skindLookup{client: http.DefaultClient}
+
+	// Usernames are spread over both, so some are owned by the failing skind
+	for i := 0; i < 100; i++ {
+		username := fmt.Sprintf("user%d", i)
+		resp, err := p.requestSkin(context.Background(), log.NewBuiltinLogger(1), lookup, username, http.Header{}, false)
+		if err != nil {
+			t.Fatalf("Lookup of %s should be retried on the working skind: %s", username, err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("ETag") != "texture" {
+			t.Errorf("Lookup of %s should be from the working skind", username)
+		}
+	}
+	// After 2 failures, the failing skind is ejected
+	if failedRequests != 2 {
+		t.Errorf("The failing skind should be ejected after 2 requests, not: %d", failedRequests)
+	}
+}
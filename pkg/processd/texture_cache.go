@@ -0,0 +1,153 @@
+package processd
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	cache_config "github.com/minotar/imgd/pkg/cache/util/config"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+// Outcomes of the texture cache (recorded in the access log as the "processd" cache)
+const (
+	textureCacheFresh       = "fresh"
+	textureCacheRevalidated = "revalidated"
+	textureCacheMiss        = "miss"
+	textureCacheRedirect    = "redirect"
+)
+
+// TextureCacheConfig keeps recent skins in processd, to skip (or shorten) the skind lookups of repeated renders
+type TextureCacheConfig struct {
+	// The backend of the cache, which is disabled with "none" (the default)
+	Cache *cache_config.Config
+	// How long skins (and the TextureID of each user) are kept
+	TTL time.Duration
+	// Within the FreshTTL, skind is not asked at all, after which the TextureID is confirmed with skind (a 304)
+	FreshTTL time.Duration
+	// How long a Username -> UUID redirect is kept
+	RedirectTTL time.Duration
+}
+
+func (c *TextureCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	c.Cache = &cache_config.Config{}
+	c.Cache.RegisterFlagsWithBackend(f, "ProcessdTextures", "none")
+	f.DurationVar(&c.TTL, "processd.texture-cache.ttl", time.Hour, "How long textures are kept in the local cache")
+	f.DurationVar(&c.FreshTTL, "processd.texture-cache.fresh-ttl", 30*time.Second, "How long a cached texture is used without asking skind")
+	f.DurationVar(&c.RedirectTTL, "processd.texture-cache.redirect-ttl", time.Minute, "How long a Username -> UUID redirect is cached")
+}
+
+func (c *TextureCacheConfig) Validate() error {
+	if c.Cache != nil {
+		if err := c.Cache.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.TTL <= 0 || c.FreshTTL < 0 || c.RedirectTTL < 0 {
+		return errors.New("processd.texture-cache.ttl should be positive (and the other TTLs not negative)")
+	}
+	if c.FreshTTL > c.TTL {
+		return errors.New("processd.texture-cache.fresh-ttl should not be longer than the ttl")
+	}
+	return nil
+}
+
+// NewTextureCache creates the texture cache of the backend, or nil when it's disabled
+func NewTextureCache(cfg TextureCacheConfig, logger log.Logger) (cache.Cache, error) {
+	if cfg.Cache == nil {
+		return nil, nil
+	}
+	cfg.Cache.Logger = logger
+	return cache_config.NewCache(cfg.Cache)
+}
+
+// textureCache stores the skins by TextureID, and the TextureID and redirect of each user
+// A nil textureCache is disabled (always missing)
+type textureCache struct {
+	cfg   TextureCacheConfig
+	cache cache.Cache
+}
+
+// cachedUser is the TextureID (and skin) of a user, and when skind last confirmed it
+type cachedUser struct {
+	textureID string
+	confirmed time.Time
+	skin      []byte
+}
+
+func (u cachedUser) fresh(freshTTL time.Duration) bool {
+	return time.Since(u.confirmed) < freshTTL
+}
+
+func textureKey(textureID string) string {
+	return "texture:" + textureID
+}
+
+func userKey(user string) string {
+	return "user:" + strings.ToLower(user)
+}
+
+func redirectKey(username string) string {
+	return "redirect:" + strings.ToLower(username)
+}
+
+// user returns the cached TextureID and skin of the user (nil unless both are still cached)
+func (tc *textureCache) user(user string) *cachedUser {
+	if tc == nil {
+		return nil
+	}
+	value, err := tc.cache.Retrieve(userKey(user))
+	if err != nil || len(value) <= 8 {
+		return nil
+	}
+	u := &cachedUser{
+		confirmed: time.Unix(0, int64(binary.BigEndian.Uint64(value[:8]))),
+		textureID: string(value[8:]),
+	}
+	if u.skin, err = tc.cache.Retrieve(textureKey(u.textureID)); err != nil {
+		return nil
+	}
+	return u
+}
+
+// confirm records that skind has (just) returned the TextureID for the user
+func (tc *textureCache) confirm(user, textureID string) {
+	if tc == nil || textureID == "" {
+		return
+	}
+	value := make([]byte, 8, 8+len(textureID))
+	binary.BigEndian.PutUint64(value, uint64(time.Now().UnixNano()))
+	value = append(value, textureID...)
+	tc.cache.InsertTTL(userKey(user), value, tc.cfg.TTL)
+}
+
+// insert the skin of the user
+func (tc *textureCache) insert(user, textureID string, skin []byte) {
+	if tc == nil || textureID == "" {
+		return
+	}
+	tc.cache.InsertTTL(textureKey(textureID), skin, tc.cfg.TTL)
+	tc.confirm(user, textureID)
+}
+
+// redirect returns the cached UUID of the username
+func (tc *textureCache) redirect(username string) (string, bool) {
+	if tc == nil || tc.cfg.RedirectTTL == 0 {
+		return "", false
+	}
+	uuid, err := tc.cache.Retrieve(redirectKey(username))
+	if err != nil {
+		return "", false
+	}
+	return string(uuid), true
+}
+
+func (tc *textureCache) insertRedirect(username, uuid string) {
+	if tc == nil || tc.cfg.RedirectTTL == 0 {
+		return
+	}
+	tc.cache.InsertTTL(redirectKey(username), []byte(uuid), tc.cfg.RedirectTTL)
+}
@@ -0,0 +1,139 @@
+package processd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	cache_config "github.com/minotar/imgd/pkg/cache/util/config"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+)
+
+const testTextureUUID = "d9135e082f2244c89cb0bee29da3e0d3"
+
+// newTextureCacheProcessd returns a Processd (with a texture cache) using the test skind
+func newTextureCacheProcessd(t *testing.T, skindURL string, freshTTL time.Duration) *Processd {
+	cfg := Config{
+		Logger:       log.NewBuiltinLogger(1),
+		TextureCache: TextureCacheConfig{Cache: &cache_config.Config{CacheType: "lru", LruCacheConfig: *lru_cache.NewLruCacheConfig(10, cache.CacheConfig{})}, TTL: time.Hour, FreshTTL: freshTTL, RedirectTTL: time.Minute},
+	}
+	skinds, err := NewSkindPool(SkindsConfig{EjectFailures: 10}, cfg.Logger, http.DefaultClient, skindURL+"/skin/")
+	if err != nil {
+		t.Fatalf("Error creating pool: %s", err)
+	}
+	textureCache, err := NewTextureCache(cfg.TextureCache, cfg.Logger)
+	if err != nil {
+		t.Fatalf("Error creating texture cache: %s", err)
+	}
+	return &Processd{
+		Cfg: cfg,
+		// The Transport is used directly for the username redirects
+		Client:       &http.Client{Transport: http.DefaultTransport},
+		Skinds:       skinds,
+		TextureCache: textureCache,
+		Settings:     route_helpers.NewLiveSettings(route_helpers.HandlerSettings{UseETags: true, RedirectUsername: true, CacheControlTTL: 6 * time.Hour, ErrorCacheControlTTL: time.Minute}),
+	}
+}
+
+func textureCacheRequest(p *Processd, vars map[string]string, eTag string) *httptest.ResponseRecorder {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/avatar/test", nil), vars)
+	if eTag != "" {
+		req.Header.Set("If-None-Match", eTag)
+	}
+	rec := httptest.NewRecorder()
+	p.SkinLookupWrapper(textureIDProcessor)(rec, req)
+	return rec
+}
+
+func TestTextureCacheRevalidation(t *testing.T) {
+	var requests, notModified int
+	skindServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", "texture1")
+		if r.Header.Get("If-None-Match") == "texture1" {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("skin"))
+	}))
+	defer skindServer.Close()
+
+	for _, freshTTL := range []time.Duration{time.Minute, 0} {
+		requests, notModified = 0, 0
+		p := newTextureCacheProcessd(t, skindServer.URL, freshTTL)
+		vars := map[string]string{"uuid": testTextureUUID}
+
+		for i := 0; i < 3; i++ {
+			rec := textureCacheRequest(p, vars, "")
+			// The cached skin is processed with its TextureID
+			if body, _ := ioutil.ReadAll(rec.Body); rec.Code != http.StatusOK || string(body) != "texture1" {
+				t.Errorf("Expected the skin to be processed, not: %d %s", rec.Code, body)
+			}
+			if eTag := rec.Header().Get("ETag"); eTag != "texture1" {
+				t.Errorf("Expected ETag texture1, not: %s", eTag)
+			}
+		}
+		if rec := textureCacheRequest(p, vars, "texture1"); rec.Code != http.StatusNotModified {
+			t.Errorf("Expected a cached skin to still return a 304, not: %d", rec.Code)
+		}
+
+		if freshTTL > 0 && requests != 1 {
+			t.Errorf("Fresh skins should not be requested from skind, expected 1 request, not: %d", requests)
+		}
+		if freshTTL == 0 && (requests != 4 || notModified != 3) {
+			t.Errorf("Stale skins should be confirmed with skind, expected 4 requests (3 x 304), not: %d (%d x 304)", requests, notModified)
+		}
+	}
+}
+
+func TestTextureCacheRedirect(t *testing.T) {
+	var requests int
+	skindServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, "/skin/"+testTextureUUID, http.StatusFound)
+	}))
+	defer skindServer.Close()
+
+	p := newTextureCacheProcessd(t, skindServer.URL, time.Minute)
+	vars := map[string]string{"username": "clone1018", "resource": "avatar"}
+
+	for i := 0; i < 2; i++ {
+		rec := textureCacheRequest(p, vars, "")
+		if location := rec.Header().Get("Location"); rec.Code != http.StatusFound || location != "/avatar/"+testTextureUUID {
+			t.Errorf("Expected a redirect to the UUID, not: %d %s", rec.Code, location)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("The redirect should be cached, expected 1 request, not: %d", requests)
+	}
+}
+
+func TestTextureCacheGRPCFallback(t *testing.T) {
+	p := newTextureCacheProcessd(t, "http://localhost", time.Minute)
+	client := &testSkindClient{resp: &skindpb.SkinResponse{Skin: []byte("steve"), TextureId: minecraft.SteveHash, Status: skindpb.Status_ERROR_RATE_LIMIT}}
+	p.SkindClient = client
+	vars := map[string]string{"uuid": testTextureUUID}
+
+	if rec := textureCacheRequest(p, vars, ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the fallback to be a 503, not: %d", rec.Code)
+	}
+	if cached := p.textures().user(testTextureUUID); cached != nil {
+		t.Errorf("The Steve fallback should not be cached, not: %s", cached.textureID)
+	}
+
+	client.resp = &skindpb.SkinResponse{Skin: []byte("skin"), TextureId: "texture1", Status: skindpb.Status_OK}
+	textureCacheRequest(p, vars, "")
+	if cached := p.textures().user(testTextureUUID); cached == nil || cached.textureID != "texture1" {
+		t.Errorf("The skin should be cached, not: %+v", cached)
+	}
+}
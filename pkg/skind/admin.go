@@ -0,0 +1,283 @@
+package skind
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/cache_inspector"
+	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+)
+
+type AdminConfig struct {
+	// Admin API is disabled when the ListenAddress is empty
+	ListenAddress string
+	// Bearer token required on every admin request
+	Token string
+}
+
+func (c *AdminConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ListenAddress, "skind.admin.listen-address", "", "Listen address for the admin API (disabled when empty)")
+	f.StringVar(&c.Token, "skind.admin.token", "", "Bearer token required by the admin API")
+}
+
+// String hides the Token, as the config is logged at startup
+func (c AdminConfig) String() string {
+	if c.Token != "" {
+		c.Token = "********"
+	}
+	// The plain type has no String method (so it does not recurse)
+	type plain AdminConfig
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+func (c *AdminConfig) Validate() error {
+	if c.ListenAddress != "" && c.Token == "" {
+		return errors.New("skind.admin.token is required when skind.admin.listen-address is set")
+	}
+	return nil
+}
+
+// AdminEntry is the JSON view of a cached value
+type AdminEntry struct {
+	Cache      string     `json:"cache"`
+	Key        string     `json:"key"`
+	TTLSeconds int64      `json:"ttl_seconds"`
+	Size       int        `json:"size"`
+	Status     string     `json:"status,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+	Detail     string     `json:"detail"`
+}
+
+func newAdminEntry(entry cache_inspector.Entry) AdminEntry {
+	adminEntry := AdminEntry{
+		Cache:      entry.Cache,
+		Key:        entry.Key,
+		TTLSeconds: int64(entry.TTL.Seconds()),
+		Size:       entry.Size,
+		Detail:     entry.Detail,
+	}
+	// Textures do not have a Status/Timestamp
+	if !entry.Timestamp.IsZero() {
+		adminEntry.Status = cache_inspector.StatusName(entry.Status)
+		adminEntry.Timestamp = &entry.Timestamp
+	}
+	return adminEntry
+}
+
+type adminResponse struct {
+	Entries []AdminEntry `json:"entries,omitempty"`
+	Purged  []string     `json:"purged,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+func writeAdminResponse(w http.ResponseWriter, code int, resp adminResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AdminAuthMiddleware rejects any request without the Bearer token
+func AdminAuthMiddleware(token string) mux.MiddlewareFunc {
+	expected := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(given, expected) != 1 {
+				adminRequests.WithLabelValues("unauthorized").Inc()
+				writeAdminResponse(w, http.StatusUnauthorized, adminResponse{Error: "unauthorized"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// adminUserReq converts a Username or (dashed) UUID into a UserReq
+func adminUserReq(user string) (mcclient.UserReq, error) {
+	user = strings.ToLower(user)
+	switch {
+	case minecraft.RegexUUID.MatchString(user):
+		return mcclient.UserReq{UUID: strings.ReplaceAll(user, "-", "")}, nil
+	case minecraft.RegexUsername.MatchString(user):
+		return mcclient.UserReq{Username: user}, nil
+	default:
+		return mcclient.UserReq{}, fmt.Errorf("\"%s\" is not a Username or UUID", user)
+	}
+}
+
+// NewAdminRouter creates the (authenticated) admin API routes for viewing, refreshing and purging users
+// The config reload route is only added when reload is set
+func NewAdminRouter(logger log.Logger, mc *mcclient.McClient, token string, reload func() error) *mux.Router {
+	logger = logger.With("component", "admin")
+	// The inspector only needs the open caches (they are not re-opened read-only)
+	inspector := &cache_inspector.CacheInspector{Cfg: cache_inspector.Config{Logger: logger}}
+	inspector.Caches = mc.Caches
+
+	r := mux.NewRouter()
+	// Unauthorized requests are also logged
+	r.Use(route_helpers.LoggingMiddleware(logger))
+	r.Use(AdminAuthMiddleware(token))
+	adminSR := r.PathPrefix("/admin/").Subrouter()
+	adminSR.Path("/user/{user}").Methods(http.MethodGet).Handler(AdminViewUserHandler(inspector)).Name("admin_view_user")
+	adminSR.Path("/user/{user}").Methods(http.MethodDelete).Handler(AdminPurgeUserHandler(logger, mc)).Name("admin_purge_user")
+	adminSR.Path("/user/{user}/refresh").Methods(http.MethodPost).Handler(AdminRefreshUserHandler(logger, mc, inspector)).Name("admin_refresh_user")
+	adminSR.Path("/texture/{texture:.+}").Methods(http.MethodDelete).Handler(AdminPurgeTextureHandler(logger, mc)).Name("admin_purge_texture")
+	adminSR.Path("/cache/{cache}/flush").Methods(http.MethodPost).Handler(AdminFlushCacheHandler(logger, inspector)).Name("admin_flush_cache")
+	if reload != nil {
+		adminSR.Path("/reload").Methods(http.MethodPost).Handler(AdminReloadHandler(reload)).Name("admin_reload")
+	}
+	return r
+}
+
+// lookupEntries views each cached entry of the user, with the error explaining where the lookup stopped
+func lookupEntries(inspector *cache_inspector.CacheInspector, user string) adminResponse {
+	entries, err := inspector.Lookup(user)
+	var resp adminResponse
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, newAdminEntry(entry))
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+func AdminViewUserHandler(inspector *cache_inspector.CacheInspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminRequests.WithLabelValues("view_user").Inc()
+		user := mux.Vars(r)["user"]
+		if _, err := adminUserReq(user); err != nil {
+			writeAdminResponse(w, http.StatusBadRequest, adminResponse{Error: err.Error()})
+			return
+		}
+
+		resp := lookupEntries(inspector, user)
+		if len(resp.Entries) == 0 {
+			writeAdminResponse(w, http.StatusNotFound, resp)
+			return
+		}
+		writeAdminResponse(w, http.StatusOK, resp)
+	}
+}
+
+func AdminRefreshUserHandler(logger log.Logger, mc *mcclient.McClient, inspector *cache_inspector.CacheInspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := access_log.Logger(r.Context(), logger)
+		adminRequests.WithLabelValues("refresh_user").Inc()
+		user := mux.Vars(r)["user"]
+		userReq, err := adminUserReq(user)
+		if err != nil {
+			writeAdminResponse(w, http.StatusBadRequest, adminResponse{Error: err.Error()})
+			return
+		}
+
+		_, mcUser, err := mc.RefreshUser(r.Context(), logger, userReq)
+		if err != nil {
+			writeAdminResponse(w, http.StatusBadGateway, adminResponse{Error: fmt.Sprintf("refresh failed: %v", err)})
+			return
+		}
+
+		// The texture for a new SkinPath is fetched now, so it's in the cache before the next request
+		_, textureIO := mc.GetSkinBufferFromReq(r.Context(), logger, mcclient.UserReq{UUID: mcUser.UUID})
+		textureIO.Close()
+
+		resp := lookupEntries(inspector, mcUser.UUID)
+		resp.Message = "refreshed " + mcUser.Username
+		writeAdminResponse(w, http.StatusOK, resp)
+	}
+}
+
+func AdminPurgeUserHandler(logger log.Logger, mc *mcclient.McClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := access_log.Logger(r.Context(), logger)
+		adminRequests.WithLabelValues("purge_user").Inc()
+		userReq, err := adminUserReq(mux.Vars(r)["user"])
+		if err != nil {
+			writeAdminResponse(w, http.StatusBadRequest, adminResponse{Error: err.Error()})
+			return
+		}
+
+		purged, err := mc.PurgeUser(r.Context(), logger, userReq)
+		if err != nil {
+			writeAdminResponse(w, http.StatusInternalServerError, adminResponse{Purged: purged, Error: err.Error()})
+			return
+		}
+		writeAdminResponse(w, http.StatusOK, adminResponse{Purged: purged})
+	}
+}
+
+func AdminPurgeTextureHandler(logger log.Logger, mc *mcclient.McClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := access_log.Logger(r.Context(), logger)
+		adminRequests.WithLabelValues("purge_texture").Inc()
+		texture := mux.Vars(r)["texture"]
+
+		err := mc.PurgeTexture(r.Context(), logger, texture)
+		if err == mcclient.ErrCacheDisabled {
+			writeAdminResponse(w, http.StatusNotFound, adminResponse{Error: err.Error()})
+			return
+		} else if err != nil {
+			writeAdminResponse(w, http.StatusInternalServerError, adminResponse{Error: err.Error()})
+			return
+		}
+		writeAdminResponse(w, http.StatusOK, adminResponse{Purged: []string{mc.Caches.Textures.Name() + ":" + texture}})
+	}
+}
+
+func AdminFlushCacheHandler(logger log.Logger, inspector *cache_inspector.CacheInspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := access_log.Logger(r.Context(), logger)
+		adminRequests.WithLabelValues("flush_cache").Inc()
+		c, err := inspector.Cache(mux.Vars(r)["cache"])
+		if err != nil {
+			writeAdminResponse(w, http.StatusNotFound, adminResponse{Error: err.Error()})
+			return
+		}
+
+		if err := c.Flush(); err != nil {
+			logger.Errorf("Failed to flush %s: %v", c.Name(), err)
+			writeAdminResponse(w, http.StatusInternalServerError, adminResponse{Error: err.Error()})
+			return
+		}
+		logger.Warnf("Flushed cache %s", c.Name())
+		writeAdminResponse(w, http.StatusOK, adminResponse{Message: "flushed " + c.Name()})
+	}
+}
+
+// AdminReloadHandler re-reads the config and swaps in the settings which are safe to change (as with a SIGHUP)
+func AdminReloadHandler(reload func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminRequests.WithLabelValues("reload").Inc()
+		if err := reload(); err != nil {
+			writeAdminResponse(w, http.StatusInternalServerError, adminResponse{Error: err.Error()})
+			return
+		}
+		writeAdminResponse(w, http.StatusOK, adminResponse{Message: "config reloaded"})
+	}
+}
+
+// newAdminServer returns the HTTP server for the admin API (or nil when it's disabled)
+func newAdminServer(cfg AdminConfig, logger log.Logger, mc *mcclient.McClient, reload func() error) (*http.Server, error) {
+	if cfg.ListenAddress == "" {
+		return nil, nil
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("the admin API requires a token")
+	}
+	return &http.Server{
+		Addr:    cfg.ListenAddress,
+		Handler: NewAdminRouter(logger, mc, cfg.Token, reload),
+	}, nil
+}
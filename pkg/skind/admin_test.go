@@ -0,0 +1,137 @@
+package skind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/cache/lru_cache"
+	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/minecraft/mockminecraft"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+const testAdminToken = "secret"
+
+func newAdminRouter(t *testing.T) (http.Handler, *mcclient.McClient, func()) {
+	logger := log.NewBuiltinLogger(1)
+	lruCache, err := lru_cache.NewLruCache(lru_cache.NewLruCacheConfig(10,
+		cache.CacheConfig{
+			Name:   "LruCache",
+			Logger: logger,
+		},
+	))
+	if err != nil {
+		t.Fatalf("Error creating LruCache: %s", err)
+	}
+
+	rt, shutdown := mockminecraft.Setup(mockminecraft.ReturnMux())
+	mc := &mcclient.McClient{
+		API: &minecraft.Minecraft{
+			Client: &http.Client{Transport: rt},
+			Cfg: minecraft.Config{
+				UUIDAPIConfig: minecraft.UUIDAPIConfig{
+					SessionServerURL: "http://example.com/session/minecraft/profile/",
+					ProfileURL:       "http://example.com/users/profiles/minecraft/",
+				},
+			},
+		},
+	}
+	mc.Caches.UUID = lruCache
+	mc.Caches.UserData = lruCache
+	mc.Caches.Textures = lruCache
+
+	return NewAdminRouter(logger, mc, testAdminToken, nil), mc, shutdown
+}
+
+func adminRequest(router http.Handler, method, path, token string) (*httptest.ResponseRecorder, adminResponse) {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp adminResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	return rec, resp
+}
+
+func TestAdminAuth(t *testing.T) {
+	router, _, shutdown := newAdminRouter(t)
+	defer shutdown()
+
+	for _, token := range []string{"", "wrong"} {
+		rec, _ := adminRequest(router, http.MethodGet, "/admin/user/clone1018", token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Token \"%s\" should be unauthorized, not: %d", token, rec.Code)
+		}
+	}
+}
+
+func TestAdminRefreshAndPurge(t *testing.T) {
+	router, mc, shutdown := newAdminRouter(t)
+	defer shutdown()
+
+	rec, _ := adminRequest(router, http.MethodGet, "/admin/user/clone1018", testAdminToken)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Uncached user should be not found, not: %d", rec.Code)
+	}
+
+	rec, resp := adminRequest(router, http.MethodPost, "/admin/user/clone1018/refresh", testAdminToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Refresh should be OK, not: %d %+v", rec.Code, resp)
+	}
+	// The McUser and texture are viewed from the refreshed UUID
+	if len(resp.Entries) != 2 || resp.Entries[1].Cache != "textures" {
+		t.Errorf("Refresh should return the McUser and texture entries, not: %+v", resp.Entries)
+	}
+
+	// Allow the goroutine caching the Username -> UUID to finish
+	time.Sleep(50 * time.Millisecond)
+
+	rec, resp = adminRequest(router, http.MethodDelete, "/admin/user/clone1018", testAdminToken)
+	if rec.Code != http.StatusOK || len(resp.Purged) != 3 {
+		t.Errorf("Purge should have removed 3 keys, not: %d %+v", rec.Code, resp)
+	}
+	if len := mc.Caches.UUID.Len(); len != 0 {
+		t.Errorf("Cache should be empty after the purge, not %d keys", len)
+	}
+}
+
+func TestAdminReload(t *testing.T) {
+	_, mc, shutdown := newAdminRouter(t)
+	defer shutdown()
+
+	reloadErr := errors.New("invalid configuration")
+	router := NewAdminRouter(log.NewBuiltinLogger(1), mc, testAdminToken, func() error { return reloadErr })
+
+	rec, resp := adminRequest(router, http.MethodPost, "/admin/reload", testAdminToken)
+	if rec.Code != http.StatusInternalServerError || resp.Error != reloadErr.Error() {
+		t.Errorf("Failed reload should return the error, not: %d %+v", rec.Code, resp)
+	}
+
+	reloadErr = nil
+	rec, _ = adminRequest(router, http.MethodPost, "/admin/reload", testAdminToken)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Reload should be OK, not: %d", rec.Code)
+	}
+}
+
+func TestAdminConfigString(t *testing.T) {
+	c := Config{Admin: AdminConfig{ListenAddress: ":4644", Token: testAdminToken}}
+	logged := fmt.Sprintf("%+v", c)
+	if strings.Contains(logged, testAdminToken) {
+		t.Errorf("The admin token should not be logged: %s", logged)
+	}
+	if !strings.Contains(logged, "ListenAddress::4644") {
+		t.Errorf("The other admin settings should be logged: %s", logged)
+	}
+}
@@ -0,0 +1,353 @@
+package skind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/mcclient/status"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const (
+	// Maximum users in a batch request
+	grpcBatchMaxUsers = 100
+	// Users looked up in parallel for each batch request
+	grpcBatchConcurrency = 8
+)
+
+// GRPCServer serves the skindpb.Skind service from the McClient
+type GRPCServer struct {
+	skindpb.UnimplementedSkindServer
+	logger log.Logger
+	mc     *mcclient.McClient
+}
+
+var _ skindpb.SkindServer = (*GRPCServer)(nil)
+
+func NewGRPCServer(logger log.Logger, mc *mcclient.McClient) *GRPCServer {
+	return &GRPCServer{logger: logger, mc: mc}
+}
+
+// grpcUserReq converts a UserRequest into a UserReq, requiring exactly one of Username or (dashed) UUID
+func grpcUserReq(req *skindpb.UserRequest) (mcclient.UserReq, error) {
+	switch {
+	case req.GetUsername() != "" && req.GetUuid() != "":
+		return mcclient.UserReq{}, grpcstatus.Error(codes.InvalidArgument, "only one of username or uuid should be given")
+	case req.GetUuid() != "":
+		uuid := strings.ToLower(req.GetUuid())
+		if !minecraft.RegexUUID.MatchString(uuid) {
+			return mcclient.UserReq{}, grpcstatus.Errorf(codes.InvalidArgument, "\"%s\" is not a UUID", uuid)
+		}
+		return mcclient.UserReq{UUID: strings.ReplaceAll(uuid, "-", "")}, nil
+	case req.GetUsername() != "":
+		username := strings.ToLower(req.GetUsername())
+		if !minecraft.RegexUsername.MatchString(username) {
+			return mcclient.UserReq{}, grpcstatus.Errorf(codes.InvalidArgument, "\"%s\" is not a Username", username)
+		}
+		return mcclient.UserReq{Username: username}, nil
+	default:
+		return mcclient.UserReq{}, grpcstatus.Error(codes.InvalidArgument, "a username or uuid is required")
+	}
+}
+
+// lookupStatus converts the error of a user lookup into a Status (the values match status.Status)
+func lookupStatus(err error) skindpb.Status {
+	if err == nil {
+		return skindpb.Status_OK
+	}
+	var s status.Status
+	if errors.As(err, &s) {
+		// A Status without a skindpb value (eg. a new one which is missing from the proto) is still an error
+		if _, ok := skindpb.Status_name[int32(s)]; ok {
+			return skindpb.Status(s)
+		}
+	}
+	return skindpb.Status_ERROR_GENERIC
+}
+
+// grpcError converts the error of a user lookup into a gRPC error
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return grpcstatus.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return grpcstatus.Error(codes.Canceled, err.Error())
+	}
+
+	switch lookupStatus(err) {
+	case skindpb.Status_ERROR_UNKNOWN_USER:
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	case skindpb.Status_ERROR_RATE_LIMIT:
+		return grpcstatus.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return grpcstatus.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// isAbandoned is true when the error was from the request context (the result is of no use to the client)
+func isAbandoned(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+func skinModel(textures mcuser.Textures) skindpb.SkinModel {
+	if textures.SkinSlim {
+		return skindpb.SkinModel_SLIM
+	}
+	return skindpb.SkinModel_CLASSIC
+}
+
+// steveSkin is the fallback response when the user lookup fails
+func steveSkin(req *skindpb.UserRequest, lookupErr error) (*skindpb.SkinResponse, error) {
+	resp := &skindpb.SkinResponse{
+		TextureId: minecraft.SteveHash,
+		Model:     skindpb.SkinModel_CLASSIC,
+		Status:    lookupStatus(lookupErr),
+	}
+	if req.GetIfNoneMatch() == minecraft.SteveHash {
+		resp.NotModified = true
+		return resp, nil
+	}
+
+	steve, err := minecraft.GetSteveBytes()
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	resp.Skin = steve.Bytes()
+	return resp, nil
+}
+
+// getSkin looks up the skin, falling back to Steve (with the Status saying why)
+// An error is only returned for an abandoned request
+func (s *GRPCServer) getSkin(ctx context.Context, req *skindpb.UserRequest, userReq mcclient.UserReq) (*skindpb.SkinResponse, error) {
+	logger := access_log.Logger(ctx, s.logger)
+
+	logger, mcUser, err := s.mc.GetMcUserFromReq(ctx, logger, userReq)
+	if err != nil {
+		if isAbandoned(err) {
+			return nil, grpcError(err)
+		}
+		logger.Debugf("Falling back to Steve: %v", err)
+		access_log.FromContext(ctx).SetFallback(mcclient.FallbackReason(err))
+		return steveSkin(req, err)
+	}
+
+	resp := &skindpb.SkinResponse{
+		Uuid:     mcUser.UUID,
+		Username: mcUser.Username,
+		Model:    skinModel(mcUser.Textures),
+		Status:   skindpb.Status_OK,
+	}
+
+	textureIO, err := s.mc.GetSkinTexture(ctx, logger, mcUser)
+	if err != nil {
+		if isAbandoned(err) {
+			return nil, grpcError(err)
+		}
+		logger.Debugf("Falling back to Steve: %v", err)
+		access_log.FromContext(ctx).SetFallback(mcclient.TextureFallbackReason(err))
+		return steveSkin(req, err)
+	}
+	defer textureIO.Close()
+	resp.TextureId = textureIO.TextureID
+
+	if req.GetIfNoneMatch() == textureIO.TextureID {
+		resp.NotModified = true
+		return resp, nil
+	}
+
+	resp.Skin, err = ioutil.ReadAll(textureIO)
+	if err != nil {
+		logger.Errorf("Failed to read texture: %v", err)
+		return steveSkin(req, err)
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) getProfile(ctx context.Context, userReq mcclient.UserReq) (*skindpb.ProfileResponse, error) {
+	logger := access_log.Logger(ctx, s.logger)
+
+	_, mcUser, err := s.mc.GetMcUserFromReq(ctx, logger, userReq)
+	if err != nil {
+		return nil, err
+	}
+	return &skindpb.ProfileResponse{
+		Uuid:      mcUser.UUID,
+		Username:  mcUser.Username,
+		TextureId: mcUser.Textures.SkinPath,
+		Model:     skinModel(mcUser.Textures),
+		Status:    skindpb.Status_OK,
+		Timestamp: mcUser.Timestamp.Time().Unix(),
+	}, nil
+}
+
+func (s *GRPCServer) resolveUsername(ctx context.Context, userReq mcclient.UserReq) (*skindpb.ResolveResponse, error) {
+	if userReq.Username == "" {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "a username is required")
+	}
+	logger := access_log.Logger(ctx, s.logger)
+
+	_, uuid, err := userReq.GetUUID(ctx, logger, s.mc)
+	if err != nil {
+		return nil, err
+	}
+	return &skindpb.ResolveResponse{
+		Username: userReq.Username,
+		Uuid:     uuid,
+		Status:   skindpb.Status_OK,
+	}, nil
+}
+
+func (s *GRPCServer) GetSkin(ctx context.Context, req *skindpb.UserRequest) (*skindpb.SkinResponse, error) {
+	userReq, err := grpcUserReq(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.getSkin(ctx, req, userReq)
+}
+
+func (s *GRPCServer) GetProfile(ctx context.Context, req *skindpb.UserRequest) (*skindpb.ProfileResponse, error) {
+	userReq, err := grpcUserReq(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.getProfile(ctx, userReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) ResolveUsername(ctx context.Context, req *skindpb.UserRequest) (*skindpb.ResolveResponse, error) {
+	userReq, err := grpcUserReq(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.resolveUsername(ctx, userReq)
+	if err != nil {
+		if _, ok := grpcstatus.FromError(err); ok {
+			return nil, err
+		}
+		return nil, grpcError(err)
+	}
+	return resp, nil
+}
+
+// forEachUser validates the whole batch, then calls lookup for each user (grpcBatchConcurrency at a time)
+// The lookup should only return an error when the request is abandoned (which is returned for the batch)
+func forEachUser(ctx context.Context, batch *skindpb.BatchUserRequest, lookup func(i int, req *skindpb.UserRequest, userReq mcclient.UserReq) error) error {
+	users := batch.GetUsers()
+	if len(users) > grpcBatchMaxUsers {
+		return grpcstatus.Errorf(codes.InvalidArgument, "a batch is limited to %d users", grpcBatchMaxUsers)
+	}
+
+	userReqs := make([]mcclient.UserReq, len(users))
+	for i, req := range users {
+		userReq, err := grpcUserReq(req)
+		if err != nil {
+			return grpcstatus.Errorf(codes.InvalidArgument, "user %d: %s", i, grpcstatus.Convert(err).Message())
+		}
+		userReqs[i] = userReq
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, grpcBatchConcurrency)
+	for i := range users {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := lookup(i, users[i], userReqs[i]); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return grpcError(firstErr)
+	}
+	// Some lookups may have been skipped
+	if err := ctx.Err(); err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func (s *GRPCServer) GetSkins(ctx context.Context, batch *skindpb.BatchUserRequest) (*skindpb.BatchSkinResponse, error) {
+	resp := &skindpb.BatchSkinResponse{Skins: make([]*skindpb.SkinResponse, len(batch.GetUsers()))}
+	err := forEachUser(ctx, batch, func(i int, req *skindpb.UserRequest, userReq mcclient.UserReq) (err error) {
+		resp.Skins[i], err = s.getSkin(ctx, req, userReq)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) GetProfiles(ctx context.Context, batch *skindpb.BatchUserRequest) (*skindpb.BatchProfileResponse, error) {
+	resp := &skindpb.BatchProfileResponse{Profiles: make([]*skindpb.ProfileResponse, len(batch.GetUsers()))}
+	err := forEachUser(ctx, batch, func(i int, req *skindpb.UserRequest, userReq mcclient.UserReq) error {
+		profile, err := s.getProfile(ctx, userReq)
+		if err != nil {
+			if isAbandoned(err) {
+				return err
+			}
+			// The failed lookup is still returned (in order) with its Status
+			profile = &skindpb.ProfileResponse{Uuid: userReq.UUID, Username: userReq.Username, Status: lookupStatus(err)}
+		}
+		resp.Profiles[i] = profile
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) ResolveUsernames(ctx context.Context, batch *skindpb.BatchUserRequest) (*skindpb.BatchResolveResponse, error) {
+	for i, req := range batch.GetUsers() {
+		if req.GetUsername() == "" {
+			return nil, grpcstatus.Error(codes.InvalidArgument, fmt.Sprintf("user %d: a username is required", i))
+		}
+	}
+
+	resp := &skindpb.BatchResolveResponse{Users: make([]*skindpb.ResolveResponse, len(batch.GetUsers()))}
+	err := forEachUser(ctx, batch, func(i int, req *skindpb.UserRequest, userReq mcclient.UserReq) error {
+		user, err := s.resolveUsername(ctx, userReq)
+		if err != nil {
+			if isAbandoned(err) {
+				return err
+			}
+			user = &skindpb.ResolveResponse{Username: userReq.Username, Status: lookupStatus(err)}
+		}
+		resp.Users[i] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,198 @@
+package skind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/minotar/imgd/pkg/mcclient/status"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+func newGRPCClient(t *testing.T) (skindpb.SkindClient, func()) {
+	_, mc, shutdownMock := newAdminRouter(t)
+	logger := log.NewBuiltinLogger(1)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %s", err)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(route_helpers.LoggingInterceptor(logger)))
+	skindpb.RegisterSkindServer(server, NewGRPCServer(logger, mc))
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Error dialing: %s", err)
+	}
+
+	return skindpb.NewSkindClient(conn), func() {
+		conn.Close()
+		server.Stop()
+		shutdownMock()
+	}
+}
+
+func TestGRPCGetSkin(t *testing.T) {
+	client, shutdown := newGRPCClient(t)
+	defer shutdown()
+	ctx := context.Background()
+
+	resp, err := client.GetSkin(ctx, &skindpb.UserRequest{Username: "clone1018"})
+	if err != nil {
+		t.Fatalf("GetSkin should not error: %s", err)
+	}
+	if resp.Status != skindpb.Status_OK || len(resp.Skin) == 0 || resp.TextureId == "" || resp.Uuid == "" {
+		t.Errorf("GetSkin should return the skin and metadata, not: %+v", resp)
+	}
+
+	notModified, err := client.GetSkin(ctx, &skindpb.UserRequest{Uuid: resp.Uuid, IfNoneMatch: resp.TextureId})
+	if err != nil {
+		t.Fatalf("GetSkin should not error: %s", err)
+	}
+	if !notModified.NotModified || len(notModified.Skin) != 0 {
+		t.Errorf("A matching TextureID should be not modified, not: %+v", notModified)
+	}
+
+	steve, err := client.GetSkin(ctx, &skindpb.UserRequest{Username: "unknownuser"})
+	if err != nil {
+		t.Fatalf("GetSkin should not error: %s", err)
+	}
+	if steve.Status != skindpb.Status_ERROR_UNKNOWN_USER || steve.TextureId != minecraft.SteveHash || len(steve.Skin) == 0 {
+		t.Errorf("Unknown user should return Steve, not: %+v", steve)
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	client, shutdown := newGRPCClient(t)
+	defer shutdown()
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "NoUser",
+			call: func() error { _, err := client.GetSkin(ctx, &skindpb.UserRequest{}); return err },
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "UsernameAndUUID",
+			call: func() error {
+				_, err := client.GetProfile(ctx, &skindpb.UserRequest{Username: "clone1018", Uuid: minecraft.SteveUUID})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "ResolveUUID",
+			call: func() error {
+				_, err := client.ResolveUsername(ctx, &skindpb.UserRequest{Uuid: minecraft.SteveUUID})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "UnknownProfile",
+			call: func() error {
+				_, err := client.GetProfile(ctx, &skindpb.UserRequest{Username: "unknownuser"})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "RateLimited",
+			call: func() error {
+				_, err := client.ResolveUsername(ctx, &skindpb.UserRequest{Username: "ratelimitapi"})
+				return err
+			},
+			code: codes.ResourceExhausted,
+		},
+		{
+			name: "BatchTooLarge",
+			call: func() error {
+				batch := &skindpb.BatchUserRequest{}
+				for i := 0; i <= grpcBatchMaxUsers; i++ {
+					batch.Users = append(batch.Users, &skindpb.UserRequest{Username: "clone1018"})
+				}
+				_, err := client.GetSkins(ctx, batch)
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := grpcstatus.Code(tc.call()); code != tc.code {
+				t.Errorf("Expected code %s, not: %s", tc.code, code)
+			}
+		})
+	}
+}
+
+func TestGRPCBatch(t *testing.T) {
+	client, shutdown := newGRPCClient(t)
+	defer shutdown()
+	ctx := context.Background()
+
+	batch := &skindpb.BatchUserRequest{Users: []*skindpb.UserRequest{
+		{Username: "clone1018"},
+		{Username: "unknownuser"},
+		{Username: "citricsquid"},
+	}}
+
+	resolved, err := client.ResolveUsernames(ctx, batch)
+	if err != nil {
+		t.Fatalf("ResolveUsernames should not error: %s", err)
+	}
+	profiles, err := client.GetProfiles(ctx, batch)
+	if err != nil {
+		t.Fatalf("GetProfiles should not error: %s", err)
+	}
+
+	expected := []skindpb.Status{skindpb.Status_OK, skindpb.Status_ERROR_UNKNOWN_USER, skindpb.Status_OK}
+	if len(resolved.Users) != len(expected) || len(profiles.Profiles) != len(expected) {
+		t.Fatalf("Expected %d results, not: %+v %+v", len(expected), resolved, profiles)
+	}
+	for i, status := range expected {
+		if resolved.Users[i].Status != status || profiles.Profiles[i].Status != status {
+			t.Errorf("User %d should have status %s, not: %s / %s", i, status, resolved.Users[i].Status, profiles.Profiles[i].Status)
+		}
+		if status == skindpb.Status_OK && resolved.Users[i].Uuid != profiles.Profiles[i].Uuid {
+			t.Errorf("User %d should resolve to the profile UUID %s, not: %s", i, profiles.Profiles[i].Uuid, resolved.Users[i].Uuid)
+		}
+	}
+}
+
+func TestLookupStatus(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status skindpb.Status
+	}{
+		{nil, skindpb.Status_OK},
+		{status.StatusErrorGeneric, skindpb.Status_ERROR_GENERIC},
+		{status.StatusErrorUnknownUser, skindpb.Status_ERROR_UNKNOWN_USER},
+		{status.StatusErrorRateLimit, skindpb.Status_ERROR_RATE_LIMIT},
+		{status.StatusErrorInvalidTexture, skindpb.Status_ERROR_INVALID_TEXTURE},
+		{fmt.Errorf("texture: %w", status.StatusErrorInvalidTexture), skindpb.Status_ERROR_INVALID_TEXTURE},
+		{errors.New("other"), skindpb.Status_ERROR_GENERIC},
+		{status.Status(100), skindpb.Status_ERROR_GENERIC},
+	}
+
+	for _, tc := range testCases {
+		if s := lookupStatus(tc.err); s != tc.status {
+			t.Errorf("Expected %v to be %s, not: %s", tc.err, tc.status, s)
+		}
+	}
+}
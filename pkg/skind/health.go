@@ -0,0 +1,74 @@
+package skind
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/minotar/imgd/pkg/cache"
+	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/util/health"
+)
+
+type ReadyConfig struct {
+	// How long a Mojang probe result is re-used (0 disables the probe)
+	ProbeInterval time.Duration
+	ProbeUsername string
+	// The upstream error rate is only checked once there are MinRequests in the window
+	MaxErrorRatio float64
+	MinRequests   uint64
+	// Whether the upstream checks (probe / error rate) make the service unready, or only degraded
+	RequireUpstream bool
+
+	// Flag prefix, for the validation errors
+	prefix string
+}
+
+// RegisterFlags registers the flags with the prefix (eg. "skind" or "imgd")
+func (c *ReadyConfig) RegisterFlags(f *flag.FlagSet, prefix string) {
+	c.prefix = prefix
+	f.DurationVar(&c.ProbeInterval, prefix+".ready.probe-interval", time.Minute, "How often readiness probes the Mojang API (0 to disable)")
+	f.StringVar(&c.ProbeUsername, prefix+".ready.probe-username", "Notch", "Username looked up by the Mojang API probe")
+	f.Float64Var(&c.MaxErrorRatio, prefix+".ready.max-error-ratio", 0.5, "Ratio of recent upstream errors before readiness reports upstream as failing")
+	f.Uint64Var(&c.MinRequests, prefix+".ready.min-requests", 20, "Recent upstream requests needed before the error ratio is checked")
+	f.BoolVar(&c.RequireUpstream, prefix+".ready.require-upstream", false, "Fail readiness (rather than report degraded) when the upstream checks fail")
+}
+
+func (c *ReadyConfig) Validate() error {
+	if c.ProbeInterval < 0 {
+		return fmt.Errorf("%s.ready.probe-interval should not be negative", c.prefix)
+	}
+	if c.MaxErrorRatio < 0 || c.MaxErrorRatio > 1 {
+		return fmt.Errorf("%s.ready.max-error-ratio %v should be between 0 and 1", c.prefix, c.MaxErrorRatio)
+	}
+	return nil
+}
+
+// cacheCheck inserts and retrieves a key to show the cache is writable
+func cacheCheck(c cache.Cache) health.CheckFunc {
+	return func(_ context.Context) error {
+		_, err := checkCache(c)
+		return err
+	}
+}
+
+// NewReadyChecker checks each cache is writable, then the upstream API reachability and recent error rate
+// A Mojang outage affects every instance equally, so the upstream checks are only critical with RequireUpstream
+func NewReadyChecker(cfg ReadyConfig, mc *mcclient.McClient) *health.Checker {
+	checker := &health.Checker{}
+	checker.Add("cache_uuid", true, cacheCheck(mc.Caches.UUID))
+	checker.Add("cache_userdata", true, cacheCheck(mc.Caches.UserData))
+	if mc.Caches.Textures != nil {
+		checker.Add("cache_textures", true, cacheCheck(mc.Caches.Textures))
+	}
+
+	if cfg.ProbeInterval > 0 {
+		probe := func(ctx context.Context) error {
+			return mc.ProbeUpstream(ctx, cfg.ProbeUsername)
+		}
+		checker.Add("mojang_api", cfg.RequireUpstream, health.CachedCheck(probe, cfg.ProbeInterval))
+	}
+	checker.Add("upstream_errors", cfg.RequireUpstream, mc.UpstreamErrors.Check(cfg.MaxErrorRatio, cfg.MinRequests))
+	return checker
+}
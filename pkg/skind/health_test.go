@@ -0,0 +1,42 @@
+package skind
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minotar/imgd/pkg/util/health"
+)
+
+func TestReadyChecker(t *testing.T) {
+	_, mc, shutdown := newAdminRouter(t)
+	defer shutdown()
+	mc.UpstreamErrors = health.NewErrorRate(time.Minute)
+
+	cfg := ReadyConfig{
+		ProbeInterval: time.Minute,
+		ProbeUsername: "clone1018",
+		MaxErrorRatio: 0.5,
+		MinRequests:   2,
+	}
+	report := NewReadyChecker(cfg, mc).Run(context.Background())
+	if report.Status != health.StatusOK {
+		t.Errorf("Ready status should be ok, not %s: %+v", report.Status, report.Checks)
+	}
+
+	mc.UpstreamErrors.Record(true)
+	mc.UpstreamErrors.Record(true)
+	report = NewReadyChecker(cfg, mc).Run(context.Background())
+	if report.Status != health.StatusDegraded {
+		t.Errorf("Upstream errors should only degrade readiness, not %s", report.Status)
+	}
+
+	cfg.RequireUpstream = true
+	report = NewReadyChecker(cfg, mc).Run(context.Background())
+	if report.Status != health.StatusFail {
+		t.Errorf("Upstream errors should fail readiness when required, not %s", report.Status)
+	}
+	if report.Checks["cache_uuid"].Status != health.StatusOK {
+		t.Errorf("Cache check should pass: %+v", report.Checks["cache_uuid"])
+	}
+}
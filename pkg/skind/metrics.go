@@ -18,4 +18,12 @@ var (
 			Help:      "Type of skind User requested.",
 		}, []string{"type"},
 	)
+	adminRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "skind",
+			Name:      "admin_requests_total",
+			Help:      "Admin API requests by action.",
+		}, []string{"action"},
+	)
 )
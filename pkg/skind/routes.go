@@ -9,6 +9,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/minotar/imgd/pkg/cache"
 	"github.com/minotar/imgd/pkg/mcclient"
+	"github.com/minotar/imgd/pkg/skind/skindpb"
+	"github.com/minotar/imgd/pkg/util/health"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -18,20 +20,29 @@ import (
 func (s *Skind) routes() {
 	s.Server.HTTP.Path("/debug/fgprof").Handler(fgprof.Handler())
 	s.Server.HTTP.Path("/healthcheck").Handler(HealthcheckHandler(s.McClient))
+	s.Server.HTTP.Path("/live").Handler(health.LiveHandler())
+	s.Server.HTTP.Path("/ready").Handler(health.ReadyHandler(NewReadyChecker(s.Cfg.Ready, s.McClient)))
 	s.Server.HTTP.Path("/dbsize").Handler(SizecheckHandler(s.McClient))
 
-	skinWrapper := NewSkinWrapper(s.Cfg.Logger, s.McClient, s.Cfg.UseETags, s.Cfg.RedirectUsername, s.Cfg.CacheControlTTL)
-	RegisterSkinRoutes(s.Server.HTTP, skinWrapper)
+	skinWrapper := NewSkinWrapper(s.Cfg.Logger, s.McClient, s.Settings)
+	skinPageWrapper := skinWrapper
+	if s.Cfg.UpgradeLegacySkins {
+		skinPageWrapper = NewSkinWrapper(s.Cfg.Logger, s.McClient, s.Settings, LegacySkinUpgrade)
+	}
+	RegisterSkinRoutes(s.Server.HTTP, skinWrapper, skinPageWrapper)
+
+	skindpb.RegisterSkindServer(s.Server.GRPC, NewGRPCServer(s.Cfg.Logger, s.McClient))
 }
 
-func RegisterSkinRoutes(m *mux.Router, skinWrapper SkinWrapper) {
+// RegisterSkinRoutes registers /skin (using the skinPageWrapper, eg. to upgrade legacy skins) and /download (always the original skin)
+func RegisterSkinRoutes(m *mux.Router, skinWrapper SkinWrapper, skinPageWrapper SkinWrapper) {
 
 	optionalPNG := "{?:(?:\\.png)?}"
 	uuidCounter := requestedUserType.MustCurryWith(prometheus.Labels{"type": "UUID"})
 	dashedCounter := requestedUserType.MustCurryWith(prometheus.Labels{"type": "DashedUUID"})
 	usernameCounter := requestedUserType.MustCurryWith(prometheus.Labels{"type": "Username"})
 
-	skinPageHandler := skinWrapper(SkinPageProcessor)
+	skinPageHandler := skinPageWrapper(SkinPageProcessor)
 
 	skinSR := m.PathPrefix("/skin/").Subrouter()
 	skinSR.Path(route_helpers.UUIDPath + optionalPNG).Handler(promhttp.InstrumentHandlerCounter(uuidCounter, skinPageHandler)).Name("skin")
@@ -75,6 +86,7 @@ func checkCache(c cache.Cache) (string, error) {
 	return fmt.Sprintf("%s is OK", c.Name()), nil
 }
 
+// HealthcheckHandler is the original cache-only check (see /ready for the detailed check)
 func HealthcheckHandler(mc *mcclient.McClient) http.HandlerFunc {
 	caches := mc.Caches
 	return func(w http.ResponseWriter, r *http.Request) {
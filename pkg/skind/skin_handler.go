@@ -1,7 +1,10 @@
 package skind
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"net/http"
 	"time"
@@ -9,42 +12,61 @@ import (
 	"github.com/minotar/imgd/pkg/mcclient"
 	"github.com/minotar/imgd/pkg/mcclient/mcuser"
 	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/minotar/imgd/pkg/util/log"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
 )
 
+// FallbackHeader names why Steve was served instead (see mcclient.FallbackReason), so processd can classify the lookup
+const FallbackHeader = "X-Skin-Fallback"
+
 // SkinProcessor *MUST* call mcuser.TextureIO.Close() before completing
 type SkinProcessor func(log.Logger, mcuser.TextureIO) http.HandlerFunc
 
 type SkinWrapper func(SkinProcessor) http.HandlerFunc
 
+// SkinFilter replaces the TextureIO before the ETag is checked (so a filter which changes the skin should also change the TextureID)
+type SkinFilter func(log.Logger, mcuser.TextureIO) mcuser.TextureIO
+
 // Requires "uuid" or "username" vars
-func NewSkinWrapper(logger log.Logger, mc *mcclient.McClient, useEtags bool, redirectUsernames bool, cacheControlTTL time.Duration) SkinWrapper {
+// The settings are read for each request (so they follow a config reload)
+// Any filters are applied (in order) to the skin
+func NewSkinWrapper(logger log.Logger, mc *mcclient.McClient, liveSettings *route_helpers.LiveSettings, filters ...SkinFilter) SkinWrapper {
 	return func(processFunc SkinProcessor) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			logger := access_log.Logger(r.Context(), logger)
+			settings := liveSettings.Load()
 
 			userReq := route_helpers.MuxToUserReq(r)
 
-			// Todo: Instead set headers based on lookup results
-			w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cacheControlTTL.Seconds())))
-
-			if redirectUsernames && userReq.Username != "" {
+			if settings.RedirectUsername && userReq.Username != "" {
 				// Redirect Usernames is enabled, and a Username was given
-				logger, uuid, err := userReq.GetUUID(logger, mc)
+				logger, uuid, err := userReq.GetUUID(r.Context(), logger, mc)
 				if err != nil {
 					logger.Debugf("Redirecting username to Steve UUID: %v", err)
 					uuid = minecraft.SteveUUID
+					setFallbackHeaders(w, settings, mcclient.FallbackReason(err))
+				} else {
+					setCacheControl(w, settings.CacheControlTTL)
 				}
 
 				http.Redirect(w, r, uuid, http.StatusFound)
 				return
 			}
 
-			logger, skinIO := mc.GetSkinBufferFromReq(logger, userReq)
+			logger, skinIO, fallback := mc.GetSkinBufferOrFallback(r.Context(), logger, userReq)
+			for _, filter := range filters {
+				skinIO = filter(logger, skinIO)
+			}
 			defer skinIO.Close()
+			if fallback != "" {
+				setFallbackHeaders(w, settings, fallback)
+			} else {
+				setCacheControl(w, settings.CacheControlTTL)
+			}
 
 			// Todo: Technically, this ETag handling is _before_ Content* headers are set, so the 304 will be missing them
-			if useEtags {
+			if settings.UseETags {
 				// ETag is always included (even for 304 responses)
 				w.Header().Set("ETag", skinIO.TextureID)
 
@@ -62,6 +84,16 @@ func NewSkinWrapper(logger log.Logger, mc *mcclient.McClient, useEtags bool, red
 	}
 }
 
+func setCacheControl(w http.ResponseWriter, ttl time.Duration) {
+	w.Header().Add("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ttl.Seconds())))
+}
+
+// setFallbackHeaders names the fallback, which is only cached briefly (the next lookup may succeed)
+func setFallbackHeaders(w http.ResponseWriter, settings route_helpers.HandlerSettings, reason string) {
+	w.Header().Set(FallbackHeader, reason)
+	setCacheControl(w, settings.ErrorCacheControlTTL)
+}
+
 // SkinPageProcessor simply copies the TextureIO to the ResponseWriter
 func SkinPageProcessor(logger log.Logger, skinIO mcuser.TextureIO) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -78,3 +110,39 @@ func SkinDownloadProcessor(logger log.Logger, skinIO mcuser.TextureIO) http.Hand
 		SkinPageProcessor(logger, skinIO)(w, r)
 	}
 }
+
+// upgradedSuffix is added to the TextureID (the ETag) of an upgraded legacy skin, as its bytes differ from the original
+const upgradedSuffix = "-64x64"
+
+// LegacySkinUpgrade upgrades a legacy (64x32) skin to the 64x64 layout, with its own TextureID
+// Other skins are passed on as they are (without being decoded)
+// It reads and closes the TextureIO, returning one which reads the (upgraded) PNG
+func LegacySkinUpgrade(logger log.Logger, skinIO mcuser.TextureIO) mcuser.TextureIO {
+	skinBytes, err := io.ReadAll(skinIO)
+	skinIO.Close()
+	if err != nil {
+		logger.Warnf("Failed to read skin for upgrade, falling back to Steve: %v", err)
+		return LegacySkinUpgrade(logger, mcuser.GetSteveTextureIO())
+	}
+	original := mcuser.TextureIO{ReadCloser: io.NopCloser(bytes.NewReader(skinBytes)), TextureID: skinIO.TextureID}
+
+	// Only the header is needed to check the layout
+	config, _, err := image.DecodeConfig(bytes.NewReader(skinBytes))
+	if err != nil || config.Width != config.Height*2 {
+		return original
+	}
+
+	var skin minecraft.Skin
+	if err := skin.Decode(bytes.NewReader(skinBytes)); err != nil {
+		logger.Debugf("Failed to decode legacy skin: %v", err)
+		return original
+	}
+	skin.Normalise()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, skin.Image); err != nil {
+		logger.Debugf("Failed to encode upgraded skin: %v", err)
+		return original
+	}
+	return mcuser.TextureIO{ReadCloser: io.NopCloser(&buf), TextureID: skinIO.TextureID + upgradedSuffix}
+}
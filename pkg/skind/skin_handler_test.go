@@ -0,0 +1,117 @@
+package skind
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/mcclient/mcuser"
+	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"github.com/minotar/imgd/pkg/util/sample_skin"
+)
+
+func TestLegacySkinUpgrade(t *testing.T) {
+	logger := log.NewBuiltinLogger(1)
+
+	// Steve is a legacy skin
+	upgraded := LegacySkinUpgrade(logger, mcuser.GetSteveTextureIO())
+	config, _, err := image.DecodeConfig(upgraded)
+	if err != nil {
+		t.Fatalf("Upgraded skin should decode: %v", err)
+	}
+	if config.Width != 64 || config.Height != 64 {
+		t.Errorf("Expected a 64x64 skin, not: %dx%d", config.Width, config.Height)
+	}
+	if upgraded.TextureID != minecraft.SteveHash+upgradedSuffix {
+		t.Errorf("Expected the upgraded skin to have its own TextureID, not: %s", upgraded.TextureID)
+	}
+
+	// A 1.8 skin is passed on unchanged
+	sampleReadCloser, err := sample_skin.GetSampleSkinReadCloser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampleBytes, _ := io.ReadAll(sampleReadCloser)
+	skinIO := mcuser.TextureIO{ReadCloser: io.NopCloser(bytes.NewReader(sampleBytes)), TextureID: "sample"}
+	unchanged := LegacySkinUpgrade(logger, skinIO)
+	if body, _ := io.ReadAll(unchanged); !bytes.Equal(body, sampleBytes) || unchanged.TextureID != "sample" {
+		t.Errorf("Expected a 1.8 skin to be unchanged, not: %s", unchanged.TextureID)
+	}
+
+	// A skin which fails to read is replaced by (the upgraded) Steve
+	failed := LegacySkinUpgrade(logger, mcuser.TextureIO{ReadCloser: io.NopCloser(iotest.ErrReader(errors.New("read failed"))), TextureID: "failed"})
+	if failed.TextureID != minecraft.SteveHash+upgradedSuffix {
+		t.Errorf("Expected a failed read to fall back to Steve, not: %s", failed.TextureID)
+	}
+}
+
+func TestLegacySkinUpgradeETag(t *testing.T) {
+	_, mc, shutdown := newAdminRouter(t)
+	defer shutdown()
+	settings := route_helpers.NewLiveSettings(route_helpers.HandlerSettings{UseETags: true})
+	handler := NewSkinWrapper(log.NewBuiltinLogger(1), mc, settings, LegacySkinUpgrade)(SkinPageProcessor)
+
+	// The original TextureID no longer matches (the body has changed)
+	testCases := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{minecraft.SteveHash, http.StatusOK},
+		{minecraft.SteveHash + upgradedSuffix, http.StatusNotModified},
+	}
+
+	for _, tc := range testCases {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/skin/unknownuser", nil), map[string]string{"username": "unknownuser"})
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("Expected status %d for If-None-Match %s, not: %d", tc.status, tc.ifNoneMatch, rec.Code)
+		}
+		if eTag := rec.Header().Get("ETag"); eTag != minecraft.SteveHash+upgradedSuffix {
+			t.Errorf("Expected the upgraded ETag, not: %s", eTag)
+		}
+	}
+}
+
+func TestSkinWrapperFallback(t *testing.T) {
+	_, mc, shutdown := newAdminRouter(t)
+	defer shutdown()
+	settings := route_helpers.NewLiveSettings(route_helpers.HandlerSettings{UseETags: true, CacheControlTTL: 6 * time.Hour, ErrorCacheControlTTL: time.Minute})
+	handler := NewSkinWrapper(log.NewBuiltinLogger(1), mc, settings)(SkinPageProcessor)
+
+	testCases := []struct {
+		username string
+		fallback string
+		maxAge   string
+	}{
+		{"clone1018", "", "public, max-age=21600"},
+		{"unknownuser", "unknown_user", "public, max-age=60"},
+	}
+
+	for _, tc := range testCases {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/skin/"+tc.username, nil), map[string]string{"username": tc.username})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected a skin (or Steve) for %s, not: %d", tc.username, rec.Code)
+		}
+		if fallback := rec.Header().Get(FallbackHeader); fallback != tc.fallback {
+			t.Errorf("Expected fallback \"%s\" for %s, not: \"%s\"", tc.fallback, tc.username, fallback)
+		}
+		if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != tc.maxAge {
+			t.Errorf("Expected Cache-Control %s for %s, not: %s", tc.maxAge, tc.username, cacheControl)
+		}
+	}
+}
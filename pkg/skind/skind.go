@@ -1,28 +1,63 @@
 package skind
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"net"
+	"net/http"
 	"time"
 
 	cache_config "github.com/minotar/imgd/pkg/cache/util/config"
 	"github.com/minotar/imgd/pkg/mcclient"
 	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/minotar/imgd/pkg/util/reload"
 	"github.com/minotar/imgd/pkg/util/route_helpers"
+	"github.com/minotar/imgd/pkg/util/tracing"
 
 	"github.com/weaveworks/common/server"
 )
 
+// reloadableSettings can be changed without a restart (see Reload)
+var reloadableSettings = append([]string{
+	"skind.cors-allow-all",
+	"skind.use-etags",
+	"skind.redirect-username",
+	"skind.cache-control-ttl",
+	"skind.error-cache-control-ttl",
+	"log.level",
+}, mcclient.ReloadableSettings...)
+
 type Config struct {
-	Server   server.Config   `yaml:"server,omitempty"`
-	McClient mcclient.Config `yaml:"mcclient,omitempty"`
+	Server   server.Config
+	McClient mcclient.Config
 	Logger   log.Logger
-	// Add open CORS headers to easch response
+	// Add open CORS headers to each response
 	CorsAllowAll bool
 	// Return an ETag based on the texture ID
 	UseETags bool
 	// Return a 302 redirect for Username requests to their related UUID
 	RedirectUsername bool
 	CacheControlTTL  time.Duration
+	// Cache TTL returned with Steve when the lookup fails
+	ErrorCacheControlTTL time.Duration
+	// Serve legacy (64x32) skins on /skin in the 64x64 layout
+	UpgradeLegacySkins bool
+	Tracing            tracing.Config
+	Admin              AdminConfig
+	Ready              ReadyConfig
+}
+
+// HandlerSettings are the response settings (which a reload can change)
+func (c *Config) HandlerSettings() route_helpers.HandlerSettings {
+	return route_helpers.HandlerSettings{
+		CorsAllowAll:         c.CorsAllowAll,
+		UseETags:             c.UseETags,
+		RedirectUsername:     c.RedirectUsername,
+		CacheControlTTL:      c.CacheControlTTL,
+		ErrorCacheControlTTL: c.ErrorCacheControlTTL,
+	}
 }
 
 // RegisterFlags registers flag.
@@ -33,24 +68,66 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.UseETags, "skind.use-etags", true, "Use etags to skip re-processing")
 	f.BoolVar(&c.RedirectUsername, "skind.redirect-username", true, "Redirect username requests to the UUID variant")
 	f.DurationVar(&c.CacheControlTTL, "skind.cache-control-ttl", time.Duration(6)*time.Hour, "Cache TTL returned to clients")
+	f.DurationVar(&c.ErrorCacheControlTTL, "skind.error-cache-control-ttl", time.Minute, "Cache TTL returned to clients when the skin lookup fails")
+	f.BoolVar(&c.UpgradeLegacySkins, "skind.upgrade-legacy-skins", false, "Serve legacy (64x32) skins on /skin in the 64x64 layout")
 
+	c.Admin.RegisterFlags(f)
+	c.Ready.RegisterFlags(f, "skind")
+	c.Tracing.RegisterFlags(f)
 	c.Server.RegisterFlags(f)
 	c.McClient.RegisterFlags(f)
 
 }
 
+func (c *Config) Validate() error {
+	if c.CacheControlTTL < 0 {
+		return errors.New("skind.cache-control-ttl should not be negative")
+	}
+	if c.ErrorCacheControlTTL < 0 {
+		return errors.New("skind.error-cache-control-ttl should not be negative")
+	}
+	if err := c.Admin.Validate(); err != nil {
+		return err
+	}
+	if err := c.Ready.Validate(); err != nil {
+		return err
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+	return c.McClient.Validate()
+}
+
 type Skind struct {
 	Cfg Config
 
-	Server   *server.Server
-	McClient *mcclient.McClient
+	Server      *server.Server
+	AdminServer *http.Server
+	McClient    *mcclient.McClient
+	Settings    *route_helpers.LiveSettings
+	// Flushes any buffered trace spans
+	TracingShutdown func(context.Context) error
+	// Re-reads the config (with the names of the changed settings), which is required to Reload
+	ConfigLoader func() (Config, []string, error)
+	Reloader     *reload.Reloader
 }
 
 func New(cfg Config) (*Skind, error) {
 	// Set namespace for all metrics
 	cfg.Server.MetricsNamespace = "skind"
-	// Set the GRPC to localhost only
-	cfg.Server.GRPCListenAddress = "127.0.0.2"
+	// Default the GRPC to localhost only
+	if cfg.Server.GRPCListenAddress == "" {
+		cfg.Server.GRPCListenAddress = "127.0.0.2"
+	}
+	cfg.Server.GRPCMiddleware = append(cfg.Server.GRPCMiddleware,
+		route_helpers.LoggingInterceptor(cfg.Logger),
+		tracing.UnaryServerInterceptor("skind"),
+	)
+
+	tracingShutdown, err := tracing.Init(cfg.Tracing, "skind", cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg.McClient.CacheUUID.Logger = cfg.Logger
 	cacheUUID, err := cache_config.NewCache(cfg.McClient.CacheUUID)
@@ -75,10 +152,17 @@ func New(cfg Config) (*Skind, error) {
 		cacheTextures.Start()
 	}
 
+	if err := log.SetZapLevel(cfg.Server.LogLevel.String()); err != nil {
+		return nil, err
+	}
+
 	skind := &Skind{
-		Cfg:      cfg,
-		McClient: mcclient.NewMcClient(&cfg.McClient),
+		Cfg:             cfg,
+		McClient:        mcclient.NewMcClient(&cfg.McClient),
+		Settings:        route_helpers.NewLiveSettings(cfg.HandlerSettings()),
+		TracingShutdown: tracingShutdown,
 	}
+	skind.Reloader = reload.New(cfg.Logger, skind.applyReload, reloadableSettings)
 
 	skind.McClient.Caches.UUID = cacheUUID
 	skind.McClient.Caches.UserData = cacheUserData
@@ -92,27 +176,92 @@ func (s *Skind) Run() error {
 	if err := s.initServer(); err != nil {
 		return err
 	}
+	// Once the server stops (eg. SIGTERM), drain the requests and close the caches
+	defer s.Shutdown()
+	stopWatch := s.Reloader.Watch()
+	defer stopWatch()
 	// init other bits
+	if err := s.initAdminServer(); err != nil {
+		return err
+	}
 
 	return s.Server.Run()
 
 	//return nil
 }
 
+// Shutdown stops accepting requests and waits for the in-flight ones (up to the server.graceful-shutdown-timeout)
+// The caches are then closed once any pending inserts finish, and remaining spans are flushed
+func (s *Skind) Shutdown() {
+	s.Cfg.Logger.Info("Shutting down skind")
+	s.Server.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.Server.ServerGracefulShutdownTimeout)
+	defer cancel()
+	if s.AdminServer != nil {
+		if err := s.AdminServer.Shutdown(ctx); err != nil {
+			s.Cfg.Logger.Errorf("Admin API shutdown: %v", err)
+		}
+	}
+
+	s.McClient.Close()
+	s.Cfg.Logger.Info("Closed caches")
+	s.TracingShutdown(ctx)
+}
+
+// applyReload swaps in the response settings, the McClient upstream settings and TTL policy, and the log level
+// The caches (and anything else) keep the config they were created with
+func (s *Skind) applyReload() ([]string, error) {
+	if s.ConfigLoader == nil {
+		return nil, errors.New("no config loader to reload from")
+	}
+	cfg, changed, err := s.ConfigLoader()
+	if err != nil {
+		return nil, err
+	}
+	if err := log.SetZapLevel(cfg.Server.LogLevel.String()); err != nil {
+		return nil, err
+	}
+	s.Settings.Store(cfg.HandlerSettings())
+	s.McClient.Reload(&cfg.McClient)
+	return changed, nil
+}
+
 func (s *Skind) initServer() error {
 	serv, err := server.New(s.Cfg.Server)
 	if err != nil {
 		return err
 	}
 
-	//serv.HTTP.Use(route_helpers.LoggingMiddleware(s.Cfg.Logger))
+	serv.HTTP.Use(route_helpers.LoggingMiddleware(s.Cfg.Logger))
+	serv.HTTP.Use(tracing.Middleware("skind"))
 
-	if s.Cfg.CorsAllowAll {
-		serv.HTTP.Use(route_helpers.CorsHandler)
-	}
+	serv.HTTP.Use(s.Settings.CorsMiddleware)
 
 	s.Server = serv
 	s.routes()
 	return nil
 
 }
+
+func (s *Skind) initAdminServer() error {
+	adminServer, err := newAdminServer(s.Cfg.Admin, s.Cfg.Logger, s.McClient, s.Reloader.Reload)
+	if err != nil || adminServer == nil {
+		return err
+	}
+	s.AdminServer = adminServer
+
+	// Listen now so an unusable address fails the startup
+	listener, err := net.Listen("tcp", adminServer.Addr)
+	if err != nil {
+		return fmt.Errorf("admin API listen: %s", err)
+	}
+
+	go func() {
+		s.Cfg.Logger.Infof("Starting admin API on %s", listener.Addr())
+		if err := adminServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.Cfg.Logger.Errorf("Admin API failed: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,910 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.17.3
+// source: pkg/skind/skindpb/skind.proto
+
+package skindpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Status of the user lookup
+type Status int32
+
+const (
+	Status_UNSET                 Status = 0
+	Status_OK                    Status = 1
+	Status_ERROR_GENERIC         Status = 2
+	Status_ERROR_UNKNOWN_USER    Status = 3
+	Status_ERROR_RATE_LIMIT      Status = 4
+	Status_ERROR_INVALID_TEXTURE Status = 5
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "UNSET",
+		1: "OK",
+		2: "ERROR_GENERIC",
+		3: "ERROR_UNKNOWN_USER",
+		4: "ERROR_RATE_LIMIT",
+		5: "ERROR_INVALID_TEXTURE",
+	}
+	Status_value = map[string]int32{
+		"UNSET":                 0,
+		"OK":                    1,
+		"ERROR_GENERIC":         2,
+		"ERROR_UNKNOWN_USER":    3,
+		"ERROR_RATE_LIMIT":      4,
+		"ERROR_INVALID_TEXTURE": 5,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_skind_skindpb_skind_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_pkg_skind_skindpb_skind_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{0}
+}
+
+type SkinModel int32
+
+const (
+	SkinModel_CLASSIC SkinModel = 0
+	SkinModel_SLIM    SkinModel = 1
+)
+
+// Enum value maps for SkinModel.
+var (
+	SkinModel_name = map[int32]string{
+		0: "CLASSIC",
+		1: "SLIM",
+	}
+	SkinModel_value = map[string]int32{
+		"CLASSIC": 0,
+		"SLIM":    1,
+	}
+)
+
+func (x SkinModel) Enum() *SkinModel {
+	p := new(SkinModel)
+	*p = x
+	return p
+}
+
+func (x SkinModel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SkinModel) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_skind_skindpb_skind_proto_enumTypes[1].Descriptor()
+}
+
+func (SkinModel) Type() protoreflect.EnumType {
+	return &file_pkg_skind_skindpb_skind_proto_enumTypes[1]
+}
+
+func (x SkinModel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SkinModel.Descriptor instead.
+func (SkinModel) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{1}
+}
+
+type UserRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Either the username or the UUID (dashes are optional)
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Uuid     string `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	// The skin is not returned when its TextureID matches (like an If-None-Match)
+	IfNoneMatch string `protobuf:"bytes,3,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+}
+
+func (x *UserRequest) Reset() {
+	*x = UserRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRequest) ProtoMessage() {}
+
+func (x *UserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRequest.ProtoReflect.Descriptor instead.
+func (*UserRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *UserRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
+type SkinResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// PNG of the skin (empty when not_modified)
+	Skin        []byte    `protobuf:"bytes,1,opt,name=skin,proto3" json:"skin,omitempty"`
+	TextureId   string    `protobuf:"bytes,2,opt,name=texture_id,json=textureId,proto3" json:"texture_id,omitempty"`
+	Model       SkinModel `protobuf:"varint,3,opt,name=model,proto3,enum=skindpb.SkinModel" json:"model,omitempty"`
+	Status      Status    `protobuf:"varint,4,opt,name=status,proto3,enum=skindpb.Status" json:"status,omitempty"`
+	NotModified bool      `protobuf:"varint,5,opt,name=not_modified,json=notModified,proto3" json:"not_modified,omitempty"`
+	Uuid        string    `protobuf:"bytes,6,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Username    string    `protobuf:"bytes,7,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *SkinResponse) Reset() {
+	*x = SkinResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SkinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SkinResponse) ProtoMessage() {}
+
+func (x *SkinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SkinResponse.ProtoReflect.Descriptor instead.
+func (*SkinResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SkinResponse) GetSkin() []byte {
+	if x != nil {
+		return x.Skin
+	}
+	return nil
+}
+
+func (x *SkinResponse) GetTextureId() string {
+	if x != nil {
+		return x.TextureId
+	}
+	return ""
+}
+
+func (x *SkinResponse) GetModel() SkinModel {
+	if x != nil {
+		return x.Model
+	}
+	return SkinModel_CLASSIC
+}
+
+func (x *SkinResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_UNSET
+}
+
+func (x *SkinResponse) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+func (x *SkinResponse) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *SkinResponse) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type ProfileResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid      string    `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Username  string    `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	TextureId string    `protobuf:"bytes,3,opt,name=texture_id,json=textureId,proto3" json:"texture_id,omitempty"`
+	Model     SkinModel `protobuf:"varint,4,opt,name=model,proto3,enum=skindpb.SkinModel" json:"model,omitempty"`
+	Status    Status    `protobuf:"varint,5,opt,name=status,proto3,enum=skindpb.Status" json:"status,omitempty"`
+	// Unix time of the Mojang lookup
+	Timestamp int64 `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *ProfileResponse) Reset() {
+	*x = ProfileResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileResponse) ProtoMessage() {}
+
+func (x *ProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileResponse.ProtoReflect.Descriptor instead.
+func (*ProfileResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProfileResponse) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ProfileResponse) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ProfileResponse) GetTextureId() string {
+	if x != nil {
+		return x.TextureId
+	}
+	return ""
+}
+
+func (x *ProfileResponse) GetModel() SkinModel {
+	if x != nil {
+		return x.Model
+	}
+	return SkinModel_CLASSIC
+}
+
+func (x *ProfileResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_UNSET
+}
+
+func (x *ProfileResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ResolveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Uuid     string `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Status   Status `protobuf:"varint,3,opt,name=status,proto3,enum=skindpb.Status" json:"status,omitempty"`
+}
+
+func (x *ResolveResponse) Reset() {
+	*x = ResolveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveResponse) ProtoMessage() {}
+
+func (x *ResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveResponse.ProtoReflect.Descriptor instead.
+func (*ResolveResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ResolveResponse) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_UNSET
+}
+
+type BatchUserRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Users []*UserRequest `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+}
+
+func (x *BatchUserRequest) Reset() {
+	*x = BatchUserRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchUserRequest) ProtoMessage() {}
+
+func (x *BatchUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchUserRequest.ProtoReflect.Descriptor instead.
+func (*BatchUserRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchUserRequest) GetUsers() []*UserRequest {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type BatchSkinResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Skins []*SkinResponse `protobuf:"bytes,1,rep,name=skins,proto3" json:"skins,omitempty"`
+}
+
+func (x *BatchSkinResponse) Reset() {
+	*x = BatchSkinResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchSkinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSkinResponse) ProtoMessage() {}
+
+func (x *BatchSkinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSkinResponse.ProtoReflect.Descriptor instead.
+func (*BatchSkinResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchSkinResponse) GetSkins() []*SkinResponse {
+	if x != nil {
+		return x.Skins
+	}
+	return nil
+}
+
+type BatchProfileResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Profiles []*ProfileResponse `protobuf:"bytes,1,rep,name=profiles,proto3" json:"profiles,omitempty"`
+}
+
+func (x *BatchProfileResponse) Reset() {
+	*x = BatchProfileResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchProfileResponse) ProtoMessage() {}
+
+func (x *BatchProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchProfileResponse.ProtoReflect.Descriptor instead.
+func (*BatchProfileResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchProfileResponse) GetProfiles() []*ProfileResponse {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+type BatchResolveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Users []*ResolveResponse `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+}
+
+func (x *BatchResolveResponse) Reset() {
+	*x = BatchResolveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResolveResponse) ProtoMessage() {}
+
+func (x *BatchResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_skind_skindpb_skind_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResolveResponse.ProtoReflect.Descriptor instead.
+func (*BatchResolveResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_skind_skindpb_skind_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchResolveResponse) GetUsers() []*ResolveResponse {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+var File_pkg_skind_skindpb_skind_proto protoreflect.FileDescriptor
+
+var file_pkg_skind_skindpb_skind_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x2f, 0x73, 0x6b, 0x69, 0x6e,
+	0x64, 0x70, 0x62, 0x2f, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x07, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x22, 0x61, 0x0a, 0x0b, 0x55, 0x73, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x22, 0x0a, 0x0d, 0x69, 0x66, 0x5f, 0x6e, 0x6f,
+	0x6e, 0x65, 0x5f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x69, 0x66, 0x4e, 0x6f, 0x6e, 0x65, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x22, 0xe7, 0x01, 0x0a, 0x0c,
+	0x53, 0x6b, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x73, 0x6b, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x73, 0x6b, 0x69, 0x6e,
+	0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x65, 0x78, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x65, 0x78, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x12,
+	0x28, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12,
+	0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x53, 0x6b, 0x69, 0x6e, 0x4d, 0x6f, 0x64,
+	0x65, 0x6c, 0x52, 0x05, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x27, 0x0a, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x73, 0x6b, 0x69, 0x6e,
+	0x64, 0x70, 0x62, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6e, 0x6f, 0x74, 0x5f, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69,
+	0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6e, 0x6f, 0x74, 0x4d, 0x6f, 0x64,
+	0x69, 0x66, 0x69, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xd1, 0x01, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x1a, 0x0a,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x65, 0x78,
+	0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74,
+	0x65, 0x78, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x05, 0x6d, 0x6f, 0x64, 0x65,
+	0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70,
+	0x62, 0x2e, 0x53, 0x6b, 0x69, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x52, 0x05, 0x6d, 0x6f, 0x64,
+	0x65, 0x6c, 0x12, 0x27, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x6a, 0x0a, 0x0f, 0x52, 0x65, 0x73,
+	0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x27, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x73,
+	0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x3e, 0x0a, 0x10, 0x42, 0x61, 0x74, 0x63, 0x68, 0x55, 0x73,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x05, 0x75, 0x73, 0x65,
+	0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64,
+	0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x05,
+	0x75, 0x73, 0x65, 0x72, 0x73, 0x22, 0x40, 0x0a, 0x11, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x6b,
+	0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x73, 0x6b,
+	0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x6b, 0x69, 0x6e,
+	0x64, 0x70, 0x62, 0x2e, 0x53, 0x6b, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x52, 0x05, 0x73, 0x6b, 0x69, 0x6e, 0x73, 0x22, 0x4c, 0x0a, 0x14, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x34, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x70, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x22, 0x46, 0x0a, 0x14, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a,
+	0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73,
+	0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x2a, 0x77, 0x0a,
+	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45, 0x54,
+	0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x45, 0x52,
+	0x52, 0x4f, 0x52, 0x5f, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x49, 0x43, 0x10, 0x02, 0x12, 0x16, 0x0a,
+	0x12, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f, 0x55,
+	0x53, 0x45, 0x52, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x52,
+	0x41, 0x54, 0x45, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x10, 0x04, 0x12, 0x19, 0x0a, 0x15, 0x45,
+	0x52, 0x52, 0x4f, 0x52, 0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x54, 0x45, 0x58,
+	0x54, 0x55, 0x52, 0x45, 0x10, 0x05, 0x2a, 0x22, 0x0a, 0x09, 0x53, 0x6b, 0x69, 0x6e, 0x4d, 0x6f,
+	0x64, 0x65, 0x6c, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x4c, 0x41, 0x53, 0x53, 0x49, 0x43, 0x10, 0x00,
+	0x12, 0x08, 0x0a, 0x04, 0x53, 0x4c, 0x49, 0x4d, 0x10, 0x01, 0x32, 0x9a, 0x03, 0x0a, 0x05, 0x53,
+	0x6b, 0x69, 0x6e, 0x64, 0x12, 0x36, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x53, 0x6b, 0x69, 0x6e, 0x12,
+	0x14, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e,
+	0x53, 0x6b, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x0a,
+	0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x2e, 0x73, 0x6b, 0x69,
+	0x6e, 0x64, 0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x18, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x66, 0x69,
+	0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a, 0x0f, 0x52, 0x65,
+	0x73, 0x6f, 0x6c, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x2e,
+	0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x73, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x41, 0x0a,
+	0x08, 0x47, 0x65, 0x74, 0x53, 0x6b, 0x69, 0x6e, 0x73, 0x12, 0x19, 0x2e, 0x73, 0x6b, 0x69, 0x6e,
+	0x64, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x53, 0x6b, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x47, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12,
+	0x19, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x73, 0x6b, 0x69,
+	0x6e, 0x64, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x10, 0x52, 0x65, 0x73,
+	0x6f, 0x6c, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x19, 0x2e,
+	0x73, 0x6b, 0x69, 0x6e, 0x64, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x73, 0x6b, 0x69, 0x6e, 0x64,
+	0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2b, 0x5a, 0x29, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x69, 0x6e, 0x6f, 0x74, 0x61, 0x72, 0x2f, 0x69, 0x6d,
+	0x67, 0x64, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x6b, 0x69, 0x6e, 0x64, 0x2f, 0x73, 0x6b, 0x69,
+	0x6e, 0x64, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_skind_skindpb_skind_proto_rawDescOnce sync.Once
+	file_pkg_skind_skindpb_skind_proto_rawDescData = file_pkg_skind_skindpb_skind_proto_rawDesc
+)
+
+func file_pkg_skind_skindpb_skind_proto_rawDescGZIP() []byte {
+	file_pkg_skind_skindpb_skind_proto_rawDescOnce.Do(func() {
+		file_pkg_skind_skindpb_skind_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_skind_skindpb_skind_proto_rawDescData)
+	})
+	return file_pkg_skind_skindpb_skind_proto_rawDescData
+}
+
+var file_pkg_skind_skindpb_skind_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_pkg_skind_skindpb_skind_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_pkg_skind_skindpb_skind_proto_goTypes = []interface{}{
+	(Status)(0),                  // 0: skindpb.Status
+	(SkinModel)(0),               // 1: skindpb.SkinModel
+	(*UserRequest)(nil),          // 2: skindpb.UserRequest
+	(*SkinResponse)(nil),         // 3: skindpb.SkinResponse
+	(*ProfileResponse)(nil),      // 4: skindpb.ProfileResponse
+	(*ResolveResponse)(nil),      // 5: skindpb.ResolveResponse
+	(*BatchUserRequest)(nil),     // 6: skindpb.BatchUserRequest
+	(*BatchSkinResponse)(nil),    // 7: skindpb.BatchSkinResponse
+	(*BatchProfileResponse)(nil), // 8: skindpb.BatchProfileResponse
+	(*BatchResolveResponse)(nil), // 9: skindpb.BatchResolveResponse
+}
+var file_pkg_skind_skindpb_skind_proto_depIdxs = []int32{
+	1,  // 0: skindpb.SkinResponse.model:type_name -> skindpb.SkinModel
+	0,  // 1: skindpb.SkinResponse.status:type_name -> skindpb.Status
+	1,  // 2: skindpb.ProfileResponse.model:type_name -> skindpb.SkinModel
+	0,  // 3: skindpb.ProfileResponse.status:type_name -> skindpb.Status
+	0,  // 4: skindpb.ResolveResponse.status:type_name -> skindpb.Status
+	2,  // 5: skindpb.BatchUserRequest.users:type_name -> skindpb.UserRequest
+	3,  // 6: skindpb.BatchSkinResponse.skins:type_name -> skindpb.SkinResponse
+	4,  // 7: skindpb.BatchProfileResponse.profiles:type_name -> skindpb.ProfileResponse
+	5,  // 8: skindpb.BatchResolveResponse.users:type_name -> skindpb.ResolveResponse
+	2,  // 9: skindpb.Skind.GetSkin:input_type -> skindpb.UserRequest
+	2,  // 10: skindpb.Skind.GetProfile:input_type -> skindpb.UserRequest
+	2,  // 11: skindpb.Skind.ResolveUsername:input_type -> skindpb.UserRequest
+	6,  // 12: skindpb.Skind.GetSkins:input_type -> skindpb.BatchUserRequest
+	6,  // 13: skindpb.Skind.GetProfiles:input_type -> skindpb.BatchUserRequest
+	6,  // 14: skindpb.Skind.ResolveUsernames:input_type -> skindpb.BatchUserRequest
+	3,  // 15: skindpb.Skind.GetSkin:output_type -> skindpb.SkinResponse
+	4,  // 16: skindpb.Skind.GetProfile:output_type -> skindpb.ProfileResponse
+	5,  // 17: skindpb.Skind.ResolveUsername:output_type -> skindpb.ResolveResponse
+	7,  // 18: skindpb.Skind.GetSkins:output_type -> skindpb.BatchSkinResponse
+	8,  // 19: skindpb.Skind.GetProfiles:output_type -> skindpb.BatchProfileResponse
+	9,  // 20: skindpb.Skind.ResolveUsernames:output_type -> skindpb.BatchResolveResponse
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_pkg_skind_skindpb_skind_proto_init() }
+func file_pkg_skind_skindpb_skind_proto_init() {
+	if File_pkg_skind_skindpb_skind_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_skind_skindpb_skind_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UserRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SkinResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProfileResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResolveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchUserRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchSkinResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchProfileResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_skind_skindpb_skind_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchResolveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_skind_skindpb_skind_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_skind_skindpb_skind_proto_goTypes,
+		DependencyIndexes: file_pkg_skind_skindpb_skind_proto_depIdxs,
+		EnumInfos:         file_pkg_skind_skindpb_skind_proto_enumTypes,
+		MessageInfos:      file_pkg_skind_skindpb_skind_proto_msgTypes,
+	}.Build()
+	File_pkg_skind_skindpb_skind_proto = out.File
+	file_pkg_skind_skindpb_skind_proto_rawDesc = nil
+	file_pkg_skind_skindpb_skind_proto_goTypes = nil
+	file_pkg_skind_skindpb_skind_proto_depIdxs = nil
+}
@@ -8,16 +8,30 @@ import (
 	"github.com/minotar/imgd/pkg/util/log"
 )
 
+// Max number of keys to read in a single DB Transaction while iterating
+const ITERATE_CHUNK_SIZE = 1000
+
 // BadgerStore does not handle any GC (eg. BadgerStore.DB.RunValueLogGC())
 type BadgerStore struct {
 	DB   *badger.DB
 	path string
 }
 
-// ensure that the storage.Storage interface is implemented
+// ensure that the storage.Storage and storage.Iterator interfaces are implemented
 var _ storage.Storage = new(BadgerStore)
+var _ storage.Iterator = new(BadgerStore)
 
 func NewBadgerStore(path string, logger log.Logger) (*BadgerStore, error) {
+	return openBadgerStore(path, logger, false)
+}
+
+// NewBadgerStoreReadOnly opens an existing DB without taking the exclusive (write) lock
+// Writes to the returned BadgerStore will fail
+func NewBadgerStoreReadOnly(path string, logger log.Logger) (*BadgerStore, error) {
+	return openBadgerStore(path, logger, true)
+}
+
+func openBadgerStore(path string, logger log.Logger, readOnly bool) (*BadgerStore, error) {
 	loggerWithWarning := log.NewShimLoggerWarning(logger)
 	opts := badger.DefaultOptions(path)
 
@@ -29,6 +43,7 @@ func NewBadgerStore(path string, logger log.Logger) (*BadgerStore, error) {
 	// Default 10
 	opts = opts.WithNumLevelZeroTablesStall(2)
 	opts = opts.WithLogger(loggerWithWarning)
+	opts = opts.WithReadOnly(readOnly)
 	db, err := badger.Open(opts)
 	if err != nil {
 		return nil, err
@@ -110,6 +125,57 @@ func (bs *BadgerStore) Flush() error {
 	return nil
 }
 
+// ItemIterateFunc additionally receives the Badger expiresAt (Unix seconds, 0 being no expiry)
+type ItemIterateFunc func(key string, value []byte, expiresAt uint64) error
+
+// IterateItems is as Iterate, but exposes the expiry Badger has stored with each key
+func (bs *BadgerStore) IterateItems(opts storage.IterateOptions, fn ItemIterateFunc) (string, error) {
+	var values [][]byte
+	var expiries []uint64
+
+	iterOpts := badger.DefaultIteratorOptions
+	iterOpts.Prefix = []byte(opts.Prefix)
+
+	// Each chunk is its own transaction so that we aren't holding the DB open while keys are processed
+	loadChunk := func(seekKey string) (keys []string, next string, err error) {
+		values, expiries = values[:0], expiries[:0]
+		err = bs.DB.View(func(txn *badger.Txn) error {
+			it := txn.NewIterator(iterOpts)
+			defer it.Close()
+
+			for it.Seek([]byte(seekKey)); it.Valid(); it.Next() {
+				item := it.Item()
+				if len(keys) == ITERATE_CHUNK_SIZE {
+					next = string(item.Key())
+					return nil
+				}
+				value, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, string(item.Key()))
+				values = append(values, value)
+				expiries = append(expiries, item.ExpiresAt())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("iterating \"%s\": %s", seekKey, err)
+		}
+		return keys, next, nil
+	}
+
+	return storage.IterateChunked(opts, loadChunk, func(i int, key string) error {
+		return fn(key, values[i], expiries[i])
+	})
+}
+
+func (bs *BadgerStore) Iterate(opts storage.IterateOptions, fn storage.IterateFunc) (string, error) {
+	return bs.IterateItems(opts, func(key string, value []byte, _ uint64) error {
+		return fn(key, value)
+	})
+}
+
 func (bs *BadgerStore) Len() uint {
 	iterOpts := badger.DefaultIteratorOptions
 	iterOpts.PrefetchValues = false
@@ -1,6 +1,7 @@
 package bolt_store
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"time"
@@ -9,14 +10,18 @@ import (
 	"github.com/minotar/imgd/pkg/storage"
 )
 
+// Max number of keys to read in a single DB Transaction while iterating
+const ITERATE_CHUNK_SIZE = 1000
+
 type BoltStore struct {
 	DB     *bolt.DB
 	path   string
 	Bucket string
 }
 
-// ensure that the storage.Storage interface is implemented
+// ensure that the storage.Storage and storage.Iterator interfaces are implemented
 var _ storage.Storage = new(BoltStore)
+var _ storage.Iterator = new(BoltStore)
 
 func NewBoltStore(path, bucketname string) (*BoltStore, error) {
 	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
@@ -41,6 +46,39 @@ func NewBoltStore(path, bucketname string) (*BoltStore, error) {
 	return bs, nil
 }
 
+// NewBoltStoreReadOnly opens an existing bucket without taking the exclusive (write) lock
+// Writes to the returned BoltStore will fail
+func NewBoltStoreReadOnly(path, bucketname string) (*BoltStore, error) {
+	// Bolt would otherwise create (and fail to initialise) a missing file
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketname)) == nil {
+			return bolt.ErrBucketNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Unable to open bucket \"%s\": %s", bucketname, err)
+	}
+
+	bs := &BoltStore{
+		DB:     db,
+		path:   path,
+		Bucket: bucketname,
+	}
+
+	return bs, nil
+}
+
 func (bs *BoltStore) Insert(key string, value []byte) error {
 	err := bs.DB.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bs.Bucket))
@@ -112,6 +150,50 @@ func (bs *BoltStore) Flush() error {
 	return nil
 }
 
+func firstOrSeek(c *bolt.Cursor, seekKey string) (k, v []byte) {
+	if seekKey == "" {
+		return c.First()
+	}
+	return c.Seek([]byte(seekKey))
+}
+
+// loadChunk reads the next chunk of keys into values
+// Each chunk is its own transaction so that we aren't holding the DB open while keys are processed
+func (bs *BoltStore) loadChunk(prefix string, values *[][]byte) storage.ChunkLoader {
+	prefixBytes := []byte(prefix)
+
+	return func(seekKey string) (keys []string, next string, err error) {
+		*values = (*values)[:0]
+		err = bs.DB.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket([]byte(bs.Bucket)).Cursor()
+
+			for k, v := firstOrSeek(c, seekKey); k != nil && bytes.HasPrefix(k, prefixBytes); k, v = c.Next() {
+				if len(keys) == ITERATE_CHUNK_SIZE {
+					next = string(k)
+					return nil
+				}
+				// Set byte slice length for copy
+				data := make([]byte, len(v))
+				copy(data, v)
+				keys = append(keys, string(k))
+				*values = append(*values, data)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("iterating \"%s\" from \"%s\": %s", seekKey, bs.Bucket, err)
+		}
+		return keys, next, nil
+	}
+}
+
+func (bs *BoltStore) Iterate(opts storage.IterateOptions, fn storage.IterateFunc) (string, error) {
+	var values [][]byte
+	return storage.IterateChunked(opts, bs.loadChunk(opts.Prefix, &values), func(i int, key string) error {
+		return fn(key, values[i])
+	})
+}
+
 func (bs *BoltStore) Len() uint {
 	var keyCount uint
 
@@ -133,6 +215,10 @@ func (bs *BoltStore) Size() uint64 {
 	return uint64(fileInfo.Size())
 }
 
+// Close syncs any writes (which may be skipped with NoSync) before closing the DB
 func (bs *BoltStore) Close() {
+	if !bs.DB.IsReadOnly() {
+		bs.DB.Sync()
+	}
 	bs.DB.Close()
 }
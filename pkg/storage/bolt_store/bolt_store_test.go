@@ -1,6 +1,7 @@
 package bolt_store
 
 import (
+	"sort"
 	"strconv"
 	"testing"
 	"time"
@@ -83,6 +84,38 @@ func TestInsertAndDelete(t *testing.T) {
 	}
 }
 
+func TestIterate(t *testing.T) {
+	store := freshStore()
+	defer store.Close()
+
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = test_helpers.RandString(32)
+		store.Insert(keys[i], []byte(keys[i]))
+	}
+	sort.Strings(keys)
+
+	var visited []string
+	marker, err := store.Iterate(storage.IterateOptions{StartKey: keys[2], Limit: 5}, func(key string, value []byte) error {
+		if string(value) != key {
+			t.Errorf("%+v did not match %s", value, key)
+		}
+		visited = append(visited, key)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Iterate should not be an error: %s", err)
+	}
+	if marker != keys[7] {
+		t.Errorf("Iterate marker should be %s, not: %s", keys[7], marker)
+	}
+	for i, key := range visited {
+		if key != keys[i+2] {
+			t.Errorf("Iterate key %d should be %s, not: %s", i, keys[i+2], key)
+		}
+	}
+}
+
 func TestHousekeeping(t *testing.T) {
 	store := freshStore()
 	defer store.Close()
@@ -107,6 +140,30 @@ func TestHousekeeping(t *testing.T) {
 	}
 }
 
+func TestReadOnly(t *testing.T) {
+	store := freshStore()
+	key := test_helpers.RandString(32)
+	store.Insert(key, []byte("var"))
+	store.Close()
+
+	roStore, err := NewBoltStoreReadOnly(TestBoltPath, TestBoltBucketName)
+	if err != nil {
+		t.Fatalf("Opening read-only store should not error: %s", err)
+	}
+	defer roStore.Close()
+
+	if v, err := roStore.Retrieve(key); err != nil || string(v) != "var" {
+		t.Errorf("Read-only Retrieve should return \"var\", not: %s %v", v, err)
+	}
+	if err := roStore.Insert(test_helpers.RandString(32), []byte("var")); err == nil {
+		t.Errorf("Read-only Insert should return an error")
+	}
+
+	if _, err := NewBoltStoreReadOnly(TestBoltPath, "missing_bucket"); err == nil {
+		t.Errorf("Read-only store with a missing bucket should return an error")
+	}
+}
+
 var largeBucket = test_store.NewTestStoreBench()
 
 func BenchmarkInsert(b *testing.B) {
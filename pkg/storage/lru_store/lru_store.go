@@ -1,6 +1,8 @@
 package lru_store
 
 import (
+	"sort"
+
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/minotar/imgd/pkg/storage"
 )
@@ -9,8 +11,9 @@ type LruStore struct {
 	store *lru.Cache
 }
 
-// ensure that the storage.Storage interface is implemented
+// ensure that the storage.Storage and storage.Iterator interfaces are implemented
 var _ storage.Storage = new(LruStore)
+var _ storage.Iterator = new(LruStore)
 
 func NewLruStore(maxEntries int) (*LruStore, error) {
 	return NewLruStoreWithEvict(maxEntries, nil)
@@ -50,6 +53,36 @@ func (ls *LruStore) Flush() error {
 	return nil
 }
 
+// Iterate works from a sorted snapshot of the keys, so they are still visited in order
+// Peek is used so that iterating does not affect which keys are recently used
+func (ls *LruStore) Iterate(opts storage.IterateOptions, fn storage.IterateFunc) (string, error) {
+	var values [][]byte
+
+	// The whole store is loaded as a single chunk
+	loadChunk := func(seekKey string) (keys []string, next string, err error) {
+		for _, k := range ls.store.Keys() {
+			if key := k.(string); opts.Match(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		// Keys may have been evicted since the snapshot
+		found := keys[:0]
+		for _, key := range keys {
+			if value, ok := ls.store.Peek(key); ok {
+				found = append(found, key)
+				values = append(values, value.([]byte))
+			}
+		}
+		return found, "", nil
+	}
+
+	return storage.IterateChunked(opts, loadChunk, func(i int, key string) error {
+		return fn(key, values[i])
+	})
+}
+
 func (ls *LruStore) Len() uint {
 	return uint(ls.store.Len())
 }
@@ -2,6 +2,7 @@ package lru_store
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"testing"
 
@@ -47,6 +48,37 @@ func TestInsertAndDelete(t *testing.T) {
 	}
 }
 
+func TestIterate(t *testing.T) {
+	store, _ := NewLruStore(10)
+
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = test_helpers.RandString(32)
+		store.Insert(keys[i], []byte(keys[i]))
+	}
+	sort.Strings(keys)
+
+	var visited []string
+	marker, err := store.Iterate(storage.IterateOptions{StartKey: keys[2], Limit: 5}, func(key string, value []byte) error {
+		if string(value) != key {
+			t.Errorf("%+v did not match %s", value, key)
+		}
+		visited = append(visited, key)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Iterate should not be an error: %s", err)
+	}
+	if marker != keys[7] {
+		t.Errorf("Iterate marker should be %s, not: %s", keys[7], marker)
+	}
+	for i, key := range visited {
+		if key != keys[i+2] {
+			t.Errorf("Iterate key %d should be %s, not: %s", i, keys[i+2], key)
+		}
+	}
+}
+
 func TestHousekeeping(t *testing.T) {
 	store, _ := NewLruStore(5)
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"errors"
+	"strings"
 )
 
 type Storage interface {
@@ -24,11 +25,97 @@ type Storage interface {
 	Close()
 }
 
+// Iterator is optionally implemented by a Storage which is able to enumerate its keys
+type Iterator interface {
+	// Iterate calls fn for each key matching the options, in ascending key order.
+	// The returned marker is the next key to be visited and can be used as the
+	// StartKey to resume the iteration. An empty marker means every key was visited.
+	Iterate(opts IterateOptions, fn IterateFunc) (marker string, err error)
+}
+
+// IterateFunc is called with each key/value visited by an Iterator
+// Returning ErrStopIteration ends the iteration early without an error
+type IterateFunc func(key string, value []byte) error
+
+type IterateOptions struct {
+	// Prefix limits the iteration to keys starting with Prefix
+	Prefix string
+	// StartKey is where to start (inclusive) - eg. a marker from a previous Iterate
+	StartKey string
+	// Limit is the max number of keys to scan before returning a marker (0 is unlimited)
+	Limit int
+}
+
+// SeekKey is the first possible key matching both the Prefix and StartKey
+func (o IterateOptions) SeekKey() string {
+	if o.StartKey > o.Prefix {
+		return o.StartKey
+	}
+	return o.Prefix
+}
+
+// Match reports whether key is within the range of the options
+func (o IterateOptions) Match(key string) bool {
+	return strings.HasPrefix(key, o.Prefix) && key >= o.StartKey
+}
+
 // Errors
 var (
-	ErrNotFound = errors.New("key does not exist")
+	ErrNotFound              = errors.New("key does not exist")
+	ErrStopIteration         = errors.New("iteration was stopped")
+	ErrIterationNotSupported = errors.New("store does not support iteration")
 )
 
+// Iterate will use the Iterator of the store, if it has one
+func Iterate(store Storage, opts IterateOptions, fn IterateFunc) (string, error) {
+	iterator, ok := store.(Iterator)
+	if !ok {
+		return "", ErrIterationNotSupported
+	}
+	return iterator.Iterate(opts, fn)
+}
+
+// ChunkLoader loads a sorted chunk of keys, starting from seekKey (inclusive), for IterateChunked
+// next is the key following the chunk, or empty if there are no more keys
+type ChunkLoader func(seekKey string) (keys []string, next string, err error)
+
+// IterateChunked handles the Limit and marker logic for an Iterator which loads its keys in chunks
+// visit is called with the index (within the last loaded chunk) and key of each key in turn
+func IterateChunked(opts IterateOptions, loadChunk ChunkLoader, visit func(i int, key string) error) (string, error) {
+	var scanned int
+	seekKey := opts.SeekKey()
+
+	for {
+		keys, next, err := loadChunk(seekKey)
+		if err != nil {
+			return seekKey, err
+		}
+
+		for i, key := range keys {
+			if opts.Limit > 0 && scanned >= opts.Limit {
+				return key, nil
+			}
+			scanned++
+
+			err := visit(i, key)
+			if err == ErrStopIteration {
+				if i+1 < len(keys) {
+					return keys[i+1], nil
+				}
+				return next, nil
+			} else if err != nil {
+				// The failed key is used as the marker so it's retried when resuming
+				return key, err
+			}
+		}
+
+		if next == "" || (opts.Limit > 0 && scanned >= opts.Limit) {
+			return next, nil
+		}
+		seekKey = next
+	}
+}
+
 func InsertKV(store Storage, key, value string) error {
 	return store.Insert(key, []byte(value))
 }
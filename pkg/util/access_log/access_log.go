@@ -0,0 +1,233 @@
+// access_log records what happened during a request (a lookup report), so a single structured line can be logged
+// and metrics observed at the end of it
+// The Entry is carried on the request context - every method is safe to call on a nil Entry (eg. outside of a request)
+package access_log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 64
+)
+
+type ctxKey uint8
+
+const (
+	ctxKeyEntry ctxKey = 0
+)
+
+// Entry is the request-scoped record used for the access log line
+type Entry struct {
+	RequestID string
+	Logger    log.Logger
+
+	mu       sync.Mutex
+	caches   map[string]string
+	upstream []string
+	fallback string
+	// Time spent in each stage (eg. "cache", "upstream", "render") in the order first seen
+	stages  []string
+	timings map[string]time.Duration
+}
+
+func NewEntry(requestID string, logger log.Logger) *Entry {
+	return &Entry{
+		RequestID: requestID,
+		Logger:    logger.With("request_id", requestID),
+		caches:    make(map[string]string),
+		timings:   make(map[string]time.Duration),
+	}
+}
+
+func NewContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, ctxKeyEntry, entry)
+}
+
+// FromContext returns the Entry of the request (or nil)
+func FromContext(ctx context.Context) *Entry {
+	entry, _ := ctx.Value(ctxKeyEntry).(*Entry)
+	return entry
+}
+
+// Logger returns the request logger (With the request ID) or the fallback when there is no Entry
+func Logger(ctx context.Context, fallback log.Logger) log.Logger {
+	if entry := FromContext(ctx); entry != nil {
+		return entry.Logger
+	}
+	return fallback
+}
+
+// RequestID returns the ID of the request (or "" when there is no Entry)
+func RequestID(ctx context.Context) string {
+	if entry := FromContext(ctx); entry != nil {
+		return entry.RequestID
+	}
+	return ""
+}
+
+// ValidRequestID checks a given ID is safe to log and forward
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Uniqueness only matters for correlation
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetCache records the outcome of a cache lookup (eg. "uuid" "fresh")
+// A later outcome for the same cache replaces the earlier one (eg. "hit" then "stale")
+func (e *Entry) SetCache(cacheName, outcome string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.caches[cacheName] = outcome
+}
+
+// AddUpstream records a call to an upstream API (the duration is added to the "upstream" stage)
+func (e *Entry) AddUpstream(source string, duration time.Duration, err error) {
+	if e == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.upstream = append(e.upstream, fmt.Sprintf("%s=%s/%dms", source, result, duration.Milliseconds()))
+	e.addTiming("upstream", duration)
+}
+
+// AddTiming adds the duration to the total of the stage
+func (e *Entry) AddTiming(stage string, duration time.Duration) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.addTiming(stage, duration)
+}
+
+// AddTimingSince adds the time since start to the stage (eg. `defer entry.AddTimingSince("render", time.Now())`)
+func (e *Entry) AddTimingSince(stage string, start time.Time) {
+	e.AddTiming(stage, time.Since(start))
+}
+
+func (e *Entry) addTiming(stage string, duration time.Duration) {
+	if _, ok := e.timings[stage]; !ok {
+		e.stages = append(e.stages, stage)
+	}
+	e.timings[stage] += duration
+}
+
+// SetFallback records why the default (Steve) skin was used instead
+func (e *Entry) SetFallback(reason string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fallback = reason
+}
+
+func (e *Entry) Fallback() string {
+	if e == nil {
+		return ""
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.fallback
+}
+
+// CacheOutcomes returns a copy of the outcome of each cache
+func (e *Entry) CacheOutcomes() map[string]string {
+	outcomes := make(map[string]string)
+	if e == nil {
+		return outcomes
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name, outcome := range e.caches {
+		outcomes[name] = outcome
+	}
+	return outcomes
+}
+
+// Timings calls fn with the total of each stage, in the order the stages were first seen
+func (e *Entry) Timings(fn func(stage string, duration time.Duration)) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	stages := append([]string(nil), e.stages...)
+	timings := make(map[string]time.Duration, len(e.timings))
+	for stage, duration := range e.timings {
+		timings[stage] = duration
+	}
+	e.mu.Unlock()
+
+	for _, stage := range stages {
+		fn(stage, timings[stage])
+	}
+}
+
+// ServerTiming formats the stage timings for a Server-Timing header (eg. "cache;dur=0.52, upstream;dur=101.30")
+func (e *Entry) ServerTiming() string {
+	var metrics []string
+	e.Timings(func(stage string, duration time.Duration) {
+		metrics = append(metrics, fmt.Sprintf("%s;dur=%.2f", stage, float64(duration.Microseconds())/1000))
+	})
+	return strings.Join(metrics, ", ")
+}
+
+// Caches returns the cache outcomes as "name=outcome" sorted by name
+func (e *Entry) Caches() string {
+	if e == nil {
+		return ""
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	outcomes := make([]string, 0, len(e.caches))
+	for name, outcome := range e.caches {
+		outcomes = append(outcomes, name+"="+outcome)
+	}
+	sort.Strings(outcomes)
+	return strings.Join(outcomes, ",")
+}
+
+// Upstream returns the upstream calls in the order they were made
+func (e *Entry) Upstream() []string {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.upstream...)
+}
@@ -2,20 +2,87 @@ package cfg
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/kamaln7/envy"
 	"github.com/spf13/pflag"
 )
 
+const (
+	configFileFlag = "config.file"
+	configDumpFlag = "config.dump"
+)
+
 type Registerer interface {
 	RegisterFlags(*flag.FlagSet)
 }
 
-func Parse(r Registerer, envName string) {
+// Validator is optionally implemented by a config to check the values once all the layers are applied
+type Validator interface {
+	Validate() error
+}
+
+// Parse layers the configuration as defaults < YAML config file < env vars (eg. SKIND_SKIND_USE_ETAGS) < flags
+// The config file is set with -config.file (or the <envName>_CONFIG_FILE env var)
+// With -config.dump, the effective configuration is printed as YAML and the process exits
+func Parse(r Registerer, envName string) error {
 	fs := flag.CommandLine
 	r.RegisterFlags(fs)
+	fs.String(configFileFlag, "", "YAML file of settings, keyed by the flag names (flags and env vars take precedence)")
+	dump := fs.Bool(configDumpFlag, false, "Print the effective configuration as YAML and exit")
+
+	if configFile := configFilePath(envName); configFile != "" {
+		if err := LoadFile(fs, configFile); err != nil {
+			return err
+		}
+	}
 
 	envy.Parse(envName)
 	pflag.CommandLine.AddGoFlagSet(fs)
 	pflag.Parse()
+
+	if v, ok := r.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	if *dump {
+		if err := Dump(os.Stdout, fs); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+	return nil
+}
+
+// configFilePath is the config file given in the args, or otherwise the <envName>_CONFIG_FILE env var
+func configFilePath(envName string) string {
+	if configFile := configFileArg(os.Args[1:]); configFile != "" {
+		return configFile
+	}
+	return os.Getenv(envName + "_CONFIG_FILE")
+}
+
+// configFileArg finds the config file in the args, before the flags are parsed
+// Both "--config.file=x" and "--config.file x" (with one or two dashes) are accepted
+func configFileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if name == configFileFlag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, configFileFlag+"=") {
+			return strings.TrimPrefix(name, configFileFlag+"=")
+		}
+	}
+	return ""
 }
@@ -0,0 +1,161 @@
+package cfg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Port  int
+	TTL   time.Duration
+	Path  string
+	Token string
+	ETags bool
+}
+
+func (c *testConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&c.Port, "server.http-listen-port", 80, "")
+	f.DurationVar(&c.TTL, "skind.cache-control-ttl", time.Hour, "")
+	f.StringVar(&c.Path, "cache.uuid.bolt-path", "/tmp/uuid.db", "")
+	f.StringVar(&c.Token, "skind.admin.token", "", "")
+	f.BoolVar(&c.ETags, "skind.use-etags", true, "")
+}
+
+func newTestFlags() (*flag.FlagSet, *testConfig) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := &testConfig{}
+	c.RegisterFlags(fs)
+	return fs, c
+}
+
+func TestLoad(t *testing.T) {
+	fs, c := newTestFlags()
+	err := Load(fs, []byte(`
+server:
+  http-listen-port: 4643
+skind:
+  cache-control-ttl: 2h
+  use-etags: false
+cache.uuid.bolt-path: /skind/uuid.db
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expected := testConfig{Port: 4643, TTL: 2 * time.Hour, Path: "/skind/uuid.db", ETags: false}
+	if *c != expected {
+		t.Errorf("Config should be %+v, not %+v", expected, *c)
+	}
+
+	// Env vars only override flags which were not explicitly set
+	fs.Visit(func(f *flag.Flag) {
+		t.Errorf("Flag %s should not be marked as set by the config file", f.Name)
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := map[string]string{
+		"skind:\n  cache-control-tl: 2h\n":    `unknown setting "skind.cache-control-tl"`,
+		"skind:\n  cache-control-ttl: soon\n": `invalid value "soon" for "skind.cache-control-ttl"`,
+		"server:\n  http-listen-port:\n":      `"server.http-listen-port" has no value`,
+		"config.file: other.yaml\n":           `unknown setting "config.file"`,
+		"skind: [\n":                          "yaml",
+	}
+
+	for data, expected := range testCases {
+		fs, _ := newTestFlags()
+		fs.String(configFileFlag, "", "")
+		err := Load(fs, []byte(data))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Loading %q should error with \"%s\", got: %v", data, expected, err)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	fs, c := newTestFlags()
+	c.Token = "secret"
+	fs.Set("server.http-listen-port", "4643")
+
+	var buf bytes.Buffer
+	if err := Dump(&buf, fs); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Dump should hide the token:\n%s", buf.String())
+	}
+
+	// The dump should load back to the same config (apart from the hidden token)
+	loadedFs, loaded := newTestFlags()
+	if err := Load(loadedFs, buf.Bytes()); err != nil {
+		t.Fatalf("Loading the dump failed: %v\n%s", err, buf.String())
+	}
+	loaded.Token = c.Token
+	if *loaded != *c {
+		t.Errorf("Loaded dump should be %+v, not %+v", *c, *loaded)
+	}
+}
+
+func TestConfigFileArg(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--config.file", "a.yaml"}, "a.yaml"},
+		{[]string{"--skind.use-etags", "-config.file=b.yaml"}, "b.yaml"},
+		{[]string{"config.file", "c.yaml"}, ""},
+		{[]string{"--", "--config.file=d.yaml"}, ""},
+		{[]string{"--config.file"}, ""},
+	}
+	for _, tc := range testCases {
+		if configFile := configFileArg(tc.args); configFile != tc.expected {
+			t.Errorf("Config file of %v should be \"%s\", not \"%s\"", tc.args, tc.expected, configFile)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("skind:\n  cache-control-ttl: 2h\n  use-etags: false\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TESTRELOAD_SKIND_USE_ETAGS", "true")
+	defer os.Unsetenv("TESTRELOAD_SKIND_USE_ETAGS")
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"skind", "--config.file", configFile, "--server.http-listen-port=4643", "--unrelated"}
+
+	// The startup config
+	runningFs, _ := newTestFlags()
+	runningFs.Set("server.http-listen-port", "4643")
+	startupFlags = runningFs
+	defer func() { startupFlags = flag.CommandLine }()
+
+	var c testConfig
+	changed, err := Reload(&c, "TESTRELOAD")
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	// The layers are applied as with Parse (config file < env var < flag)
+	expected := testConfig{Port: 4643, TTL: 2 * time.Hour, Path: "/tmp/uuid.db", ETags: true}
+	if c != expected {
+		t.Errorf("Config should be %+v, not %+v", expected, c)
+	}
+	if !reflect.DeepEqual(changed, []string{"skind.cache-control-ttl"}) {
+		t.Errorf("Only the TTL should have changed, not: %v", changed)
+	}
+
+	// The next reload is still compared to the startup config (eg. a change which needs a restart is reported again)
+	changed, err = Reload(&testConfig{}, "TESTRELOAD")
+	if err != nil || !reflect.DeepEqual(changed, []string{"skind.cache-control-ttl"}) {
+		t.Errorf("Reloading again should report the same change: %v %v", changed, err)
+	}
+}
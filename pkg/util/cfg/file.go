@@ -0,0 +1,178 @@
+package cfg
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Settings which are not part of the config file (or the dump)
+var fileIgnoredFlags = map[string]bool{
+	configFileFlag: true,
+	configDumpFlag: true,
+	"version":      true,
+}
+
+// LoadFile applies a YAML config file to the flags
+// The keys are the flag names, which can be nested on the dots, eg. both of these set "cache.uuid.bolt-path":
+//
+//	cache:
+//	  uuid:
+//	    bolt-path: /skind/bolt_cache_uuid.db
+//	cache.uuid.bolt-path: /skind/bolt_cache_uuid.db
+func LoadFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	if err := Load(fs, data); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load applies the YAML settings to the flags
+// The flag values are set directly, so the flags are not marked as set (env vars can still override them)
+func Load(fs *flag.FlagSet, data []byte) error {
+	var settings yaml.MapSlice
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+
+	values := make(map[string][]string)
+	if err := flatten("", settings, values); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || fileIgnoredFlags[name] {
+			errs = append(errs, fmt.Sprintf("unknown setting \"%s\"", name))
+			continue
+		}
+		for _, value := range values[name] {
+			if err := f.Value.Set(value); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid value \"%s\" for \"%s\": %v", value, name, err))
+			}
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// flatten joins the nested keys with dots
+// A list is set one item at a time (for flags which can be repeated)
+func flatten(prefix string, settings yaml.MapSlice, values map[string][]string) error {
+	for _, item := range settings {
+		key := fmt.Sprint(item.Key)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value := item.Value.(type) {
+		case yaml.MapSlice:
+			if err := flatten(key, value, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, v := range value {
+				if _, ok := v.(yaml.MapSlice); ok {
+					return fmt.Errorf("\"%s\" must be a list of values", key)
+				}
+				values[key] = append(values[key], fmt.Sprint(v))
+			}
+		case nil:
+			return fmt.Errorf("\"%s\" has no value", key)
+		default:
+			values[key] = append(values[key], fmt.Sprint(value))
+		}
+	}
+	return nil
+}
+
+// secretFlag hides values such as the admin token in the dump
+func secretFlag(name string) bool {
+	for _, suffix := range []string{"token", "auth", "password", "secret"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpValue uses the typed value where possible (so the YAML is not all strings)
+func dumpValue(f *flag.Flag) interface{} {
+	if secretFlag(f.Name) && f.Value.String() != "" {
+		return "********"
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return f.Value.String()
+	}
+	switch value := getter.Get().(type) {
+	case time.Duration:
+		return value.String()
+	case bool, int, int64, uint, uint64, float64, string:
+		return value
+	default:
+		return f.Value.String()
+	}
+}
+
+// Dump writes the value of every flag as YAML (in the format read by LoadFile)
+func Dump(w io.Writer, fs *flag.FlagSet) error {
+	var settings yaml.MapSlice
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || fileIgnoredFlags[f.Name] {
+			return
+		}
+		settings, err = insertSetting(settings, strings.Split(f.Name, "."), dumpValue(f))
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// insertSetting nests the value under each part of the flag name
+func insertSetting(settings yaml.MapSlice, parts []string, value interface{}) (yaml.MapSlice, error) {
+	if len(parts) == 1 {
+		return append(settings, yaml.MapItem{Key: parts[0], Value: value}), nil
+	}
+	for i, item := range settings {
+		if item.Key != parts[0] {
+			continue
+		}
+		nested, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return nil, fmt.Errorf("setting \"%s\" is both a value and a section", parts[0])
+		}
+		nested, err := insertSetting(nested, parts[1:], value)
+		settings[i].Value = nested
+		return settings, err
+	}
+	nested, err := insertSetting(nil, parts[1:], value)
+	return append(settings, yaml.MapItem{Key: parts[0], Value: nested}), err
+}
@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// startupFlags are the flags the service started with, which each reload is compared to
+// A reload only applies some settings, so a change which needs a restart is reported until then
+var startupFlags = flag.CommandLine
+
+// Reload parses the configuration again into r, which should be a new (unregistered) config
+// The same layers as Parse are used (the config file is re-read, and the env vars and args are applied again)
+// The names of the settings which differ from the startup config are returned
+func Reload(r Registerer, envName string) ([]string, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	r.RegisterFlags(fs)
+	fs.String(configFileFlag, "", "")
+	fs.Bool(configDumpFlag, false, "")
+
+	if configFile := configFilePath(envName); configFile != "" {
+		if err := LoadFile(fs, configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(fs, envName); err != nil {
+		return nil, err
+	}
+
+	pfs := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	pfs.SetOutput(io.Discard)
+	// Flags registered outside of the config (eg. by libraries) are not reloaded
+	pfs.ParseErrorsWhitelist.UnknownFlags = true
+	pfs.AddGoFlagSet(fs)
+	if err := pfs.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
+
+	if v, ok := r.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	return Changed(startupFlags, fs), nil
+}
+
+// applyEnv sets each flag from its env var, named in the same way as envy (eg. SKIND_SKIND_USE_ETAGS)
+func applyEnv(fs *flag.FlagSet, envName string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		envVar := strings.NewReplacer("-", "_", ".", "_").Replace(envName + "_" + strings.ToUpper(f.Name))
+		if val := os.Getenv(envVar); val != "" && err == nil {
+			if setErr := fs.Set(f.Name, val); setErr != nil {
+				err = fmt.Errorf("invalid value \"%s\" for %s: %v", val, envVar, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// Changed lists the settings of next which have a different value in prev (or are new)
+func Changed(prev, next *flag.FlagSet) []string {
+	var changed []string
+	next.VisitAll(func(f *flag.Flag) {
+		if fileIgnoredFlags[f.Name] {
+			return
+		}
+		if p := prev.Lookup(f.Name); p == nil || p.Value.String() != f.Value.String() {
+			changed = append(changed, f.Name)
+		}
+	})
+	return changed
+}
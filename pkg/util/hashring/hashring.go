@@ -0,0 +1,101 @@
+// Package hashring maps keys to nodes by consistent hashing
+// Adding or removing a node only moves the keys of that node, so each node keeps a stable share of the keys
+package hashring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of points each node has on the ring (more points give a more even share)
+const DefaultReplicas = 128
+
+// Ring is immutable - create a new Ring when the nodes change
+type Ring struct {
+	nodes  []string
+	points []uint64
+	// The node of each point
+	owners map[uint64]string
+}
+
+// hash is FNV-1a, which alone mixes the last bytes of a key poorly into the high bits (so similar keys would cluster)
+// The result is therefore finalised as in MurmurHash3
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+	return sum
+}
+
+// New creates a Ring of the (de-duplicated) nodes, each with replicas points
+func New(nodes []string, replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{owners: make(map[uint64]string)}
+
+	for _, node := range nodes {
+		if containsNode(r.nodes, node) {
+			continue
+		}
+		r.nodes = append(r.nodes, node)
+		for i := 0; i < replicas; i++ {
+			point := hash(strconv.Itoa(i) + "-" + node)
+			// A (rare) collision keeps the first owner
+			if _, exists := r.owners[point]; exists {
+				continue
+			}
+			r.owners[point] = node
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Nodes returns the nodes of the Ring (in the order they were given)
+func (r *Ring) Nodes() []string {
+	return append([]string(nil), r.nodes...)
+}
+
+// Len is the number of nodes
+func (r *Ring) Len() int {
+	return len(r.nodes)
+}
+
+// Lookup returns up to n distinct nodes for the key
+// The first is the owner of the key, with the rest being the next nodes around the ring (eg. for a retry)
+func (r *Ring) Lookup(key string, n int) []string {
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	keyHash := hash(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= keyHash })
+	nodes := make([]string, 0, n)
+	for i := 0; i < len(r.points) && len(nodes) < n; i++ {
+		node := r.owners[r.points[(start+i)%len(r.points)]]
+		if !containsNode(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func containsNode(nodes []string, node string) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
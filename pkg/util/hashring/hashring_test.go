@@ -0,0 +1,76 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+var testNodes = []string{"http://skind-0:4643/skin/", "http://skind-1:4643/skin/", "http://skind-2:4643/skin/"}
+
+func testKeys(count int) []string {
+	keys := make([]string, count)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user%d", i)
+	}
+	return keys
+}
+
+func TestLookup(t *testing.T) {
+	ring := New(append(testNodes, testNodes[0]), 0)
+	if ring.Len() != len(testNodes) {
+		t.Fatalf("Duplicate nodes should be ignored, expected %d nodes, not: %d", len(testNodes), ring.Len())
+	}
+
+	nodes := ring.Lookup("clone1018", 5)
+	if len(nodes) != len(testNodes) {
+		t.Fatalf("Lookup should be limited to the %d nodes, not: %v", len(testNodes), nodes)
+	}
+	if nodes[0] == nodes[1] || nodes[1] == nodes[2] || nodes[0] == nodes[2] {
+		t.Errorf("Lookup should return distinct nodes, not: %v", nodes)
+	}
+	if again := ring.Lookup("clone1018", 1); again[0] != nodes[0] {
+		t.Errorf("Lookup should be consistent, expected %s, not: %s", nodes[0], again[0])
+	}
+
+	if nodes := New(nil, 0).Lookup("clone1018", 1); len(nodes) != 0 {
+		t.Errorf("An empty ring should not return nodes, not: %v", nodes)
+	}
+}
+
+func TestBalance(t *testing.T) {
+	// Nodes which only differ in a few characters should still get an even share
+	similarNodes := []string{"http://127.0.0.1:43955/skin/", "http://127.0.0.1:43956/skin/"}
+
+	for _, nodes := range [][]string{testNodes, similarNodes} {
+		ring := New(nodes, 0)
+		keys := testKeys(30000)
+
+		counts := make(map[string]int)
+		for _, key := range keys {
+			counts[ring.Lookup(key, 1)[0]]++
+		}
+		// Each node should be within 25% of an even share
+		even := len(keys) / len(nodes)
+		for _, node := range nodes {
+			if counts[node] < even*3/4 || counts[node] > even*5/4 {
+				t.Errorf("Node %s has an uneven share: %d (expected ~%d)", node, counts[node], even)
+			}
+		}
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	ring := New(testNodes, 0)
+	smaller := New(testNodes[:2], 0)
+
+	for _, key := range testKeys(1000) {
+		before, after := ring.Lookup(key, 2), smaller.Lookup(key, 1)[0]
+		// Only the keys of the removed node should move, and then to the next node of the ring
+		if before[0] != testNodes[2] && before[0] != after {
+			t.Errorf("Key %s moved from %s to %s", key, before[0], after)
+		}
+		if before[0] == testNodes[2] && before[1] != after {
+			t.Errorf("Key %s should move to the next node %s, not: %s", key, before[1], after)
+		}
+	}
+}
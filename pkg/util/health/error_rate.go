@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const errorRateBuckets = 6
+
+// ErrorRate counts the results of (eg. upstream) requests over a sliding window
+// The window is split into buckets, so old results expire a bucket at a time
+type ErrorRate struct {
+	mu         sync.Mutex
+	bucketSize time.Duration
+	buckets    [errorRateBuckets]errorRateBucket
+	now        func() time.Time
+}
+
+type errorRateBucket struct {
+	start    time.Time
+	total    uint64
+	failures uint64
+}
+
+func NewErrorRate(window time.Duration) *ErrorRate {
+	return &ErrorRate{
+		bucketSize: window / errorRateBuckets,
+		now:        time.Now,
+	}
+}
+
+// bucket returns the current bucket, resetting it when it has expired
+func (e *ErrorRate) bucket(now time.Time) *errorRateBucket {
+	start := now.Truncate(e.bucketSize)
+	b := &e.buckets[(start.UnixNano()/int64(e.bucketSize))%errorRateBuckets]
+	if !b.start.Equal(start) {
+		*b = errorRateBucket{start: start}
+	}
+	return b
+}
+
+// Record the result of a request - safe to call on a nil ErrorRate
+func (e *ErrorRate) Record(failed bool) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := e.bucket(e.now())
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+// Counts returns the total and failed requests within the window
+func (e *ErrorRate) Counts() (total, failures uint64) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	oldest := now.Truncate(e.bucketSize).Add(-e.bucketSize * (errorRateBuckets - 1))
+	for _, b := range e.buckets {
+		if !b.start.Before(oldest) {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return
+}
+
+// Check fails when the ratio of failures exceeds maxRatio (ignored until there are minRequests in the window)
+func (e *ErrorRate) Check(maxRatio float64, minRequests uint64) CheckFunc {
+	return func(_ context.Context) error {
+		total, failures := e.Counts()
+		if total == 0 || total < minRequests {
+			return nil
+		}
+		if ratio := float64(failures) / float64(total); ratio > maxRatio {
+			return fmt.Errorf("%d of %d recent requests failed (%.2f > %.2f)", failures, total, ratio, maxRatio)
+		}
+		return nil
+	}
+}
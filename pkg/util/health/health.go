@@ -0,0 +1,156 @@
+// health provides the liveness and readiness endpoints
+// Liveness only shows the process is serving HTTP, readiness runs each Check and returns the detail as JSON
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+
+	DefaultCheckTimeout = 5 * time.Second
+)
+
+// CheckFunc returns an error when the dependency is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name string
+	// A failing Critical check makes the service unready, otherwise it is only reported as degraded
+	Critical bool
+	Check    CheckFunc
+}
+
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs the readiness checks concurrently, each with the Timeout
+type Checker struct {
+	Checks  []Check
+	Timeout time.Duration
+}
+
+func (c *Checker) Add(name string, critical bool, check CheckFunc) {
+	c.Checks = append(c.Checks, Check{Name: name, Critical: critical, Check: check})
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.Checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.Checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			result := CheckResult{
+				Status:     StatusOK,
+				Critical:   check.Critical,
+				DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Error = err.Error()
+				result.Status = StatusDegraded
+				if check.Critical {
+					result.Status = StatusFail
+					report.Status = StatusFail
+				} else if report.Status == StatusOK {
+					report.Status = StatusDegraded
+				}
+			}
+			report.Checks[check.Name] = result
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	code := http.StatusOK
+	if report.Status == StatusFail {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// LiveHandler always reports OK - the process is able to serve requests
+func LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, Report{Status: StatusOK})
+	}
+}
+
+// ReadyHandler runs the checks, returning a 503 if any critical check fails
+func ReadyHandler(checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, checker.Run(r.Context()))
+	}
+}
+
+// CachedCheck re-uses the last result of the check for the interval (eg. to limit requests to a rate-limited API)
+func CachedCheck(check CheckFunc, interval time.Duration) CheckFunc {
+	var mu sync.Mutex
+	var lastRun time.Time
+	var lastErr error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !lastRun.IsZero() && time.Since(lastRun) < interval {
+			return lastErr
+		}
+		lastErr = check(ctx)
+		lastRun = time.Now()
+		return lastErr
+	}
+}
+
+// HTTPCheck expects a 200 from a GET of the URL
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func readyRequest(checker *Checker) (int, Report) {
+	rec := httptest.NewRecorder()
+	ReadyHandler(checker).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	var report Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	return rec.Code, report
+}
+
+func TestReadyHandler(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("down") }
+	passing := func(context.Context) error { return nil }
+
+	testCases := []struct {
+		name     string
+		checks   []Check
+		code     int
+		expected string
+	}{
+		{"ok", []Check{{"a", true, passing}, {"b", false, passing}}, http.StatusOK, StatusOK},
+		{"degraded", []Check{{"a", true, passing}, {"b", false, failing}}, http.StatusOK, StatusDegraded},
+		{"fail", []Check{{"a", true, failing}, {"b", false, failing}}, http.StatusServiceUnavailable, StatusFail},
+	}
+
+	for _, tc := range testCases {
+		code, report := readyRequest(&Checker{Checks: tc.checks})
+		if code != tc.code {
+			t.Errorf("%s: status code should be %d, not %d", tc.name, tc.code, code)
+		}
+		if report.Status != tc.expected {
+			t.Errorf("%s: status should be %s, not %s", tc.name, tc.expected, report.Status)
+		}
+		if len(report.Checks) != len(tc.checks) {
+			t.Errorf("%s: expected %d check results, got %d", tc.name, len(tc.checks), len(report.Checks))
+		}
+	}
+
+	_, report := readyRequest(&Checker{Checks: []Check{{"a", true, failing}}})
+	if report.Checks["a"].Error != "down" {
+		t.Errorf("Check error should be reported, got \"%s\"", report.Checks["a"].Error)
+	}
+}
+
+func TestCachedCheck(t *testing.T) {
+	calls := 0
+	check := CachedCheck(func(context.Context) error {
+		calls++
+		return nil
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		check(context.Background())
+	}
+	if calls != 1 {
+		t.Errorf("Check should only run once within the interval, ran %d times", calls)
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	e := NewErrorRate(time.Minute)
+	e.now = func() time.Time { return now }
+	check := e.Check(0.5, 4)
+
+	e.Record(true)
+	e.Record(true)
+	e.Record(false)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("Error rate should be ignored below the minimum requests: %v", err)
+	}
+	e.Record(true)
+	if err := check(context.Background()); err == nil {
+		t.Error("Error rate of 0.75 should fail the check")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if total, _ := e.Counts(); total != 0 {
+		t.Errorf("Results should expire after the window, got %d", total)
+	}
+	if err := check(context.Background()); err != nil {
+		t.Errorf("Expired errors should not fail the check: %v", err)
+	}
+
+	var nilRate *ErrorRate
+	nilRate.Record(true)
+	if err := nilRate.Check(0, 0)(context.Background()); err != nil {
+		t.Errorf("A nil ErrorRate should pass: %v", err)
+	}
+}
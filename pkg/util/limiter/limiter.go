@@ -0,0 +1,129 @@
+// Package limiter bounds concurrent work by weight, with a bounded queue of waiting work
+// Work which would exceed the queue is shed (rather than waiting behind everything else)
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by Acquire when there are already maxQueue waiters
+var ErrQueueFull = errors.New("limiter queue is full")
+
+// Weighted is a weighted semaphore with a FIFO wait queue
+type Weighted struct {
+	capacity int64
+	maxQueue int
+
+	mu   sync.Mutex
+	used int64
+	// Of *waiter, in the order they arrived
+	waiters list.List
+}
+
+type waiter struct {
+	weight int64
+	ready  chan struct{}
+}
+
+// NewWeighted allows up to capacity weight at once, with up to maxQueue waiters
+func NewWeighted(capacity int64, maxQueue int) *Weighted {
+	return &Weighted{capacity: capacity, maxQueue: maxQueue}
+}
+
+// clamp the weight, so that heavy work can still run (alone)
+func (l *Weighted) clamp(weight int64) int64 {
+	if weight > l.capacity {
+		return l.capacity
+	}
+	if weight < 1 {
+		return 1
+	}
+	return weight
+}
+
+// Acquire waits for the weight to be available, until the ctx is done
+// It returns ErrQueueFull straight away when the queue is full
+func (l *Weighted) Acquire(ctx context.Context, weight int64) error {
+	weight = l.clamp(weight)
+
+	l.mu.Lock()
+	if l.waiters.Len() == 0 && l.used+weight <= l.capacity {
+		l.used += weight
+		l.mu.Unlock()
+		return nil
+	}
+	if l.waiters.Len() >= l.maxQueue {
+		l.mu.Unlock()
+		return ErrQueueFull
+	}
+	w := &waiter{weight: weight, ready: make(chan struct{})}
+	elem := l.waiters.PushBack(w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-w.ready:
+			// Acquired as the ctx was done, so it's handed back
+			l.used -= weight
+		default:
+			l.waiters.Remove(elem)
+		}
+		// Either way, the waiters behind might now fit
+		l.notify()
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Release the weight of an Acquire
+func (l *Weighted) Release(weight int64) {
+	weight = l.clamp(weight)
+
+	l.mu.Lock()
+	l.used -= weight
+	if l.used < 0 {
+		l.mu.Unlock()
+		panic("limiter: released more than acquired")
+	}
+	l.notify()
+	l.mu.Unlock()
+}
+
+// notify the waiters (in order) which now fit - mu must be held
+func (l *Weighted) notify() {
+	for {
+		front := l.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if l.used+w.weight > l.capacity {
+			// Later (lighter) waiters are not let ahead, so heavy work isn't starved
+			return
+		}
+		l.used += w.weight
+		l.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// Queued is the number of waiters
+func (l *Weighted) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiters.Len()
+}
+
+// InUse is the weight currently acquired
+func (l *Weighted) InUse() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used
+}
@@ -0,0 +1,89 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquireOrder(t *testing.T) {
+	l := NewWeighted(4, 10)
+	ctx := context.Background()
+
+	if err := l.Acquire(ctx, 3); err != nil {
+		t.Fatalf("Acquire should succeed with capacity, not: %s", err)
+	}
+
+	// A heavy waiter is queued, and the lighter waiter behind should not be let ahead
+	heavy, light := make(chan struct{}), make(chan struct{})
+	go func() {
+		l.Acquire(ctx, 3)
+		close(heavy)
+	}()
+	waitForQueued(t, l, 1)
+	go func() {
+		l.Acquire(ctx, 1)
+		close(light)
+	}()
+	waitForQueued(t, l, 2)
+
+	select {
+	case <-light:
+		t.Fatal("The light waiter should not jump the queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.Release(3)
+	<-heavy
+	<-light
+	if inUse := l.InUse(); inUse != 4 {
+		t.Errorf("Expected 4 in use, not: %d", inUse)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	l := NewWeighted(1, 1)
+	ctx := context.Background()
+
+	l.Acquire(ctx, 1)
+	go l.Acquire(ctx, 1)
+	waitForQueued(t, l, 1)
+
+	if err := l.Acquire(ctx, 1); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, not: %v", err)
+	}
+}
+
+func TestAcquireCancel(t *testing.T) {
+	l := NewWeighted(2, 10)
+	l.Acquire(context.Background(), 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, not: %v", err)
+	}
+	if queued := l.Queued(); queued != 0 {
+		t.Errorf("A cancelled waiter should leave the queue, not: %d queued", queued)
+	}
+
+	// Weight beyond the capacity is clamped, so it can run alone
+	l.Release(2)
+	if err := l.Acquire(context.Background(), 10); err != nil {
+		t.Errorf("Heavy Acquire should succeed, not: %s", err)
+	}
+	if inUse := l.InUse(); inUse != 2 {
+		t.Errorf("Expected 2 in use, not: %d", inUse)
+	}
+}
+
+func waitForQueued(t *testing.T, l *Weighted, queued int) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if l.Queued() == queued {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d queued, not: %d", queued, l.Queued())
+}
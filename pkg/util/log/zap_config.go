@@ -1,11 +1,30 @@
 package log
 
-import "go.uber.org/zap"
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapLevel is the level of the loggers from NewZapProd, which can be changed while running (eg. on a config reload)
+var ZapLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
 
 func NewZapProd() (*zap.Logger, error) {
 	zapConf := zap.NewProductionConfig()
 
+	zapConf.Level = ZapLevel
 	zapConf.Encoding = "console"
 	zapConf.EncoderConfig = zap.NewDevelopmentEncoderConfig()
 	return zapConf.Build()
 }
+
+// SetZapLevel sets the ZapLevel from a name such as "debug" or "warn"
+func SetZapLevel(level string) error {
+	return ZapLevel.UnmarshalText([]byte(level))
+}
+
+// ParseZapLevel parses a name such as "debug" or "warn" (without setting the ZapLevel)
+func ParseZapLevel(level string) (zapcore.Level, error) {
+	var l zapcore.Level
+	err := l.UnmarshalText([]byte(level))
+	return l, err
+}
@@ -0,0 +1,106 @@
+// Package reload re-applies the configuration of a running service (on SIGHUP or an admin request)
+// Only the settings which are safe to swap are applied, the rest (eg. caches, listen addresses) need a restart
+package reload
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/minotar/imgd/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var reloads = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "imgd",
+		Subsystem: "config",
+		Name:      "reloads_total",
+		Help:      "Config reloads by result.",
+	}, []string{"result"},
+)
+
+// Reloader serialises the reloads (a SIGHUP and admin request could arrive together)
+type Reloader struct {
+	mu     sync.Mutex
+	logger log.Logger
+	reload func() ([]string, error)
+	// Setting names (or prefixes ending in ".") which the reload applies
+	reloadable []string
+}
+
+// New creates a Reloader, where reload applies the new config and returns the names of the settings which changed
+func New(logger log.Logger, reload func() ([]string, error), reloadable []string) *Reloader {
+	return &Reloader{
+		logger:     logger.With("component", "reload"),
+		reload:     reload,
+		reloadable: reloadable,
+	}
+}
+
+// Reload re-reads and applies the config, warning about any changed settings which need a restart
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed, err := r.reload()
+	if err != nil {
+		reloads.WithLabelValues("error").Inc()
+		r.logger.Errorf("Config reload failed (keeping the current config): %v", err)
+		return err
+	}
+	reloads.WithLabelValues("success").Inc()
+
+	if restart := NeedsRestart(changed, r.reloadable); len(restart) > 0 {
+		r.logger.Warnf("Config reloaded, but these changes need a restart: %s", strings.Join(restart, ", "))
+	}
+	r.logger.Infof("Config reloaded (%d changed settings)", len(changed))
+	return nil
+}
+
+// Watch reloads on each SIGHUP, until stop is called
+func (r *Reloader) Watch() (stop func()) {
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-hup:
+				r.logger.Info("Received SIGHUP, reloading config")
+				r.Reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
+}
+
+// NeedsRestart filters the changed settings to those which are not reloadable
+func NeedsRestart(changed []string, reloadable []string) []string {
+	var restart []string
+	for _, name := range changed {
+		if !isReloadable(name, reloadable) {
+			restart = append(restart, name)
+		}
+	}
+	return restart
+}
+
+func isReloadable(name string, reloadable []string) bool {
+	for _, r := range reloadable {
+		if name == r || (strings.HasSuffix(r, ".") && strings.HasPrefix(name, r)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package reload
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func TestNeedsRestart(t *testing.T) {
+	reloadable := []string{"skind.use-etags", "mcclient.ttl."}
+	changed := []string{"skind.use-etags", "mcclient.ttl.uuid", "mcclient.ttl", "cache.uuid.bolt-path"}
+
+	restart := NeedsRestart(changed, reloadable)
+	if expected := []string{"mcclient.ttl", "cache.uuid.bolt-path"}; !reflect.DeepEqual(restart, expected) {
+		t.Errorf("Settings needing a restart should be %v, not %v", expected, restart)
+	}
+}
+
+func TestReload(t *testing.T) {
+	var applied int
+	failing := true
+	r := New(log.NewBuiltinLogger(1), func() ([]string, error) {
+		if failing {
+			return nil, errors.New("invalid configuration")
+		}
+		applied++
+		return []string{"cache.uuid.bolt-path"}, nil
+	}, nil)
+
+	if err := r.Reload(); err == nil {
+		t.Error("Reload should return the config error")
+	}
+
+	failing = false
+	if err := r.Reload(); err != nil || applied != 1 {
+		t.Errorf("Reload should apply the config: %v", err)
+	}
+}
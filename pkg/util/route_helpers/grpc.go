@@ -0,0 +1,61 @@
+package route_helpers
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The request ID is forwarded in the gRPC metadata (which uses lowercase keys)
+var requestIDMetadata = strings.ToLower(access_log.RequestIDHeader)
+
+// LoggingInterceptor is the gRPC equivalent of the LoggingMiddleware
+// The request ID is accepted (or generated) from the metadata, and an access log line is emitted for each RPC
+func LoggingInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDMetadata); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if !access_log.ValidRequestID(requestID) {
+			requestID = access_log.NewRequestID()
+		}
+
+		entry := access_log.NewEntry(requestID, logger)
+		resp, err := handler(access_log.NewContext(ctx, entry), req)
+
+		// Named after the method, eg. "grpc.GetSkin"
+		route := "grpc." + path.Base(info.FullMethod)
+		observeEntry(route, entry)
+		entry.Logger.With(
+			"method", info.FullMethod,
+			"route", route,
+			"code", status.Code(err).String(),
+			"cache", entry.Caches(),
+			"upstream", entry.Upstream(),
+			"fallback", entry.Fallback(),
+			"timing", entry.ServerTiming(),
+			"duration", time.Since(start),
+		).Info("access")
+		return resp, err
+	}
+}
+
+// RequestIDInterceptor forwards the request ID of the context to the gRPC server
+func RequestIDInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if requestID := access_log.RequestID(ctx); requestID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, requestID)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,50 @@
+package route_helpers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	metricsNamespace = "imgd"
+)
+
+var (
+	requestStageDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "request",
+			Name:      "stage_duration_seconds",
+			Help:      "Time (in seconds) each request spent in a stage (eg. cache, upstream, render).",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"route", "stage"},
+	)
+
+	requestUpstreamCalls = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "request",
+			Name:      "upstream_calls",
+			Help:      "Number of upstream API calls made by each request.",
+			Buckets:   []float64{0, 1, 2, 3},
+		}, []string{"route"},
+	)
+
+	requestCacheOutcome = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "request",
+			Name:      "cache_outcome_total",
+			Help:      "Final outcome of each cache lookup made by a request.",
+		}, []string{"route", "cache", "outcome"},
+	)
+
+	requestFallback = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "request",
+			Name:      "fallback_total",
+			Help:      "Requests served the default skin, by reason.",
+		}, []string{"route", "reason"},
+	)
+)
@@ -3,10 +3,12 @@ package route_helpers
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/minotar/imgd/pkg/mcclient"
 	"github.com/minotar/imgd/pkg/minecraft"
+	"github.com/minotar/imgd/pkg/util/access_log"
 	"github.com/minotar/imgd/pkg/util/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -57,17 +59,104 @@ func MuxToUserReq(r *http.Request) (userReq mcclient.UserReq) {
 	return
 }
 
+// UserType names how the user was given in the route vars
+func UserType(r *http.Request) string {
+	vars := mux.Vars(r)
+	switch {
+	case vars["username"] != "":
+		return "Username"
+	case vars["uuid"] != "":
+		return "UUID"
+	case vars["dashedUUID"] != "":
+		return "DashedUUID"
+	default:
+		return ""
+	}
+}
+
+// responseRecorder captures the status and size of the response for the access log
+// The Server-Timing header is added as the headers are written (so only covers the stages completed by then)
+type responseRecorder struct {
+	http.ResponseWriter
+	entry       *access_log.Entry
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.wroteHeader = true
+		rr.status = status
+		if serverTiming := rr.entry.ServerTiming(); serverTiming != "" {
+			rr.Header().Set("Server-Timing", serverTiming)
+		}
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// observeEntry exports the lookup report of the request as metrics
+func observeEntry(route string, entry *access_log.Entry) {
+	entry.Timings(func(stage string, duration time.Duration) {
+		requestStageDuration.WithLabelValues(route, stage).Observe(duration.Seconds())
+	})
+	requestUpstreamCalls.WithLabelValues(route).Observe(float64(len(entry.Upstream())))
+	for cacheName, outcome := range entry.CacheOutcomes() {
+		requestCacheOutcome.WithLabelValues(route, cacheName, outcome).Inc()
+	}
+	if reason := entry.Fallback(); reason != "" {
+		requestFallback.WithLabelValues(route, reason).Inc()
+	}
+}
+
+// LoggingMiddleware accepts (or generates) a request ID, which is echoed in the response and added to the request logger
+// Once the request is complete, a single access log line is emitted and the lookup report is observed by route
 func LoggingMiddleware(logger log.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger.With(
+			start := time.Now()
+
+			requestID := r.Header.Get(access_log.RequestIDHeader)
+			if !access_log.ValidRequestID(requestID) {
+				requestID = access_log.NewRequestID()
+			}
+			w.Header().Set(access_log.RequestIDHeader, requestID)
+
+			entry := access_log.NewEntry(requestID, logger)
+			rr := &responseRecorder{ResponseWriter: w, entry: entry, status: http.StatusOK}
+			next.ServeHTTP(rr, r.WithContext(access_log.NewContext(r.Context(), entry)))
+
+			var routeName string
+			if route := mux.CurrentRoute(r); route != nil {
+				routeName = route.GetName()
+			}
+			if routeName != "" {
+				// Unnamed routes (eg. /healthcheck) are not looked up
+				observeEntry(routeName, entry)
+			}
+			entry.Logger.With(
 				"method", r.Method,
-				"content_length", r.ContentLength,
-				"host", r.Host,
+				"path", r.URL.Path,
+				"route", routeName,
+				"user_type", UserType(r),
+				"status", rr.status,
+				"bytes", rr.bytes,
+				"cache", entry.Caches(),
+				"upstream", entry.Upstream(),
+				"fallback", entry.Fallback(),
+				"timing", entry.ServerTiming(),
+				"duration", time.Since(start),
 				"remote_addr", r.RemoteAddr,
-				"url", r.URL,
-			).Debugf("incoming: %v", r.Header)
-			next.ServeHTTP(w, r)
+			).Info("access")
 		})
 	}
 }
@@ -0,0 +1,93 @@
+package route_helpers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/util/access_log"
+	"github.com/minotar/imgd/pkg/util/log"
+)
+
+func TestLoggingMiddlewareRequestID(t *testing.T) {
+	var handlerID string
+	router := mux.NewRouter()
+	router.Use(LoggingMiddleware(log.NewBuiltinLogger(1)))
+	router.Path("/skin" + UsernamePath).Name("skin").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID = access_log.RequestID(r.Context())
+		access_log.FromContext(r.Context()).SetCache("uuid", "fresh")
+	})
+
+	testCases := []struct {
+		given   string
+		reuseID bool
+	}{
+		{"", false},
+		{"abc-123", true},
+		{"has spaces", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/skin/clone1018", nil)
+		if tc.given != "" {
+			req.Header.Set(access_log.RequestIDHeader, tc.given)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		respID := rec.Header().Get(access_log.RequestIDHeader)
+		if !access_log.ValidRequestID(respID) {
+			t.Errorf("Response should have a valid request ID, not \"%s\"", respID)
+		}
+		if respID != handlerID {
+			t.Errorf("Handler request ID \"%s\" should match the response \"%s\"", handlerID, respID)
+		}
+		if tc.reuseID != (respID == tc.given) {
+			t.Errorf("Given request ID \"%s\" reuse should be %t, but got \"%s\"", tc.given, tc.reuseID, respID)
+		}
+	}
+}
+
+func TestUserType(t *testing.T) {
+	router := mux.NewRouter()
+	var userType string
+	handler := func(w http.ResponseWriter, r *http.Request) { userType = UserType(r) }
+	router.Path("/u" + UsernamePath).HandlerFunc(handler)
+	router.Path("/i" + UUIDPath).HandlerFunc(handler)
+
+	for path, expected := range map[string]string{
+		"/u/clone1018":                        "Username",
+		"/i/d9135e082f2244c89cb0bee234155292": "UUID",
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if userType != expected {
+			t.Errorf("UserType of %s should be %s, not \"%s\"", path, expected, userType)
+		}
+	}
+}
+
+func TestLoggingMiddlewareServerTiming(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(LoggingMiddleware(log.NewBuiltinLogger(1)))
+	router.Path("/skin" + UsernamePath).Name("skin").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := access_log.FromContext(r.Context())
+		entry.AddTiming("cache", 1500*time.Microsecond)
+		entry.AddUpstream("GetAPIProfile", 100*time.Millisecond, nil)
+		entry.AddTiming("cache", 500*time.Microsecond)
+		w.Write([]byte("skin"))
+		// Stages after the headers are written are only observed as metrics
+		entry.AddTiming("encode", time.Millisecond)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/skin/clone1018", nil))
+
+	expected := "cache;dur=2.00, upstream;dur=100.00"
+	if serverTiming := rec.Header().Get("Server-Timing"); serverTiming != expected {
+		t.Errorf("Server-Timing should be \"%s\", not \"%s\"", expected, serverTiming)
+	}
+}
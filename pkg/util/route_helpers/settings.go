@@ -0,0 +1,52 @@
+package route_helpers
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HandlerSettings are the response settings, which are read for each request so a config reload can change them
+type HandlerSettings struct {
+	// Add open CORS headers to each response
+	CorsAllowAll bool
+	// Return an ETag based on the texture ID
+	UseETags bool
+	// Return a 302 redirect for Username requests to their related UUID
+	RedirectUsername bool
+	// Cache TTL returned to clients
+	CacheControlTTL time.Duration
+	// Cache TTL returned to clients when the skin lookup falls back to Steve
+	ErrorCacheControlTTL time.Duration
+}
+
+// LiveSettings holds the current HandlerSettings, which are swapped atomically
+type LiveSettings struct {
+	v atomic.Value
+}
+
+func NewLiveSettings(settings HandlerSettings) *LiveSettings {
+	l := &LiveSettings{}
+	l.Store(settings)
+	return l
+}
+
+func (l *LiveSettings) Load() HandlerSettings {
+	return l.v.Load().(HandlerSettings)
+}
+
+func (l *LiveSettings) Store(settings HandlerSettings) {
+	l.v.Store(settings)
+}
+
+// CorsMiddleware adds the CORS headers while CorsAllowAll is enabled
+func (l *LiveSettings) CorsMiddleware(next http.Handler) http.Handler {
+	cors := CorsHandler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.Load().CorsAllowAll {
+			cors.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,214 @@
+// tracing sets up OpenTelemetry and provides the helpers used to create spans
+// With no exporter configured, the global no-op TracerProvider is left in place
+// so spans cost next to nothing
+package tracing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/minotar/imgd/pkg/util/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	EXPORTER_LIST = "{none|stdout|otlp}"
+
+	instrumentationPrefix = "github.com/minotar/imgd/"
+)
+
+type Config struct {
+	Exporter string
+	// OTLP/HTTP collector host:port (the OTEL_EXPORTER_OTLP_* env vars are also respected)
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// Fraction of new traces to sample (propagated traces follow their parent)
+	SampleRatio float64
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.Exporter, "tracing.exporter", "none", "Trace exporter to use "+EXPORTER_LIST)
+	f.StringVar(&c.OTLPEndpoint, "tracing.otlp-endpoint", "", "OTLP/HTTP collector host:port (default is localhost:4318)")
+	f.BoolVar(&c.OTLPInsecure, "tracing.otlp-insecure", false, "Use plain HTTP to the OTLP collector")
+	f.Float64Var(&c.SampleRatio, "tracing.sample-ratio", 1, "Ratio of new traces to sample")
+}
+
+func (c *Config) Validate() error {
+	switch strings.ToLower(c.Exporter) {
+	case "", "none", "stdout", "otlp":
+	default:
+		return fmt.Errorf("tracing.exporter \"%s\" should be one of %s", c.Exporter, EXPORTER_LIST)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample-ratio %v should be between 0 and 1", c.SampleRatio)
+	}
+	return nil
+}
+
+func newExporter(cfg Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter \"%s\" (expected %s)", cfg.Exporter, EXPORTER_LIST)
+	}
+}
+
+// Init installs the global TracerProvider and W3C trace context propagator
+// The returned func flushes and stops the exporter
+func Init(cfg Config, serviceName string, logger log.Logger) (func(context.Context) error, error) {
+	// The propagator is always set, so trace context passes through a service without an exporter
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.Exporter == "" || strings.ToLower(cfg.Exporter) == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warnf("Tracing error: %v", err)
+	}))
+
+	logger.Infof("Tracing with the %s exporter", cfg.Exporter)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns a named tracer from the global TracerProvider (eg. "mcclient")
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(instrumentationPrefix + name)
+}
+
+// EndSpan records the error (if any) and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statusRecorder captures the response status for the span
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware creates a server span for each request, continuing any trace context from the request headers
+// The span is named after the route template (rather than the path) to keep the cardinality low
+func Middleware(serviceName string) mux.MiddlewareFunc {
+	tracer := Tracer(serviceName)
+	propagator := otel.GetTextMapPropagator
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := r.URL.Path
+			if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+				if tmpl, err := currentRoute.GetPathTemplate(); err == nil {
+					route = tmpl
+				}
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethodKey.String(r.Method),
+					semconv.HTTPRouteKey.String(route),
+					semconv.HTTPTargetKey.String(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sr, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(sr.status))
+			if sr.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sr.status))
+			}
+		})
+	}
+}
+
+// Transport creates a client span for each request and injects the trace context into the headers
+func Transport(name string, base http.RoundTripper) http.RoundTripper {
+	return &transport{tracer: Tracer(name), base: base}
+}
+
+type transport struct {
+	tracer trace.Tracer
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(r.Context(), "HTTP "+r.Method+" "+r.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(r.Method),
+			semconv.HTTPURLKey.String(r.URL.String()),
+		),
+	)
+
+	// RoundTrippers should not modify the original request
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		EndSpan(span, err)
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	return resp, nil
+}
+
+// CacheAttributes are the common attributes of a cache operation span
+func CacheAttributes(cacheName, operation string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("cache.name", cacheName),
+		attribute.String("cache.operation", operation),
+	)
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder() *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return sr
+}
+
+func TestPropagation(t *testing.T) {
+	sr := setupRecorder()
+
+	var serverSpan trace.SpanContext
+	router := mux.NewRouter()
+	router.Use(Middleware("server"))
+	router.Path("/skin/{username}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, parent := Tracer("client").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/skin/clone1018", nil)
+	client := &http.Client{Transport: Transport("client", http.DefaultTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if serverSpan.TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("Server span should continue the client trace: %s != %s", serverSpan.TraceID(), parent.SpanContext().TraceID())
+	}
+
+	names := map[string]bool{}
+	for _, span := range sr.Ended() {
+		names[span.Name()] = true
+	}
+	for _, name := range []string{"parent", "HTTP GET " + req.URL.Host, "GET /skin/{username}"} {
+		if !names[name] {
+			t.Errorf("Span \"%s\" was not recorded: %v", name, names)
+		}
+	}
+}